@@ -0,0 +1,146 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/metrics"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const accessLogTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  getUser:
+    path: /users/{id}
+    server: testServer
+    timeout: 1000
+    retry_count: 1
+    access_log:
+      level: debug
+  createUser:
+    method: POST
+    path: /users
+    server: testServer
+    timeout: 1000
+    access_log:
+      error_level: error
+      max_body_bytes: 8
+  getOrder:
+    path: /orders
+    server: testServer
+    timeout: 1000
+    access_log:
+      success_sample_rate: 0
+  getItem:
+    path: /items
+    server: testServer
+    timeout: 1000
+  createOrder:
+    method: POST
+    path: /orders
+    server: testServer
+    timeout: 1000
+    request_compression: gzip
+    access_log:
+      max_body_bytes: 8
+`
+
+// Access log entries logged since last call - other logs are dropped
+func takeAccessLogs(logs *observer.ObservedLogs) []observer.LoggedEntry {
+	entries := make([]observer.LoggedEntry, 0)
+	for _, entry := range logs.TakeAll() {
+		if entry.Message == "http call" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func Test_AccessLog(t *testing.T) {
+	var calls int32
+	core, logs := observer.New(zapcore.DebugLevel)
+	cf := gox.NewCrossFunction(zap.New(core), metrics.NoOpMetric())
+	config, closeFunc := testserver.Start(t, accessLogTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			_, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"error": "bad name"}`)
+			return
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": 1}`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Successful call after a retry - logged at debug (level of this api)
+	_, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").WithPathParam("id", 10).Build())
+	assert.NoError(t, err)
+	entries := takeAccessLogs(logs)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+	assert.Equal(t, "goxHttp.access", entries[0].LoggerName)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "getUser", fields["api"])
+	assert.Equal(t, "testServer", fields["server"])
+	assert.Equal(t, "GET", fields["method"])
+	assert.True(t, strings.HasSuffix(fields["url"].(string), "/users/{id}"))
+	assert.Equal(t, int64(200), fields["status"])
+	assert.Equal(t, int64(2), fields["attempts"])
+	assert.Equal(t, int64(9), fields["response_bytes"])
+	assert.Equal(t, "none", fields["error_code"])
+	assert.NotNil(t, fields["duration"])
+	_, hasBody := fields["response_body"]
+	assert.False(t, hasBody)
+
+	// Failed call - logged at error level with body up to max_body_bytes
+	_, err = goxHttpCtx.Execute(ctx, "createUser", command.NewGoxRequestBuilder("createUser").WithBody(`{"name": "John"}`).Build())
+	assert.Error(t, err)
+	entries = takeAccessLogs(logs)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+	fields = entries[0].ContextMap()
+	assert.Equal(t, "POST", fields["method"])
+	assert.Equal(t, int64(400), fields["status"])
+	assert.Equal(t, int64(1), fields["attempts"])
+	assert.Equal(t, int64(16), fields["request_bytes"])
+	assert.Equal(t, "server_response_with_error", fields["error_code"])
+	assert.Equal(t, `{"name":`, fields["request_body"])
+	assert.Equal(t, `{"error"`, fields["response_body"])
+
+	// Successful calls are not logged with success_sample_rate=0, and apis without access_log are not logged
+	_, err = goxHttpCtx.Execute(ctx, "getOrder", command.NewGoxRequestBuilder("getOrder").Build())
+	assert.NoError(t, err)
+	_, err = goxHttpCtx.Execute(ctx, "getItem", command.NewGoxRequestBuilder("getItem").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(takeAccessLogs(logs)))
+
+	// Request body is logged before it is compressed
+	_, err = goxHttpCtx.Execute(ctx, "createOrder", command.NewGoxRequestBuilder("createOrder").WithBody(`{"id": 10}`).Build())
+	assert.Error(t, err)
+	entries = takeAccessLogs(logs)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, `{"id": 1`, entries[0].ContextMap()["request_body"])
+}
@@ -17,6 +17,37 @@ type GoxHttpContext interface {
 	ReloadApi(apiToReload string) error
 	Execute(ctx context.Context, api string, request *command.GoxRequest) (*command.GoxResponse, error)
 	ExecuteAsync(ctx context.Context, api string, request *command.GoxRequest) chan *command.GoxResponse
+
+	// ExecuteStream executes the request without reading the response body. The body is given in
+	// GoxResponse.BodyStream and the caller must close it.
+	//
+	// Api timeout applies only till the response headers are received - the body can be read as long as ctx is valid.
+	// Use command.NewNdJsonDecoder or command.NewJsonArrayDecoder to decode the body one value at a time
+	ExecuteStream(ctx context.Context, api string, request *command.GoxRequest) (*command.GoxResponse, error)
+
+	// ExecuteSse connects to a server-sent events api (api must have "sse" enabled). The stream reconnects
+	// automatically if connection is lost, and it is closed when ctx is done or Close is called
+	ExecuteSse(ctx context.Context, api string, request *command.GoxRequest) (command.SseStream, error)
+
+	// OpenWebsocket opens a WebSocket connection to an api (api must have "websocket" enabled). Host, headers and auth
+	// of the api are used. The connection reconnects automatically, and it is closed when ctx is done or Close is called
+	OpenWebsocket(ctx context.Context, api string, request *command.GoxRequest) (command.WebsocketConnection, error)
+
+	// Paginate gives an iterator over the pages (or items) of an api with "pagination" config. Pages are fetched
+	// lazily using Execute, so every page goes through interceptors, circuit breaker and retries. Iteration stops
+	// when there are no more pages, "max_pages" are read, or ctx is done
+	Paginate(ctx context.Context, api string, request *command.GoxRequest) (command.PageIterator, error)
+
+	// ExecuteAll runs the named calls in parallel using Execute, with concurrency cap and deadline given in options.
+	// Result has the response and error of every call. With options.FailFast the first error is returned and other
+	// calls are cancelled; otherwise all calls run and returned error is nil
+	ExecuteAll(ctx context.Context, options *command.BatchOptions, calls ...*command.BatchCall) (*command.BatchResult, error)
+
+	// AddInterceptor registers interceptors which run for all APIs. These should be added before making any call.
+	//
+	// Interceptors run in the following order: global -> server -> api. All of them run outside the circuit breaker
+	// and retries i.e. they see a request once, and they see the final response after all retries are done
+	AddInterceptor(interceptors ...command.Interceptor)
 }
 
 // Create a new http context to be used
@@ -26,7 +57,7 @@ func NewGoxHttpContext(cf gox.CrossFunction, config *command.Config) (GoxHttpCon
 		logger:        cf.Logger().Named("gox-http"),
 		config:        config,
 		commands:      map[string]command.Command{},
-		lock:          &sync.Mutex{},
+		lock:          &sync.RWMutex{},
 	}
 
 	if err := c.setup(); err != nil {
@@ -20,11 +20,14 @@ type goxHttpContextImpl struct {
 	config   *command.Config
 	commands map[string]command.Command
 	timeouts map[string]int
-	lock     *sync.Mutex
+	lock     *sync.RWMutex
+
+	interceptors []command.Interceptor
+	coalescers   map[string]*command.Coalescer
 }
 
 func (g *goxHttpContextImpl) Execute(ctx context.Context, api string, request *command.GoxRequest) (*command.GoxResponse, error) {
-	if cmd, ok := g.commands[api]; !ok {
+	if cmd, timeout, ok := g.findCommand(api); !ok {
 		return nil, &command.GoxHttpError{
 			Err:        ErrCommandNotRegisteredForApi,
 			StatusCode: http.StatusBadRequest,
@@ -34,13 +37,161 @@ func (g *goxHttpContextImpl) Execute(ctx context.Context, api string, request *c
 		}
 	} else {
 
+		// Body of a streaming request is read by the caller after we return, so we can't put a timeout on the
+		// whole call - the command applies the api timeout till response headers are received
+		if request != nil && request.Stream {
+			return g.handler(api, cmd)(ctx, request)
+		}
+
 		// Setup context with timeout
-		timeout := g.timeouts[api]
 		newCtx, ctxCancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Millisecond)
 		defer ctxCancel()
 
-		return cmd.Execute(newCtx, request)
+		return g.handler(api, cmd)(newCtx, request)
+	}
+}
+
+func (g *goxHttpContextImpl) ExecuteStream(ctx context.Context, api string, request *command.GoxRequest) (*command.GoxResponse, error) {
+	streamRequest := command.GoxRequest{}
+	if request != nil {
+		streamRequest = *request
 	}
+	streamRequest.Stream = true
+	return g.Execute(ctx, api, &streamRequest)
+}
+
+func (g *goxHttpContextImpl) ExecuteSse(ctx context.Context, api string, request *command.GoxRequest) (command.SseStream, error) {
+	cmd, _, ok := g.findCommand(api)
+	if !ok {
+		return nil, &command.GoxHttpError{
+			Err:        ErrCommandNotRegisteredForApi,
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("command to execute not found: name=%s", api),
+			ErrorCode:  "command_not_found",
+			Body:       nil,
+		}
+	}
+
+	apiConfig, server, err := g.findApi(api)
+	if err != nil {
+		return nil, errors.Wrap(err, "server not found for api=%s", api)
+	}
+	if apiConfig.Sse == nil || !apiConfig.Sse.Enabled {
+		return nil, &command.GoxHttpError{
+			Err:        errors.New("sse is not enabled for api=%s", api),
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("sse is not enabled for api: name=%s", api),
+			ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+		}
+	}
+	return httpCommand.NewSseStream(ctx, g.CrossFunction, server, apiConfig, request, g.handler(api, cmd))
+}
+
+func (g *goxHttpContextImpl) OpenWebsocket(ctx context.Context, api string, request *command.GoxRequest) (command.WebsocketConnection, error) {
+	apiConfig, server, err := g.findApi(api)
+	if apiConfig == nil {
+		return nil, &command.GoxHttpError{
+			Err:        ErrCommandNotRegisteredForApi,
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("command to execute not found: name=%s", api),
+			ErrorCode:  "command_not_found",
+			Body:       nil,
+		}
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "server not found for api=%s", api)
+	}
+	if apiConfig.Websocket == nil || !apiConfig.Websocket.Enabled {
+		return nil, &command.GoxHttpError{
+			Err:        errors.New("websocket is not enabled for api=%s", api),
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("websocket is not enabled for api: name=%s", api),
+			ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+		}
+	}
+	return httpCommand.NewWebsocketConnection(ctx, g.CrossFunction, server, apiConfig, request)
+}
+
+func (g *goxHttpContextImpl) Paginate(ctx context.Context, api string, request *command.GoxRequest) (command.PageIterator, error) {
+	apiConfig, _, _ := g.findApi(api)
+	if apiConfig == nil {
+		return nil, &command.GoxHttpError{
+			Err:        ErrCommandNotRegisteredForApi,
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("command to execute not found: name=%s", api),
+			ErrorCode:  "command_not_found",
+			Body:       nil,
+		}
+	}
+	iterator, err := command.NewPageIterator(ctx, apiConfig, request, func(ctx context.Context, request *command.GoxRequest) (*command.GoxResponse, error) {
+		return g.Execute(ctx, api, request)
+	})
+	if err != nil {
+		return nil, &command.GoxHttpError{
+			Err:        err,
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("pagination is not configured correctly for api: name=%s", api),
+			ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+		}
+	}
+	return iterator, nil
+}
+
+func (g *goxHttpContextImpl) ExecuteAll(ctx context.Context, options *command.BatchOptions, calls ...*command.BatchCall) (*command.BatchResult, error) {
+	return command.ExecuteAll(ctx, options, calls, g.Execute)
+}
+
+func (g *goxHttpContextImpl) AddInterceptor(interceptors ...command.Interceptor) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.interceptors = append(g.interceptors, interceptors...)
+}
+
+// Command and timeout of an api - read under lock because ReloadApi can change them
+func (g *goxHttpContextImpl) findCommand(api string) (command.Command, int, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	cmd, ok := g.commands[api]
+	return cmd, g.timeouts[api], ok
+}
+
+// Config of an api and its server - api is nil if it is not found
+func (g *goxHttpContextImpl) findApi(api string) (*command.Api, *command.Server, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	apiConfig, ok := g.config.Apis[api]
+	if !ok {
+		return nil, nil, errors.New("api not found: name=%s", api)
+	}
+	server, err := g.config.FindServerByName(apiConfig.Server)
+	return apiConfig, server, err
+}
+
+// Wrap command with request coalescing (if enabled), and global, server and api interceptors (in this order)
+func (g *goxHttpContextImpl) handler(apiName string, cmd command.Command) command.Handler {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	handler := cmd.Execute
+	if coalescer := g.coalescers[apiName]; coalescer != nil {
+		handler = func(ctx context.Context, request *command.GoxRequest) (*command.GoxResponse, error) {
+			return coalescer.Execute(ctx, request, cmd.Execute)
+		}
+	}
+
+	interceptors := make([]command.Interceptor, 0, len(g.interceptors))
+	interceptors = append(interceptors, g.interceptors...)
+	if api, ok := g.config.Apis[apiName]; ok {
+		if server, err := g.config.FindServerByName(api.Server); err == nil {
+			interceptors = append(interceptors, server.Interceptors...)
+		}
+		interceptors = append(interceptors, api.Interceptors...)
+	}
+	if len(interceptors) == 0 {
+		return handler
+	}
+	return command.ChainInterceptors(handler, interceptors...)
 }
 
 func (g *goxHttpContextImpl) ExecuteAsync(ctx context.Context, api string, request *command.GoxRequest) chan *command.GoxResponse {
@@ -53,6 +204,7 @@ func (g *goxHttpContextImpl) setup() error {
 
 	// Setup timeouts
 	g.timeouts = map[string]int{}
+	g.coalescers = map[string]*command.Coalescer{}
 
 	for apiName, api := range g.config.Apis {
 
@@ -77,6 +229,9 @@ func (g *goxHttpContextImpl) setup() error {
 		g.commands[apiName] = cmd
 		// g.timeouts[apiName] = api.Timeout
 		g.timeouts[apiName] = api.GetTimeoutWithRetryIncluded()
+		if g.coalescers[apiName], err = command.NewCoalescer(server, api); err != nil {
+			return errors.Wrap(err, "failed to create coalescer: api=%s", apiName)
+		}
 
 	}
 	return nil
@@ -125,9 +280,15 @@ func (g *goxHttpContextImpl) addNewAPi(api *command.Api) error {
 		return errors.Wrap(err, "failed to create http command: api=%s", apiName)
 	}
 
+	coalescer, err := command.NewCoalescer(server, api)
+	if err != nil {
+		return errors.Wrap(err, "failed to create coalescer: api=%s", apiName)
+	}
+
 	// Store this http command to use
 	g.commands[apiName] = cmd
 	g.timeouts[apiName] = api.Timeout
+	g.coalescers[apiName] = coalescer
 
 	return nil
 }
@@ -156,10 +317,15 @@ func (g *goxHttpContextImpl) updateAPi(api *command.Api) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to create http command: api=%s", apiName)
 	}
+	coalescer, err := command.NewCoalescer(server, api)
+	if err != nil {
+		return errors.Wrap(err, "failed to create coalescer: api=%s", apiName)
+	}
 
 	// Store this http command to use
 	g.commands[apiName] = updatedCommand
 	g.timeouts[apiName] = api.Timeout
+	g.coalescers[apiName] = coalescer
 
 	return nil
 }
@@ -13,8 +13,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
-	"strings"
 	"testing"
 	"time"
 )
@@ -37,8 +35,7 @@ func Test_Hystrix_Update(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 
 	// Setup goHttp context
 	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
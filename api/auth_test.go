@@ -0,0 +1,252 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var authTestConfig = `
+servers:
+  testServer:
+    host: localhost
+    port: 9123
+    auth:
+      type: basic
+      username: user
+      password: "secret:GOX_HTTP_TEST_PASSWORD"
+
+apis:
+  basic_auth:
+    path: /basic
+    server: testServer
+    timeout: 1000
+  api_key_auth:
+    path: /api_key
+    server: testServer
+    timeout: 1000
+    auth:
+      type: api_key
+      in: query
+      name: key
+      value: "env:string: prod=prod_key; default=dev_key"
+  no_auth:
+    path: /none
+    server: testServer
+    timeout: 1000
+    auth:
+      type: none
+  oauth2_auth:
+    path: /oauth2
+    server: testServer
+    timeout: 1000
+    auth:
+      type: oauth2_client_credentials
+      client_id: client
+      client_secret: client_secret
+      scopes: read,write
+  oauth2_websocket:
+    path: /ws
+    server: testServer
+    timeout: 100
+    auth:
+      type: oauth2_client_credentials
+      token_url: http://localhost:9123/token
+      client_id: client
+      client_secret: client_secret
+    websocket:
+      ping_interval_ms: -1
+`
+
+func Test_Auth_StaticProviders(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	_ = os.Setenv("GOX_HTTP_TEST_PASSWORD", "password")
+	defer os.Unsetenv("GOX_HTTP_TEST_PASSWORD")
+
+	// Send back the auth we got
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		data := gox.StringObjectMap{"user": user, "password": password, "key": r.URL.Query().Get("key"), "authorization": r.Header.Get("Authorization")}
+		_, _ = fmt.Fprintln(w, serialization.StringifySuppressError(data, "{}"))
+	}))
+	defer ts.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(authTestConfig, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+	config.Apis["oauth2_auth"].Auth.TokenUrl = ts.URL + "/token"
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Server auth is used
+	response, err := goxHttpCtx.Execute(ctx, "basic_auth", command.NewGoxRequestBuilder("basic_auth").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "user", response.AsStringObjectMapOrEmpty().StringOrEmpty("user"))
+	assert.Equal(t, "password", response.AsStringObjectMapOrEmpty().StringOrEmpty("password"))
+
+	// Api overrides server auth
+	response, err = goxHttpCtx.Execute(ctx, "api_key_auth", command.NewGoxRequestBuilder("api_key_auth").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "prod_key", response.AsStringObjectMapOrEmpty().StringOrEmpty("key"))
+	assert.Equal(t, "", response.AsStringObjectMapOrEmpty().StringOrEmpty("user"))
+
+	// Api disables server auth
+	response, err = goxHttpCtx.Execute(ctx, "no_auth", command.NewGoxRequestBuilder("no_auth").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "", response.AsStringObjectMapOrEmpty().StringOrEmpty("authorization"))
+
+	// Header set in request is not overwritten
+	response, err = goxHttpCtx.Execute(ctx, "basic_auth", command.NewGoxRequestBuilder("basic_auth").WithHeader("Authorization", "Bearer abcd").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer abcd", response.AsStringObjectMapOrEmpty().StringOrEmpty("authorization"))
+}
+
+func Test_Auth_OAuth2_TokenIsCachedAndRefreshedOn401(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	_ = os.Setenv("GOX_HTTP_TEST_PASSWORD", "password")
+	defer os.Unsetenv("GOX_HTTP_TEST_PASSWORD")
+
+	// Stub token server - every call gives a new token. Api server only accepts the latest token
+	var tokenCalls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			_ = r.ParseForm()
+			assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "client", r.PostForm.Get("client_id"))
+			assert.Equal(t, "client_secret", r.PostForm.Get("client_secret"))
+			assert.Equal(t, "read write", r.PostForm.Get("scope"))
+			count := atomic.AddInt32(&tokenCalls, 1)
+			_, _ = fmt.Fprintf(w, `{"access_token": "token_%d", "token_type": "bearer", "expires_in": 3600}`, count)
+			return
+		}
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token_%d", atomic.LoadInt32(&tokenCalls)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintln(w, `{"status": "ok"}`)
+	}))
+	defer ts.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(authTestConfig, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+	config.Apis["oauth2_auth"].Auth.TokenUrl = ts.URL + "/token"
+	config.Apis["oauth2_auth"].DisableHystrix = true
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Token is fetched once and reused
+	for i := 0; i < 3; i++ {
+		response, err := goxHttpCtx.Execute(ctx, "oauth2_auth", command.NewGoxRequestBuilder("oauth2_auth").Build())
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", response.AsStringObjectMapOrEmpty().StringOrEmpty("status"))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokenCalls))
+
+	// Token is revoked on server - we get 401, fetch a new token and retry once
+	atomic.AddInt32(&tokenCalls, 1)
+	response, err := goxHttpCtx.Execute(ctx, "oauth2_auth", command.NewGoxRequestBuilder("oauth2_auth").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", response.AsStringObjectMapOrEmpty().StringOrEmpty("status"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&tokenCalls))
+}
+
+func Test_Auth_OAuth2_ConcurrentRequestsWith401RefreshTokenOnce(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	_ = os.Setenv("GOX_HTTP_TEST_PASSWORD", "password")
+	defer os.Unsetenv("GOX_HTTP_TEST_PASSWORD")
+
+	// Token server gives a new token on every call. Api server only accepts the latest token, and sends 401 a bit late
+	// so that all requests with the revoked token get 401
+	var tokenCalls int32
+	config, closeFunc := testserver.Start(t, authTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			count := atomic.AddInt32(&tokenCalls, 1)
+			_, _ = fmt.Fprintf(w, `{"access_token": "token_%d", "expires_in": 3600}`, count)
+			return
+		}
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token_%d", atomic.LoadInt32(&tokenCalls)) {
+			time.Sleep(100 * time.Millisecond)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintln(w, `{"status": "ok"}`)
+	}))
+	defer closeFunc()
+	config.Apis["oauth2_auth"].Auth.TokenUrl = config.Servers[testserver.ServerName].Url + "/token"
+	config.Apis["oauth2_auth"].DisableHystrix = true
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	_, err = goxHttpCtx.Execute(ctx, "oauth2_auth", command.NewGoxRequestBuilder("oauth2_auth").Build())
+	assert.NoError(t, err)
+
+	// Token is revoked - only the first 401 drops the token, others use the refreshed token
+	atomic.AddInt32(&tokenCalls, 1)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := goxHttpCtx.Execute(ctx, "oauth2_auth", command.NewGoxRequestBuilder("oauth2_auth").Build())
+			if assert.NoError(t, err) {
+				assert.Equal(t, "ok", response.AsStringObjectMapOrEmpty().StringOrEmpty("status"))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&tokenCalls))
+}
+
+func Test_Auth_OAuth2_TokenUrlCallHasTimeout(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	_ = os.Setenv("GOX_HTTP_TEST_PASSWORD", "password")
+	defer os.Unsetenv("GOX_HTTP_TEST_PASSWORD")
+	config, closeFunc := testserver.Start(t, authTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			time.Sleep(time.Second)
+		}
+		_, _ = fmt.Fprintln(w, `{"access_token": "token_1"}`)
+	}))
+	defer closeFunc()
+	config.Apis["oauth2_auth"].Auth.TokenUrl = config.Servers[testserver.ServerName].Url + "/token"
+	config.Apis["oauth2_websocket"].Auth.TokenUrl = config.Servers[testserver.ServerName].Url + "/token"
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	// Token url is called with timeout of the api - even if ctx has no deadline (e.g. websocket connection)
+	start := time.Now()
+	_, err = goxHttpCtx.OpenWebsocket(context.Background(), "oauth2_websocket", nil)
+	goxErr, ok := err.(*command.GoxHttpError)
+	if assert.True(t, ok, "expected GoxHttpError, got %v", err) {
+		assert.Equal(t, command.ErrorCodeFailedToFetchAuthToken, goxErr.ErrorCode)
+	}
+	assert.True(t, time.Since(start) < 800*time.Millisecond, "token url call must time out")
+}
@@ -0,0 +1,91 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	httpCommand "github.com/devlibx/gox-http/command/http"
+	"github.com/devlibx/gox-http/testhelper"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_ExecuteAll(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	httpCommand.HystrixConfigMap = gox.StringObjectMap{}
+	hystrix.Flush()
+
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		switch id {
+		case "bad":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case "slow":
+			select {
+			case <-time.After(2 * time.Second):
+			case <-done:
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id": "%s"}`, id)
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+	config.Apis["delay_timeout_10"].Timeout = 3000
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	call := func(name string, id string) *command.BatchCall {
+		return &command.BatchCall{
+			Name:    name,
+			Api:     "delay_timeout_10",
+			Request: command.NewGoxRequestBuilder("delay_timeout_10").WithQueryParam("id", id).Build(),
+		}
+	}
+
+	// Collect-all - every call runs and errors are in the result
+	result, err := goxHttpCtx.ExecuteAll(context.Background(), &command.BatchOptions{Concurrency: 2},
+		call("first", "1"), call("second", "bad"), call("third", "3"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "third"}, result.Names)
+	response, err := result.Get("third")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", response.AsStringObjectMapOrEmpty().StringOrEmpty("id"))
+	_, err = result.Get("second")
+	assert.Equal(t, http.StatusInternalServerError, err.(*command.GoxHttpError).StatusCode)
+	assert.Equal(t, 1, len(result.Errors()))
+
+	// Fail-fast - slow call is cancelled when other call fails
+	start := time.Now()
+	result, err = goxHttpCtx.ExecuteAll(context.Background(), &command.BatchOptions{FailFast: true},
+		call("slow", "slow"), call("bad", "bad"))
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+	_, err = result.Get("slow")
+	assert.True(t, err.(*command.GoxHttpError).IsBatchCancelledError())
+
+	// Batch deadline
+	result, err = goxHttpCtx.ExecuteAll(context.Background(), &command.BatchOptions{Timeout: 100 * time.Millisecond},
+		call("fast", "fast"), call("slow", "slow"))
+	assert.NoError(t, err)
+	_, err = result.Get("fast")
+	assert.NoError(t, err)
+	_, err = result.Get("slow")
+	assert.Error(t, err)
+}
@@ -0,0 +1,230 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const cacheTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  getUser:
+    path: /users
+    server: testServer
+    timeout: 1000
+    cache:
+      enabled: true
+`
+
+func Test_Cache_FreshResponseIsServedFromCache(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, cacheTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = fmt.Fprintf(w, `{"count": %d}`, count)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	response, err := goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, command.CacheStatusMiss, response.CacheStatus)
+
+	response, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, command.CacheStatusHit, response.CacheStatus)
+	assert.Equal(t, 1, response.AsStringObjectMapOrEmpty().IntOrZero("count"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Different query param is a different cache entry
+	response, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").WithQueryParam("id", 1).Build())
+	assert.NoError(t, err)
+	assert.Equal(t, command.CacheStatusMiss, response.CacheStatus)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_Cache_RevalidateWithETag(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, cacheTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"status": "ok"}`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	response, err := goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, command.CacheStatusMiss, response.CacheStatus)
+
+	response, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, command.CacheStatusRevalidated, response.CacheStatus)
+	assert.Equal(t, "ok", response.AsStringObjectMapOrEmpty().StringOrEmpty("status"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_Cache_StaleIfErrorAndStaleWhileRevalidate(t *testing.T) {
+	var calls int32
+	var fail int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, cacheTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=1, stale-while-revalidate=1, stale-if-error=60")
+		_, _ = fmt.Fprintf(w, `{"count": %d}`, count)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxC()
+
+	response, err := goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, command.CacheStatusMiss, response.CacheStatus)
+
+	// Expired but within stale-while-revalidate - stale response is returned and revalidated in background
+	time.Sleep(1100 * time.Millisecond)
+	response, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, command.CacheStatusStale, response.CacheStatus)
+	assert.Equal(t, 1, response.AsStringObjectMapOrEmpty().IntOrZero("count"))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 2 }, time.Second, 10*time.Millisecond)
+
+	// Server is failing and response is past stale-while-revalidate - stale-if-error gives the last response
+	atomic.StoreInt32(&fail, 1)
+	time.Sleep(2100 * time.Millisecond)
+	response, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, command.CacheStatusStale, response.CacheStatus)
+	assert.Equal(t, 2, response.AsStringObjectMapOrEmpty().IntOrZero("count"))
+}
+
+func Test_Cache_ResponseIsNotSharedAcrossCredentials(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, cacheTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = fmt.Fprintf(w, `{"user": "%s"}`, r.Header.Get("Authorization"))
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	response, err := goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").WithHeader("Authorization", "Bearer user1").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, command.CacheStatusMiss, response.CacheStatus)
+
+	// Same credentials - served from cache
+	response, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").WithHeader("Authorization", "Bearer user1").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, command.CacheStatusHit, response.CacheStatus)
+	assert.Equal(t, "Bearer user1", response.AsStringObjectMapOrEmpty().StringOrEmpty("user"))
+
+	// Other credentials, or no credentials - response of user1 must not be returned
+	response, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").WithHeader("Authorization", "Bearer user2").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, command.CacheStatusMiss, response.CacheStatus)
+	assert.Equal(t, "Bearer user2", response.AsStringObjectMapOrEmpty().StringOrEmpty("user"))
+
+	response, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, command.CacheStatusMiss, response.CacheStatus)
+	assert.Equal(t, "", response.AsStringObjectMapOrEmpty().StringOrEmpty("user"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func Test_Cache_StaleIfErrorIsNotUsedWithMustRevalidateOrNoCache(t *testing.T) {
+	for _, cacheControl := range []string{"max-age=1, must-revalidate, stale-if-error=60", "no-cache, stale-if-error=60"} {
+		t.Run(cacheControl, func(t *testing.T) {
+			var fail int32
+			cf, _ := test.MockCf(t)
+			config, closeFunc := testserver.Start(t, cacheTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.LoadInt32(&fail) == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Cache-Control", cacheControl)
+				w.Header().Set("ETag", `"v1"`)
+				_, _ = fmt.Fprint(w, `{"status": "ok"}`)
+			}))
+			defer closeFunc()
+			goxHttpCtx, err := NewGoxHttpContext(cf, config)
+			assert.NoError(t, err)
+
+			ctx, ctxC := context.WithTimeout(context.Background(), 5*time.Second)
+			defer ctxC()
+
+			response, err := goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+			assert.NoError(t, err)
+			assert.Equal(t, command.CacheStatusMiss, response.CacheStatus)
+
+			// Server is failing after response expired - error is returned instead of stale response
+			atomic.StoreInt32(&fail, 1)
+			time.Sleep(1100 * time.Millisecond)
+			_, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_Cache_StaleIfErrorIsNotUsedForNoCacheRequest(t *testing.T) {
+	var fail int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, cacheTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60, stale-if-error=60")
+		_, _ = fmt.Fprint(w, `{"status": "ok"}`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	_, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+	assert.NoError(t, err)
+
+	atomic.StoreInt32(&fail, 1)
+	_, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").WithHeader("Cache-Control", "no-cache").Build())
+	assert.Error(t, err)
+}
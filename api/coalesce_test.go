@@ -0,0 +1,322 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const coalesceTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  getUsers:
+    path: /users
+    server: testServer
+    timeout: 1000
+    coalesce: true
+    concurrency: 1
+  getStatus:
+    path: /status
+    server: testServer
+    timeout: 1000
+    coalesce: true
+    concurrency: 3
+  getUser:
+    path: /users/{id}
+    server: testServer
+    timeout: 1000
+    coalesce: true
+    concurrency: 3
+  getUserFields:
+    path: /users/{id}
+    server: testServer
+    timeout: 1000
+    coalesce: true
+    concurrency: 3
+    query_params:
+      fields: '{{ .ParamOr "fields" "all" }}'
+  getUsersFromUrl:
+    path: /users
+    server: testServer
+    timeout: 1000
+    coalesce: true
+    concurrency: 10
+    allow_url_override: true
+`
+
+func Test_Coalesce_IdenticalRequestsMakeOneCall(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, coalesceTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := atomic.AddInt32(&calls, 1)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprintf(w, `{"count": %d, "id": "%s"}`, count, r.URL.Query().Get("id"))
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Every caller gets the response in its own object
+			out := gox.StringObjectMap{}
+			request := command.NewGoxRequestBuilder("getUsers").
+				WithQueryParam("id", "1").
+				WithResponseBuilder(command.NewJsonToObjectResponseBuilder(&out)).
+				Build()
+			_, err := goxHttpCtx.Execute(ctx, "getUsers", request)
+			assert.NoError(t, err)
+			assert.Equal(t, "1", out.StringOrEmpty("id"))
+			assert.Equal(t, 1, out.IntOrZero("count"))
+		}()
+	}
+
+	// Caller with a short deadline gives up without affecting others
+	time.Sleep(50 * time.Millisecond)
+	shortCtx, shortCtxC := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer shortCtxC()
+	_, err = goxHttpCtx.Execute(shortCtx, "getUsers", command.NewGoxRequestBuilder("getUsers").WithQueryParam("id", "1").Build())
+	assert.Error(t, err)
+	if e, ok := err.(*command.GoxHttpError); ok {
+		assert.Equal(t, "request_timeout_on_client", e.ErrorCode)
+	} else {
+		assert.Fail(t, "expected GoxHttpError error")
+	}
+
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Request with different query param is not merged
+	response, err := goxHttpCtx.Execute(ctx, "getUsers", command.NewGoxRequestBuilder("getUsers").WithQueryParam("id", "2").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "2", response.AsStringObjectMapOrEmpty().StringOrEmpty("id"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_Coalesce_FirstValueOfPathParamIsUsed(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, coalesceTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprintf(w, `{"path": "%s"}`, r.URL.Path)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Both requests call "/users/1" - so they are merged
+	wg := sync.WaitGroup{}
+	for _, other := range []string{"2", "3"} {
+		wg.Add(1)
+		go func(other string) {
+			defer wg.Done()
+			request := command.NewGoxRequestBuilder("getUser").WithPathParam("id", "1").WithPathParam("id", other).Build()
+			response, err := goxHttpCtx.Execute(ctx, "getUser", request)
+			assert.NoError(t, err)
+			assert.Equal(t, "/users/1", response.AsStringObjectMapOrEmpty().StringOrEmpty("path"))
+		}(other)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func Test_Coalesce_CancelledCallIsNotJoined(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, coalesceTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = fmt.Fprint(w, `{"status": "ok"}`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	// Only caller gives up - upstream call is cancelled
+	shortCtx, shortCtxC := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer shortCtxC()
+	_, err = goxHttpCtx.Execute(shortCtx, "getStatus", command.NewGoxRequestBuilder("getStatus").Build())
+	assert.Error(t, err)
+
+	// Next request must make a new call, and not get the error of the cancelled call
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+	response, err := goxHttpCtx.Execute(ctx, "getStatus", command.NewGoxRequestBuilder("getStatus").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", response.AsStringObjectMapOrEmpty().StringOrEmpty("status"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_Coalesce_RequestsWithDifferentTemplateParamsAreNotMerged(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, coalesceTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprintf(w, `{"path": "%s", "fields": "%s"}`, r.URL.Path, r.URL.Query().Get("fields"))
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Path param and query param both come from template params - only the last request is same as the first one
+	params := []map[string]interface{}{
+		{"id": 1, "fields": "name"},
+		{"id": 1, "fields": "email"},
+		{"id": 2, "fields": "name"},
+		{"id": 1, "fields": "name"},
+	}
+	wg := sync.WaitGroup{}
+	for _, p := range params {
+		wg.Add(1)
+		go func(p map[string]interface{}) {
+			defer wg.Done()
+			request := command.NewGoxRequestBuilder("getUserFields").WithTemplateParams(p).Build()
+			response, err := goxHttpCtx.Execute(ctx, "getUserFields", request)
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("/users/%d", p["id"]), response.AsStringObjectMapOrEmpty().StringOrEmpty("path"))
+			assert.Equal(t, p["fields"], response.AsStringObjectMapOrEmpty().StringOrEmpty("fields"))
+		}(p)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func Test_Coalesce_RequestsWithDifferentUrlOverridesAreNotMerged(t *testing.T) {
+	var calls int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprintf(w, `{"url": "%s%s"}`, r.Host, r.URL.Path)
+	}
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, coalesceTestConfig, http.HandlerFunc(handler))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	// Other server with the same path
+	otherServer := httptest.NewServer(http.HandlerFunc(handler))
+	defer otherServer.Close()
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	requests := map[string]*command.GoxRequest{
+		"/users": command.NewGoxRequestBuilder("getUsersFromUrl").Build(),
+		"/a":     command.NewGoxRequestBuilder("getUsersFromUrl").WithPath("/a").Build(),
+		"/b":     command.NewGoxRequestBuilder("getUsersFromUrl").WithPath("/b").Build(),
+		strings.TrimPrefix(otherServer.URL, "http://") + "/users": command.NewGoxRequestBuilder("getUsersFromUrl").WithUrl(otherServer.URL + "/users").Build(),
+	}
+	wg := sync.WaitGroup{}
+	for expected, request := range requests {
+		wg.Add(1)
+		go func(expected string, request *command.GoxRequest) {
+			defer wg.Done()
+			response, err := goxHttpCtx.Execute(ctx, "getUsersFromUrl", request)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.True(t, strings.HasSuffix(response.AsStringObjectMapOrEmpty().StringOrEmpty("url"), expected))
+		}(expected, request)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func Test_Coalesce_RequestsWithDifferentCredentialsAreNotMerged(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, coalesceTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprintf(w, `{"auth": "%s"}`, r.Header.Get("Authorization"))
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Credential headers are part of the key even if they are not listed in coalesce_headers
+	wg := sync.WaitGroup{}
+	for _, token := range []string{"Bearer a", "Bearer b"} {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+			request := command.NewGoxRequestBuilder("getStatus").WithHeader("Authorization", token).Build()
+			response, err := goxHttpCtx.Execute(ctx, "getStatus", request)
+			assert.NoError(t, err)
+			assert.Equal(t, token, response.AsStringObjectMapOrEmpty().StringOrEmpty("auth"))
+		}(token)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_Coalesce_EveryCallerGetsItsOwnHeaderAndBody(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, coalesceTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(200 * time.Millisecond)
+		w.Header().Set("X-Request-Id", "1")
+		_, _ = fmt.Fprint(w, `{"status": "ok"}`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	responses := make([]*command.GoxResponse, 3)
+	wg := sync.WaitGroup{}
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := goxHttpCtx.Execute(ctx, "getStatus", command.NewGoxRequestBuilder("getStatus").Build())
+			assert.NoError(t, err)
+			responses[i] = response
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Change made by one caller is not seen by others
+	responses[0].Header.Set("X-Request-Id", "changed")
+	responses[0].Body[0] = '['
+	for _, response := range responses[1:] {
+		assert.Equal(t, "1", response.Header.Get("X-Request-Id"))
+		assert.Equal(t, `{"status": "ok"}`, string(response.Body))
+	}
+}
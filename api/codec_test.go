@@ -0,0 +1,124 @@
+package goxHttpApi
+
+import (
+	"context"
+	"encoding/xml"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type codecTestOrder struct {
+	XMLName xml.Name `json:"-" xml:"order" msgpack:"-"`
+	Id      string   `json:"id" xml:"id" msgpack:"id"`
+	Amount  int      `json:"amount" xml:"amount" msgpack:"amount"`
+}
+
+const codecTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  createOrder:
+    method: POST
+    path: /orders
+    server: testServer
+    timeout: 1000
+    content_type: application/xml
+  createNote:
+    method: POST
+    path: /notes
+    server: testServer
+    timeout: 1000
+`
+
+func Test_Codec_RequestAndResponseUseContentType(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, codecTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		// Send back the order in the format asked by client
+		order := codecTestOrder{}
+		switch r.Header.Get("Content-Type") {
+		case "application/xml":
+			assert.NoError(t, xml.Unmarshal(body, &order))
+		case "application/msgpack":
+			assert.NoError(t, msgpack.Unmarshal(body, &order))
+		default:
+			assert.Fail(t, "unexpected content type: "+r.Header.Get("Content-Type"))
+		}
+		order.Amount = order.Amount * 2
+
+		switch r.Header.Get("Accept") {
+		case "application/msgpack":
+			w.Header().Set("Content-Type", "application/msgpack")
+			body, _ = msgpack.Marshal(order)
+		default:
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			body, _ = xml.Marshal(order)
+		}
+		_, _ = w.Write(body)
+	}))
+	defer closeFunc()
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Request is encoded using api content type - response is xml
+	out := codecTestOrder{}
+	response, err := goxHttpCtx.Execute(ctx, "createOrder", command.NewGoxRequestBuilder("createOrder").
+		WithBody(codecTestOrder{Id: "1", Amount: 10}).
+		WithResponseTarget(&out).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, &out, response.Response)
+	assert.Equal(t, "1", out.Id)
+	assert.Equal(t, 20, out.Amount)
+	assert.Equal(t, "application/xml; charset=utf-8", response.Header.Get("Content-Type"))
+
+	// Content-Type header in request overrides api content type - response is msgpack
+	out = codecTestOrder{}
+	_, err = goxHttpCtx.Execute(ctx, "createOrder", command.NewGoxRequestBuilder("createOrder").
+		WithHeader("Content-Type", "application/msgpack").
+		WithHeader("Accept", "application/msgpack").
+		WithBody(codecTestOrder{Id: "2", Amount: 5}).
+		WithResponseTarget(&out).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "2", out.Id)
+	assert.Equal(t, 10, out.Amount)
+}
+
+func Test_Codec_TextAndUnknownContentTypeSendObjectAsJson(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, codecTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write(body)
+	}))
+	defer closeFunc()
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	for _, contentType := range []string{"text/plain", "text/csv"} {
+		response, err := goxHttpCtx.Execute(ctx, "createNote", command.NewGoxRequestBuilder("createNote").
+			WithHeader("Content-Type", contentType).
+			WithBody(map[string]interface{}{"name": "a"}).
+			Build())
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name":"a"}`, string(response.Body), contentType)
+	}
+}
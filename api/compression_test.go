@@ -0,0 +1,121 @@
+package goxHttpApi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"github.com/andybalholm/brotli"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const compressionTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  createUser:
+    method: POST
+    path: /users
+    server: testServer
+    timeout: 1000
+    request_compression: gzip
+    request_compression_min_bytes: 100
+  getStatus:
+    path: /status
+    server: testServer
+    timeout: 1000
+    response_compression: true
+`
+
+func Test_Compression_RequestBodyIsCompressedAboveThreshold(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, compressionTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(bytes.NewReader(body))
+			assert.NoError(t, err)
+			body, _ = ioutil.ReadAll(reader)
+		}
+		out := map[string]interface{}{}
+		_ = json.Unmarshal(body, &out)
+		out["encoding"] = r.Header.Get("Content-Encoding")
+		_, _ = w.Write([]byte(serialization.StringifySuppressError(out, "{}")))
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Small body is sent as it is
+	response, err := goxHttpCtx.Execute(ctx, "createUser", command.NewGoxRequestBuilder("createUser").
+		WithBody(map[string]interface{}{"name": "small"}).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "", response.AsStringObjectMapOrEmpty().StringOrEmpty("encoding"))
+	assert.Equal(t, "small", response.AsStringObjectMapOrEmpty().StringOrEmpty("name"))
+
+	// Large body is compressed
+	large := strings.Repeat("a", 1000)
+	response, err = goxHttpCtx.Execute(ctx, "createUser", command.NewGoxRequestBuilder("createUser").
+		WithBody(map[string]interface{}{"name": large}).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "gzip", response.AsStringObjectMapOrEmpty().StringOrEmpty("encoding"))
+	assert.Equal(t, large, response.AsStringObjectMapOrEmpty().StringOrEmpty("name"))
+}
+
+func Test_Compression_ResponseIsDecoded(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, compressionTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gzip, deflate, br, zstd", r.Header.Get("Accept-Encoding"))
+		body := []byte(`{"status": "ok"}`)
+		buf := &bytes.Buffer{}
+		switch encoding := r.URL.Query().Get("encoding"); encoding {
+		case "br":
+			writer := brotli.NewWriter(buf)
+			_, _ = writer.Write(body)
+			_ = writer.Close()
+			w.Header().Set("Content-Encoding", encoding)
+		case "zstd":
+			writer, _ := zstd.NewWriter(buf)
+			_, _ = writer.Write(body)
+			_ = writer.Close()
+			w.Header().Set("Content-Encoding", encoding)
+		case "gzip":
+			writer := gzip.NewWriter(buf)
+			_, _ = writer.Write(body)
+			_ = writer.Close()
+			w.Header().Set("Content-Encoding", encoding)
+		default:
+			buf.Write(body)
+		}
+		_, _ = w.Write(buf.Bytes())
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	for _, encoding := range []string{"br", "zstd", "gzip", "identity"} {
+		response, err := goxHttpCtx.Execute(ctx, "getStatus", command.NewGoxRequestBuilder("getStatus").
+			WithQueryParam("encoding", encoding).
+			Build())
+		assert.NoError(t, err, encoding)
+		assert.Equal(t, "ok", response.AsStringObjectMapOrEmpty().StringOrEmpty("status"), encoding)
+	}
+}
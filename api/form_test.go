@@ -0,0 +1,111 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const formTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  updateUser:
+    method: PATCH
+    path: /users
+    server: testServer
+    timeout: 1000
+  uploadReport:
+    method: POST
+    path: /reports
+    server: testServer
+    timeout: 1000
+    retry_count: 1
+`
+
+func Test_Form_UrlEncoded(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, formTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		assert.NoError(t, r.ParseForm())
+		_, _ = fmt.Fprintf(w, `{"name": "%s", "tags": "%s"}`, r.PostForm.Get("name"), strings.Join(r.PostForm["tag"], ","))
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	response, err := goxHttpCtx.Execute(ctx, "updateUser", command.NewGoxRequestBuilder("updateUser").
+		WithFormField("name", "harish & co").
+		WithFormField("tag", "a").
+		WithFormField("tag", 1).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "harish & co", response.AsStringObjectMapOrEmpty().StringOrEmpty("name"))
+	assert.Equal(t, "a,1", response.AsStringObjectMapOrEmpty().StringOrEmpty("tags"))
+}
+
+func Test_Form_MultipartIsResentOnRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gox-http-form")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "report.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"id": 1}`), 0644))
+
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, formTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseMultipartForm(1024*1024))
+
+		fromPath, fromPathHeader, err := r.FormFile("report")
+		assert.NoError(t, err)
+		fromPathContent, _ := ioutil.ReadAll(fromPath)
+		assert.Equal(t, "report.json", fromPathHeader.Filename)
+		assert.Equal(t, "application/json", fromPathHeader.Header.Get("Content-Type"))
+
+		fromReader, fromReaderHeader, err := r.FormFile("image")
+		assert.NoError(t, err)
+		fromReaderContent, _ := ioutil.ReadAll(fromReader)
+		assert.Equal(t, "a.png", fromReaderHeader.Filename)
+		assert.Equal(t, "image/png", fromReaderHeader.Header.Get("Content-Type"))
+
+		// Fail first attempt - retry must send the same parts again
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"name": "%s", "report": %s, "image": "%s"}`, r.FormValue("name"), fromPathContent, fromReaderContent)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxC()
+
+	response, err := goxHttpCtx.Execute(ctx, "uploadReport", command.NewGoxRequestBuilder("uploadReport").
+		WithFormField("name", "harish").
+		WithFileFromPath("report", path, "").
+		WithFileFromReader("image", "a.png", "image/png", strings.NewReader("png-bytes")).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, "harish", response.AsStringObjectMapOrEmpty().StringOrEmpty("name"))
+	assert.Equal(t, 1, response.AsStringObjectMapOrEmpty().StringObjectMapOrEmpty("report").IntOrZero("id"))
+	assert.Equal(t, "png-bytes", response.AsStringObjectMapOrEmpty().StringOrEmpty("image"))
+}
@@ -0,0 +1,265 @@
+package goxHttpApi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const graphQLTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  getUser:
+    type: graphql
+    path: /graphql
+    server: testServer
+    timeout: 1000
+    graphql:
+      query: "query getUser($id: ID!) { user(id: $id) { id name } }"
+      operation_name: getUser
+`
+
+// Query file is created by the test
+const graphQLPersistedTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  getUserPersisted:
+    type: graphql
+    path: /graphql
+    server: testServer
+    timeout: 1000
+    graphql:
+      query_file: __QUERY_FILE__
+      persisted_query: true
+`
+
+type graphQLTestUser struct {
+	User struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"user"`
+}
+
+type graphQLTestRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    struct {
+		PersistedQuery struct {
+			Version    int    `json:"version"`
+			Sha256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// Check that request is a graphql request, and call the handler with the decoded request
+func graphQLTestHandler(t *testing.T, handler func(w http.ResponseWriter, request *graphQLTestRequest)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		request := &graphQLTestRequest{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(request))
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, request)
+	})
+}
+
+func Test_GraphQL_Config(t *testing.T) {
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(graphQLTestConfig, &config)
+	assert.NoError(t, err)
+	assert.True(t, config.Apis["getUser"].IsGraphQL())
+	assert.Equal(t, "POST", config.Apis["getUser"].Method)
+	assert.Equal(t, "getUser", config.Apis["getUser"].GraphQL.OperationName)
+	assert.False(t, config.Apis["getUser"].GraphQL.PersistedQuery)
+
+	config = command.Config{}
+	err = serialization.ReadYamlFromString(graphQLPersistedTestConfig, &config)
+	assert.NoError(t, err)
+	assert.True(t, config.Apis["getUserPersisted"].GraphQL.PersistedQuery)
+}
+
+func Test_GraphQL_DataIsDecoded(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, graphQLTestConfig, graphQLTestHandler(t, func(w http.ResponseWriter, request *graphQLTestRequest) {
+		assert.Equal(t, "query getUser($id: ID!) { user(id: $id) { id name } }", request.Query)
+		assert.Equal(t, "getUser", request.OperationName)
+		_, _ = fmt.Fprintf(w, `{"data": {"user": {"id": "%v", "name": "harish"}}}`, request.Variables["id"])
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), time.Second)
+	defer ctxC()
+
+	user := &graphQLTestUser{}
+	response, err := goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").
+		WithGraphQLVariable("id", "10").
+		WithResponseTarget(user).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "10", user.User.Id)
+	assert.Equal(t, "harish", user.User.Name)
+}
+
+func Test_GraphQL_ErrorsOnHttp200(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, graphQLTestConfig, graphQLTestHandler(t, func(w http.ResponseWriter, request *graphQLTestRequest) {
+		if request.Variables["id"] == "partial" {
+			_, _ = fmt.Fprint(w, `{"data": {"user": {"id": "partial", "name": null}}, "errors": [{"message": "name is not available", "path": ["user", "name"], "locations": [{"line": 1, "column": 40}], "extensions": {"code": "NOT_AVAILABLE"}}]}`)
+		} else {
+			_, _ = fmt.Fprint(w, `{"data": null, "errors": [{"message": "user not found"}, {"message": "access denied", "extensions": {"code": "FORBIDDEN"}}]}`)
+		}
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), time.Second)
+	defer ctxC()
+
+	// Partial data - data is decoded and errors are returned
+	user := &graphQLTestUser{}
+	response, err := goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").
+		WithGraphQLVariable("id", "partial").
+		WithResponseTarget(user).
+		Build())
+	goxErr, ok := err.(*command.GoxHttpError)
+	assert.True(t, ok)
+	assert.True(t, goxErr.IsGraphQLError())
+	assert.Equal(t, command.ErrorCodeGraphQLPartialError, goxErr.ErrorCode)
+	assert.Equal(t, http.StatusOK, goxErr.StatusCode)
+	assert.Equal(t, 1, len(goxErr.GraphQLErrors()))
+	assert.Equal(t, "NOT_AVAILABLE", goxErr.GraphQLErrors()[0].Code())
+	assert.Equal(t, []interface{}{"user", "name"}, goxErr.GraphQLErrors()[0].Path)
+	assert.Equal(t, 40, goxErr.GraphQLErrors()[0].Locations[0].Column)
+	assert.NotNil(t, response)
+	assert.Equal(t, "partial", user.User.Id)
+
+	// No data - only errors
+	_, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").
+		WithGraphQLVariable("id", "missing").
+		Build())
+	goxErr, ok = err.(*command.GoxHttpError)
+	assert.True(t, ok)
+	assert.Equal(t, command.ErrorCodeGraphQLError, goxErr.ErrorCode)
+	assert.Equal(t, 2, len(goxErr.GraphQLErrors()))
+	assert.Equal(t, "FORBIDDEN", goxErr.GraphQLErrors()[1].Code())
+}
+
+func Test_GraphQL_ErrorsOnHttp200DoNotOpenCircuit(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, graphQLTestConfig, graphQLTestHandler(t, func(w http.ResponseWriter, request *graphQLTestRequest) {
+		_, _ = fmt.Fprint(w, `{"data": null, "errors": [{"message": "user not found"}]}`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxC()
+
+	circuitOpenCount := 0
+	for i := 0; i < 200; i++ {
+		_, err := goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").
+			WithGraphQLVariable("id", "missing").
+			Build())
+		goxErr, ok := err.(*command.GoxHttpError)
+		assert.True(t, ok)
+		if goxErr.IsHystrixCircuitOpenError() {
+			circuitOpenCount++
+		} else {
+			assert.True(t, goxErr.IsGraphQLError())
+		}
+	}
+	assert.Equal(t, 0, circuitOpenCount)
+}
+
+func Test_GraphQL_QueryFromRequestAndErrorResponse(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, graphQLTestConfig, graphQLTestHandler(t, func(w http.ResponseWriter, request *graphQLTestRequest) {
+		assert.Equal(t, "query { broken", request.Query)
+		assert.Equal(t, "", request.OperationName)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, `{"errors": [{"message": "Syntax Error: Expected Name, found <EOF>."}]}`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), time.Second)
+	defer ctxC()
+
+	_, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").
+		WithGraphQLQuery("query { broken").
+		Build())
+	goxErr, ok := err.(*command.GoxHttpError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusBadRequest, goxErr.StatusCode)
+	assert.Equal(t, "Syntax Error: Expected Name, found <EOF>.", goxErr.GraphQLErrors()[0].Message)
+}
+
+func Test_GraphQL_PersistedQuery(t *testing.T) {
+	hash := sha256.Sum256([]byte("query { me { id } }"))
+	expectedHash := hex.EncodeToString(hash[:])
+
+	lock := &sync.Mutex{}
+	knownQueries := map[string]string{}
+	calls := make([]bool, 0)
+	queryFile := filepath.Join(t.TempDir(), "user.graphql")
+	assert.NoError(t, ioutil.WriteFile(queryFile, []byte("query { me { id } }"), os.ModePerm))
+	configYaml := strings.ReplaceAll(graphQLPersistedTestConfig, "__QUERY_FILE__", queryFile)
+
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, configYaml, graphQLTestHandler(t, func(w http.ResponseWriter, request *graphQLTestRequest) {
+		lock.Lock()
+		defer lock.Unlock()
+		calls = append(calls, request.Query != "")
+
+		assert.Equal(t, 1, request.Extensions.PersistedQuery.Version)
+		assert.Equal(t, expectedHash, request.Extensions.PersistedQuery.Sha256Hash)
+		if request.Query != "" {
+			knownQueries[request.Extensions.PersistedQuery.Sha256Hash] = request.Query
+		}
+		if _, ok := knownQueries[request.Extensions.PersistedQuery.Sha256Hash]; !ok {
+			_, _ = fmt.Fprint(w, `{"errors": [{"message": "PersistedQueryNotFound", "extensions": {"code": "PERSISTED_QUERY_NOT_FOUND"}}]}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"data": {"me": {"id": "1"}}}`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), time.Second)
+	defer ctxC()
+
+	// First call sends the query after server asks for it, next call sends only the hash
+	for i := 0; i < 2; i++ {
+		response, err := goxHttpCtx.Execute(ctx, "getUserPersisted", command.NewGoxRequestBuilder("getUserPersisted").Build())
+		assert.NoError(t, err)
+		assert.Equal(t, `{"data": {"me": {"id": "1"}}}`, string(response.Body))
+	}
+	assert.Equal(t, []bool{false, true, false}, calls)
+}
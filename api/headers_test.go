@@ -0,0 +1,108 @@
+package goxHttpApi
+
+import (
+	"context"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+var headersTestConfig = `
+env: dev
+
+servers:
+  testServer:
+    host: localhost
+    port: 9123
+    headers:
+      user-agent: 'my-app/{{ .Env "GOX_HTTP_TEST_APP_VERSION" }}'
+      x-source: server
+      x-env: "env:string: prod=production; default=development"
+
+apis:
+  with_headers:
+    path: /headers
+    server: testServer
+    timeout: 1000
+    headers:
+      x-source: api
+      x-tenant: '{{ .Ctx "tenant_id" }}'
+      x-user: '{{ .Ctx "user_id" }}'
+`
+
+type headersTestTenantKey struct{}
+
+func Test_Headers_MergedInOrderAndTemplated(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	_ = os.Setenv("GOX_HTTP_TEST_APP_VERSION", "1.2.3")
+	defer os.Unsetenv("GOX_HTTP_TEST_APP_VERSION")
+	command.RegisterContextValue("tenant_id", func(ctx context.Context) (string, bool) {
+		v, ok := ctx.Value(headersTestTenantKey{}).(string)
+		return v, ok
+	})
+
+	// Send back all headers we got
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gox.StringObjectMap{}
+		for name := range r.Header {
+			data[strings.ToLower(name)] = r.Header.Get(name)
+		}
+		_, _ = w.Write(serialization.ToBytesSuppressError(data))
+	}))
+	defer ts.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(headersTestConfig, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+	ctx = context.WithValue(ctx, headersTestTenantKey{}, "tenant_1")
+
+	// Request header has the highest priority
+	request := command.NewGoxRequestBuilder("with_headers").WithHeader("x-env", "from_request").Build()
+	response, err := goxHttpCtx.Execute(ctx, "with_headers", request)
+	assert.NoError(t, err)
+	headers := response.AsStringObjectMapOrEmpty()
+	assert.Equal(t, "my-app/1.2.3", headers.StringOrEmpty("user-agent"))
+	assert.Equal(t, "api", headers.StringOrEmpty("x-source"))
+	assert.Equal(t, "tenant_1", headers.StringOrEmpty("x-tenant"))
+	assert.Equal(t, "from_request", headers.StringOrEmpty("x-env"))
+	assert.Equal(t, "application/json", headers.StringOrEmpty("content-type"))
+
+	// Template which evaluates to empty value is not sent
+	_, found := headers["x-user"]
+	assert.False(t, found)
+
+	// Env specific value is used if request does not override it
+	response, err = goxHttpCtx.Execute(ctx, "with_headers", command.NewGoxRequestBuilder("with_headers").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "development", response.AsStringObjectMapOrEmpty().StringOrEmpty("x-env"))
+}
+
+func Test_Headers_InvalidTemplateFailsConfigLoad(t *testing.T) {
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(`
+servers:
+  testServer:
+    host: localhost
+apis:
+  bad:
+    server: testServer
+    headers:
+      x-bad: '{{ .Ctx "a" '
+`, &config)
+	assert.Error(t, err)
+}
@@ -11,8 +11,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
-	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -32,8 +30,7 @@ func Test_Get_Success(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 	config.Apis["delay_timeout_10"].DisableHystrix = true
 
 	// Setup goHttp context
@@ -70,8 +67,7 @@ func Test_Get_Timeout(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 	config.Apis["delay_timeout_10"].DisableHystrix = true
 
 	// Setup goHttp context
@@ -112,8 +108,7 @@ func Test_Get_With_Acceptable_Status_Code(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 
 	config.Apis["delay_timeout_10"].DisableHystrix = true
 	config.Apis["delay_timeout_10"].AcceptableCodes = "202,401"
@@ -152,8 +147,7 @@ func Test_Get_With_Unacceptable_Status_Code(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 	config.Apis["delay_timeout_10"].DisableHystrix = true
 
 	// Setup goHttp context
@@ -189,8 +183,7 @@ func Test_Get_With_Retry(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 	config.Apis["delay_timeout_10"].RetryCount = 3
 	config.Apis["delay_timeout_10"].Timeout = 10000
 
@@ -235,8 +228,7 @@ func Test_Get_With_Retry_With_Finally_Success(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 	config.Apis["delay_timeout_10"].RetryCount = 3
 	config.Apis["delay_timeout_10"].Timeout = 10000
 
@@ -278,8 +270,7 @@ func Test_Get_With_Retry_Non_2xx_But_Acceptable_Code(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 	config.Apis["delay_timeout_10"].RetryCount = 3
 	config.Apis["delay_timeout_10"].Timeout = 10000
 	config.Apis["delay_timeout_10"].AcceptableCodes = "200, 401"
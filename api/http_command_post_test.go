@@ -12,8 +12,6 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
-	"strings"
 	"testing"
 	"time"
 )
@@ -44,8 +42,7 @@ func Test_Post_Success(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 	config.Apis["delay_timeout_10_POST"].DisableHystrix = true
 
 	// Setup goHttp context
@@ -84,8 +81,7 @@ func Test_Post_Timeout(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 	config.Apis["delay_timeout_10_POST"].DisableHystrix = true
 
 	// Setup goHttp context
@@ -138,8 +134,7 @@ func Test_Post_With_Acceptable_Status_Code(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 
 	config.Apis["delay_timeout_10_POST"].DisableHystrix = true
 	config.Apis["delay_timeout_10_POST"].AcceptableCodes = "202,401"
@@ -179,8 +174,7 @@ func Test_Post_With_Unacceptable_Status_Code(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 	config.Apis["delay_timeout_10_POST"].DisableHystrix = true
 
 	// Setup goHttp context
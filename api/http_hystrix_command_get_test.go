@@ -13,8 +13,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -38,8 +36,7 @@ func Test_Hystrix_Get_Success(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 
 	// Setup goHttp context
 	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
@@ -81,8 +78,7 @@ func Test_Hystrix_Get_Timeout_WhenHttpCallTimeoutFirst(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 
 	// Setup goHttp context
 	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
@@ -128,8 +124,7 @@ func Test_Hystrix_Get_Timeout_WhenHystrixTimeoutHappensBeforeHttpTimeout(t *test
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 
 	config.Apis["delay_timeout_10"].Timeout = 100
 
@@ -174,8 +169,7 @@ func Test_Hystrix_Get_With_Acceptable_Status_Code(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 
 	config.Apis["delay_timeout_10"].AcceptableCodes = "202,401"
 
@@ -216,8 +210,7 @@ func Test_Hystrix_Get_With_Unacceptable_Status_Code(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 
 	// Setup goHttp context
 	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
@@ -254,8 +247,7 @@ func Test_Hystrix_Get_Verify_Circuit_Will_Open_On_Too_ManyErrors(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 
 	// Setup goHttp context
 	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
@@ -307,8 +299,7 @@ func Test_Hystrix_Get_Verify_Circuit_Will_Open_Due_To_Timeout(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 	config.Apis["delay_timeout_10"].Timeout = 100
 
 	// Setup goHttp context
@@ -365,8 +356,7 @@ func Test_Hystrix_Get_Verify_We_Go_Too_Many_Requests(t *testing.T) {
 	config := command.Config{}
 	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
 	assert.NoError(t, err)
-	config.Servers["testServer"].Port, err = strconv.Atoi(strings.ReplaceAll(ts.URL, "http://127.0.0.1:", ""))
-	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
 	config.Apis["delay_timeout_10"].Concurrency = 10
 	config.Apis["delay_timeout_10"].Timeout = 100
 
@@ -0,0 +1,154 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	httpCommand "github.com/devlibx/gox-http/command/http"
+	"github.com/devlibx/gox-http/testhelper"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_Priority_HighPriorityIsNotStarvedByNormalRequests(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	httpCommand.HystrixConfigMap = gox.StringObjectMap{}
+	hystrix.Flush()
+
+	// Requests with "block" header wait till we unblock them
+	unblock := make(chan bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("block") == "true" {
+			<-unblock
+		}
+		data := gox.StringObjectMap{"status": "ok"}
+		_, _ = fmt.Fprintln(w, serialization.StringifySuppressError(data, "{}"))
+	}))
+	defer ts.Close()
+
+	// Read config and put the port to call
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+	config.Apis["delay_timeout_10"].Timeout = 1000
+	config.Apis["delay_timeout_10"].Concurrency = 2
+	config.Apis["delay_timeout_10"].QueueSize = 1
+	config.Apis["delay_timeout_10"].HighPriorityConcurrencyPercent = 50
+
+	// Setup goHttp context
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Batch request takes the only non-reserved slot, the next one waits in queue
+	blocked := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			request := command.NewGoxRequestBuilder("delay_timeout_10").WithHeader("block", "true").Build()
+			_, err := goxHttpCtx.Execute(ctx, "delay_timeout_10", request)
+			blocked <- err
+		}()
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Queue is full - low priority request is shed
+	request := command.NewGoxRequestBuilder("delay_timeout_10").WithPriority(command.PriorityLow).Build()
+	_, err = goxHttpCtx.Execute(ctx, "delay_timeout_10", request)
+	assert.Error(t, err)
+	if e, ok := err.(*command.GoxHttpError); ok {
+		assert.True(t, e.IsRequestShedError())
+	} else {
+		assert.Fail(t, "expected GoxHttpError error")
+	}
+
+	// High priority request uses the reserved slot
+	request = command.NewGoxRequestBuilder("delay_timeout_10").
+		WithPriority(command.PriorityHigh).
+		WithResponseBuilder(command.NewJsonToObjectResponseBuilder(&gox.StringObjectMap{})).
+		Build()
+	response, err := goxHttpCtx.Execute(ctx, "delay_timeout_10", request)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", response.AsStringObjectMapOrEmpty().StringOrEmpty("status"))
+
+	// Unblock the batch requests - both must complete
+	close(unblock)
+	assert.NoError(t, <-blocked)
+	assert.NoError(t, <-blocked)
+}
+
+func Test_Priority_ReloadApiRebuildsPriorityLimiter(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	httpCommand.HystrixConfigMap = gox.StringObjectMap{}
+	hystrix.Flush()
+
+	// Requests with "block" header wait till we unblock them
+	unblock := make(chan bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("block") == "true" {
+			<-unblock
+		}
+		data := gox.StringObjectMap{"status": "ok"}
+		_, _ = fmt.Fprintln(w, serialization.StringifySuppressError(data, "{}"))
+	}))
+	defer ts.Close()
+
+	// Read config and put the port to call - priority is not enabled at start
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+	config.Apis["delay_timeout_10"].Timeout = 1000
+	config.Apis["delay_timeout_10"].Concurrency = 2
+	config.Apis["delay_timeout_10"].QueueSize = 1
+
+	// Setup goHttp context
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	// Enable priority based admission
+	config.Apis["delay_timeout_10"].HighPriorityConcurrencyPercent = 50
+	err = goxHttpCtx.ReloadApi("delay_timeout_10")
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Batch request takes the only non-reserved slot
+	blocked := make(chan error, 1)
+	go func() {
+		request := command.NewGoxRequestBuilder("delay_timeout_10").WithHeader("block", "true").Build()
+		_, err := goxHttpCtx.Execute(ctx, "delay_timeout_10", request)
+		blocked <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// Low priority request can not use the reserved slot and times out in queue
+	lowCtx, lowCtxC := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer lowCtxC()
+	request := command.NewGoxRequestBuilder("delay_timeout_10").WithPriority(command.PriorityLow).Build()
+	_, err = goxHttpCtx.Execute(lowCtx, "delay_timeout_10", request)
+	assert.Error(t, err)
+	if e, ok := err.(*command.GoxHttpError); ok {
+		assert.Equal(t, "request_timeout_on_client", e.ErrorCode)
+	} else {
+		assert.Fail(t, "expected GoxHttpError error")
+	}
+
+	// High priority request uses the reserved slot
+	request = command.NewGoxRequestBuilder("delay_timeout_10").WithPriority(command.PriorityHigh).Build()
+	_, err = goxHttpCtx.Execute(ctx, "delay_timeout_10", request)
+	assert.NoError(t, err)
+
+	close(unblock)
+	assert.NoError(t, <-blocked)
+}
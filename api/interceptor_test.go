@@ -0,0 +1,143 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Interceptor_OrderAndRequestModification(t *testing.T) {
+	cf, _ := test.MockCf(t)
+
+	// Setup sample response - send back the headers set by interceptors
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gox.StringObjectMap{"status": "ok", "order": r.Header.Get("order")}
+		_, _ = fmt.Fprintln(w, serialization.StringifySuppressError(data, "{}"))
+	}))
+	defer ts.Close()
+
+	// Read config and put the port to call
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+	config.Apis["delay_timeout_10"].DisableHystrix = true
+	config.Apis["delay_timeout_10"].Timeout = 1000
+
+	// Each interceptor appends its name to the header and to the response
+	interceptor := func(name string) command.Interceptor {
+		return command.InterceptorFunc(func(ctx context.Context, request *command.GoxRequest, next command.Handler) (*command.GoxResponse, error) {
+			if request.Header == nil {
+				request.Header = http.Header{}
+			}
+			request.Header.Set("order", request.Header.Get("order")+name+",")
+			response, err := next(ctx, request)
+			if response != nil {
+				response.Body = append(response.Body, []byte(name)...)
+			}
+			return response, err
+		})
+	}
+	config.Servers["testServer"].Interceptors = []command.Interceptor{interceptor("server")}
+	config.Apis["delay_timeout_10"].Interceptors = []command.Interceptor{interceptor("api")}
+
+	// Setup goHttp context
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+	goxHttpCtx.AddInterceptor(interceptor("global"))
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	request := command.NewGoxRequestBuilder("delay_timeout_10").
+		WithResponseBuilder(command.NewJsonToObjectResponseBuilder(&gox.StringObjectMap{})).
+		Build()
+	response, err := goxHttpCtx.Execute(ctx, "delay_timeout_10", request)
+	assert.NoError(t, err)
+	assert.Equal(t, "global,server,api,", response.AsStringObjectMapOrEmpty().StringOrEmpty("order"))
+	assert.True(t, strings.HasSuffix(string(response.Body), "apiserverglobal"))
+}
+
+func Test_Interceptor_ShortCircuit(t *testing.T) {
+	cf, _ := test.MockCf(t)
+
+	// Server must not be called
+	var serverCalls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&serverCalls, 1)
+		_, _ = fmt.Fprintln(w, "{}")
+	}))
+	defer ts.Close()
+
+	// Read config and put the port to call
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+
+	// Setup goHttp context
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+	goxHttpCtx.AddInterceptor(command.InterceptorFunc(func(ctx context.Context, request *command.GoxRequest, next command.Handler) (*command.GoxResponse, error) {
+		return &command.GoxResponse{StatusCode: http.StatusOK, Body: []byte(`{"status": "from_interceptor"}`)}, nil
+	}))
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	request := command.NewGoxRequestBuilder("delay_timeout_10").Build()
+	response, err := goxHttpCtx.Execute(ctx, "delay_timeout_10", request)
+	assert.NoError(t, err)
+	assert.Equal(t, "from_interceptor", response.AsStringObjectMapOrEmpty().StringOrEmpty("status"))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&serverCalls))
+}
+
+func Test_Interceptor_AddWhileExecuting(t *testing.T) {
+	cf, _ := test.MockCf(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintln(w, "{}")
+	}))
+	defer ts.Close()
+
+	// Read config and put the port to call
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+	config.Apis["delay_timeout_10"].DisableHystrix = true
+	config.Apis["delay_timeout_10"].Timeout = 1000
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	// Run with -race - interceptors are added while calls are running
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			goxHttpCtx.AddInterceptor(command.InterceptorFunc(func(ctx context.Context, request *command.GoxRequest, next command.Handler) (*command.GoxResponse, error) {
+				return next(ctx, request)
+			}))
+		}
+	}()
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxC()
+	for i := 0; i < 20; i++ {
+		_, err := goxHttpCtx.Execute(ctx, "delay_timeout_10", command.NewGoxRequestBuilder("delay_timeout_10").Build())
+		assert.NoError(t, err)
+	}
+	<-done
+}
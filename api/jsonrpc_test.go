@@ -0,0 +1,226 @@
+package goxHttpApi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const jsonRpcTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  calculator:
+    type: jsonrpc
+    path: /rpc
+    server: testServer
+    timeout: 1000
+    retry_count: 1
+`
+
+type jsonRpcTestRequest struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  []int           `json:"params"`
+	Id      json.RawMessage `json:"id"`
+}
+
+// Handle a single jsonrpc request - returns nil for a notification
+func jsonRpcTestHandle(request *jsonRpcTestRequest) map[string]interface{} {
+	if len(request.Id) == 0 {
+		return nil
+	}
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": request.Id}
+	switch request.Method {
+	case "add":
+		sum := 0
+		for _, p := range request.Params {
+			sum += p
+		}
+		response["result"] = map[string]int{"sum": sum}
+	case "fail":
+		response["error"] = map[string]interface{}{"code": -32000, "message": "failed to calculate", "data": map[string]string{"reason": "overflow"}}
+	default:
+		response["error"] = map[string]interface{}{"code": -32601, "message": "Method not found"}
+	}
+	return response
+}
+
+// Jsonrpc server which handles single and batch calls. If failFirst is true, first call fails with 503
+func jsonRpcTestHandler(t *testing.T, calls *int32, failFirst bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(calls, 1) == 1 && failFirst {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, http.MethodPost, r.Method)
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+
+		if strings.HasPrefix(string(body), "[") {
+			requests := make([]*jsonRpcTestRequest, 0)
+			assert.NoError(t, json.Unmarshal(body, &requests))
+
+			// Send responses in reverse order - client must match them by id
+			responses := make([]map[string]interface{}, 0)
+			for i := len(requests) - 1; i >= 0; i-- {
+				assert.Equal(t, "2.0", requests[i].JsonRpc)
+				if response := jsonRpcTestHandle(requests[i]); response != nil {
+					responses = append(responses, response)
+				}
+			}
+			if len(responses) == 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(responses)
+			return
+		}
+
+		request := &jsonRpcTestRequest{}
+		assert.NoError(t, json.Unmarshal(body, request))
+		assert.Equal(t, "2.0", request.JsonRpc)
+		if response := jsonRpcTestHandle(request); response != nil {
+			_ = json.NewEncoder(w).Encode(response)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
+type jsonRpcTestResult struct {
+	Sum int `json:"sum"`
+}
+
+func Test_JsonRpc_SingleCall(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, jsonRpcTestConfig, jsonRpcTestHandler(t, &calls, true))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// First attempt fails with 503 and is retried
+	result := &jsonRpcTestResult{}
+	_, err = goxHttpCtx.Execute(ctx, "calculator", command.NewGoxRequestBuilder("calculator").
+		WithJsonRpcMethod("add", []int{1, 2, 3}).
+		WithResponseTarget(result).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, 6, result.Sum)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// Error object is returned as GoxHttpError with jsonrpc code
+	_, err = goxHttpCtx.Execute(ctx, "calculator", command.NewGoxRequestBuilder("calculator").
+		WithJsonRpcMethod("fail", []int{1}).
+		Build())
+	goxErr, ok := err.(*command.GoxHttpError)
+	assert.True(t, ok)
+	assert.True(t, goxErr.IsJsonRpcError())
+	assert.Equal(t, -32000, goxErr.JsonRpcError().Code)
+	assert.Equal(t, "failed to calculate", goxErr.JsonRpcError().Message)
+	assert.JSONEq(t, `{"reason": "overflow"}`, string(goxErr.JsonRpcError().Data))
+
+	// Notification has no response
+	response, err := goxHttpCtx.Execute(ctx, "calculator", command.NewGoxRequestBuilder("calculator").
+		WithJsonRpcNotification("add", []int{1}).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+}
+
+func Test_JsonRpc_ErrorObjectDoesNotOpenCircuit(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, jsonRpcTestConfig, jsonRpcTestHandler(t, &calls, false))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxC()
+
+	circuitOpenCount := 0
+	for i := 0; i < 200; i++ {
+		_, err := goxHttpCtx.Execute(ctx, "calculator", command.NewGoxRequestBuilder("calculator").
+			WithJsonRpcMethod("fail", []int{1}).
+			Build())
+		goxErr, ok := err.(*command.GoxHttpError)
+		assert.True(t, ok)
+		if goxErr.IsHystrixCircuitOpenError() {
+			circuitOpenCount++
+		} else {
+			assert.True(t, goxErr.IsJsonRpcError())
+		}
+	}
+	assert.Equal(t, 0, circuitOpenCount)
+}
+
+func Test_JsonRpc_Batch(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, jsonRpcTestConfig, jsonRpcTestHandler(t, &calls, false))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	first, second := &jsonRpcTestResult{}, &jsonRpcTestResult{}
+	batch := []*command.JsonRpcCall{
+		{Method: "add", Params: []int{1, 2}, Result: first},
+		{Method: "fail", Params: []int{1}},
+		{Method: "add", Params: []int{10, 20}, Result: second, Id: "my-id"},
+		{Method: "add", Params: []int{1}, Notification: true},
+		{Method: "unknown"},
+	}
+	response, err := goxHttpCtx.Execute(ctx, "calculator", command.NewGoxRequestBuilder("calculator").
+		WithJsonRpcBatch(batch...).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	results := response.JsonRpcResults
+	assert.Equal(t, len(batch), len(results))
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 3, first.Sum)
+	assert.Equal(t, -32000, results[1].Err.(*command.GoxHttpError).JsonRpcError().Code)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, 30, second.Sum)
+	assert.Equal(t, "my-id", results[2].Id)
+	assert.JSONEq(t, `{"sum": 30}`, string(results[2].RawResult))
+	assert.NoError(t, results[3].Err)
+	assert.Nil(t, results[3].Id)
+	assert.Equal(t, "unknown", results[4].Method)
+	assert.Equal(t, command.JsonRpcCodeMethodNotFound, results[4].Err.(*command.GoxHttpError).JsonRpcError().Code)
+
+	// Ids are generated for calls without id - calls are not changed
+	assert.NotNil(t, results[0].Id)
+	assert.NotEqual(t, results[0].Id, results[1].Id)
+	assert.Nil(t, batch[0].Id)
+	assert.Nil(t, batch[1].Id)
+
+	// Same calls can be sent again - errors of the first batch are not kept
+	response, err = goxHttpCtx.Execute(ctx, "calculator", command.NewGoxRequestBuilder("calculator").
+		WithJsonRpcBatch(batch[0], batch[2]).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(response.JsonRpcResults))
+	assert.NoError(t, response.JsonRpcResults[0].Err)
+	assert.NoError(t, response.JsonRpcResults[1].Err)
+	assert.NotEqual(t, results[0].Id, response.JsonRpcResults[0].Id)
+}
@@ -0,0 +1,208 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/metrics"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	httpCommand "github.com/devlibx/gox-http/command/http"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const metricsTestConfig = `
+servers:
+  testServer:
+    host: localhost
+    port: 9123
+apis:
+  getUser:
+    path: /users
+    server: testServer
+    timeout: 1000
+    retry_count: 1
+    metrics:
+      latency_buckets_ms: 100,10,1000
+`
+
+// Records all metrics - key of a metric is name and sorted tags e.g. "gox_http_retry{api=getUser,...}"
+type metricsTestRecorder struct {
+	lock       *sync.Mutex
+	counters   map[string]int64
+	gauges     map[string]float64
+	histograms map[string][]float64
+	buckets    map[string]metrics.Buckets
+}
+
+type metricsTestScope struct {
+	*metricsTestRecorder
+	tags map[string]string
+}
+
+type metricsTestMetric struct {
+	scope *metricsTestScope
+	key   string
+}
+
+func newMetricsTestScope() *metricsTestScope {
+	return &metricsTestScope{
+		metricsTestRecorder: &metricsTestRecorder{
+			lock:       &sync.Mutex{},
+			counters:   map[string]int64{},
+			gauges:     map[string]float64{},
+			histograms: map[string][]float64{},
+			buckets:    map[string]metrics.Buckets{},
+		},
+		tags: map[string]string{},
+	}
+}
+
+func (s *metricsTestScope) key(name string) string {
+	tags := make([]string, 0)
+	for k, v := range s.tags {
+		tags = append(tags, k+"="+v)
+	}
+	sort.Strings(tags)
+	return name + "{" + strings.Join(tags, ",") + "}"
+}
+
+func (s *metricsTestScope) Counter(name string) metrics.Counter {
+	return &metricsTestMetric{scope: s, key: s.key(name)}
+}
+
+func (s *metricsTestScope) Gauge(name string) metrics.Gauge {
+	return &metricsTestMetric{scope: s, key: s.key(name)}
+}
+
+func (s *metricsTestScope) Timer(name string) metrics.Timer {
+	return &metricsTestMetric{scope: s, key: s.key(name)}
+}
+
+func (s *metricsTestScope) Histogram(name string, buckets metrics.Buckets) metrics.Histogram {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.buckets[name] = buckets
+	return &metricsTestMetric{scope: s, key: s.key(name)}
+}
+
+func (s *metricsTestScope) Tagged(tags map[string]string) metrics.Scope {
+	merged := map[string]string{}
+	for k, v := range s.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return &metricsTestScope{metricsTestRecorder: s.metricsTestRecorder, tags: merged}
+}
+
+func (s *metricsTestScope) SubScope(name string) metrics.Scope {
+	return s
+}
+
+func (s *metricsTestScope) Capabilities() metrics.Capabilities {
+	return s
+}
+
+func (s *metricsTestScope) Reporting() bool {
+	return true
+}
+
+func (s *metricsTestScope) Tagging() bool {
+	return true
+}
+
+func (m *metricsTestMetric) Inc(delta int64) {
+	m.scope.lock.Lock()
+	defer m.scope.lock.Unlock()
+	m.scope.counters[m.key] += delta
+}
+
+func (m *metricsTestMetric) Update(value float64) {
+	m.scope.lock.Lock()
+	defer m.scope.lock.Unlock()
+	m.scope.gauges[m.key] = value
+}
+
+func (m *metricsTestMetric) Record(value time.Duration) {
+	m.RecordDuration(value)
+}
+
+func (m *metricsTestMetric) RecordValue(value float64) {
+	m.scope.lock.Lock()
+	defer m.scope.lock.Unlock()
+	m.scope.histograms[m.key] = append(m.scope.histograms[m.key], value)
+}
+
+func (m *metricsTestMetric) RecordDuration(value time.Duration) {
+	m.RecordValue(float64(value))
+}
+
+func (m *metricsTestMetric) RecordStopwatch(start time.Time) {
+	m.RecordDuration(time.Since(start))
+}
+
+func (m *metricsTestMetric) Start() metrics.Stopwatch {
+	return metrics.NewStopwatch(time.Now(), m)
+}
+
+func Test_Metrics(t *testing.T) {
+	scope := newMetricsTestScope()
+	cf, _ := test.MockCf(t, scope)
+	httpCommand.HystrixConfigMap = gox.StringObjectMap{}
+	hystrix.Flush()
+	httpCommand.EnableGoxHttpMetricLogging = true
+	defer func() { httpCommand.EnableGoxHttpMetricLogging = false }()
+
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": 1}`)
+	}))
+	defer ts.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(metricsTestConfig, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+	_, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").Build())
+	assert.NoError(t, err)
+
+	tags := "api=getUser,method=GET,server=testServer"
+	scope.lock.Lock()
+	defer scope.lock.Unlock()
+
+	assert.Equal(t, 1, len(scope.histograms["gox_http_request_duration{api=getUser,error=none,method=GET,server=testServer,status_class=2xx}"]))
+	assert.Equal(t, []float64{9}, scope.histograms["gox_http_response_size{api=getUser,error=none,method=GET,server=testServer,status_class=2xx}"])
+	assert.Equal(t, 1, len(scope.histograms["gox_http_attempt_duration{api=getUser,error=none,method=GET,server=testServer,status_class=5xx}"]))
+	assert.Equal(t, 1, len(scope.histograms["gox_http_attempt_duration{api=getUser,error=none,method=GET,server=testServer,status_class=2xx}"]))
+	assert.Equal(t, int64(1), scope.counters["gox_http_retry{"+tags+"}"])
+	assert.Equal(t, float64(0), scope.gauges["gox_http_in_flight{"+tags+"}"])
+	assert.Equal(t, float64(0), scope.gauges["gox_http_circuit_open{"+tags+"}"])
+	assert.Equal(t, int64(1), scope.counters["gox_http_call{api=getUser,server=testServer,status=200}"])
+
+	// Buckets from config are used
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 100 * time.Millisecond, time.Second}, scope.buckets["gox_http_request_duration"].AsDurations())
+	assert.Equal(t, command.DefaultResponseSizeBuckets.AsValues(), scope.buckets["gox_http_response_size"].AsValues())
+}
@@ -0,0 +1,181 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	httpCommand "github.com/devlibx/gox-http/command/http"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const otelTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  getUser:
+    path: /users/{id}
+    server: testServer
+    timeout: 1000
+    retry_count: 2
+  createUser:
+    method: POST
+    path: /users
+    server: testServer
+    timeout: 1000
+    retry_count: 0
+`
+
+// Enable tracing with an in-memory exporter - returned func disables it again
+func enableOtelTestTracing() (*tracetest.InMemoryExporter, func()) {
+	exporter := tracetest.NewInMemoryExporter()
+	httpCommand.EnableOpenTelemetryTracing = true
+	httpCommand.OpenTelemetryTracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return exporter, func() {
+		httpCommand.EnableOpenTelemetryTracing = false
+		httpCommand.OpenTelemetryTracerProvider = nil
+	}
+}
+
+func otelAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	values := map[attribute.Key]attribute.Value{}
+	for _, a := range span.Attributes {
+		values[a.Key] = a.Value
+	}
+	return values
+}
+
+func Test_OpenTelemetry_ClientSpanWithAttemptSpans(t *testing.T) {
+	var calls int32
+	var lock sync.Mutex
+	traceParents := make([]string, 0)
+	exporter, disableTracing := enableOtelTestTracing()
+	defer disableTracing()
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, otelTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		traceParents = append(traceParents, r.Header.Get("traceparent"))
+		lock.Unlock()
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": 1}`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	// Client span must be a child of the span in the context
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+	ctx, parent := httpCommand.OpenTelemetryTracerProvider.Tracer("test").Start(ctx, "parent")
+	_, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").WithPathParam("id", 1).Build())
+	parent.End()
+	assert.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	assert.Equal(t, 5, len(spans))
+
+	var call tracetest.SpanStub
+	attempts := make([]tracetest.SpanStub, 0)
+	for _, span := range spans {
+		switch span.Name {
+		case "getUser":
+			call = span
+		case "HTTP GET":
+			attempts = append(attempts, span)
+		}
+	}
+
+	assert.Equal(t, trace.SpanKindClient, call.SpanKind)
+	assert.Equal(t, parent.SpanContext().SpanID(), call.Parent.SpanID())
+	callAttributes := otelAttributes(call)
+	assert.Equal(t, "GET", callAttributes["http.method"].AsString())
+	assert.True(t, strings.HasSuffix(callAttributes["http.url"].AsString(), "/users/1"))
+	assert.Equal(t, int64(200), callAttributes["http.status_code"].AsInt64())
+	assert.Equal(t, int64(2), callAttributes["gox_http.retry_count"].AsInt64())
+	assert.Equal(t, "testServer", callAttributes["gox_http.server"].AsString())
+	assert.Equal(t, "getUser", callAttributes["gox_http.api"].AsString())
+	assert.Equal(t, codes.Unset, call.Status.Code)
+
+	// One span for every attempt - traceparent of the attempt is sent to the server
+	assert.Equal(t, 3, len(attempts))
+	for i, attempt := range attempts {
+		attemptAttributes := otelAttributes(attempt)
+		assert.Equal(t, call.SpanContext.SpanID(), attempt.Parent.SpanID())
+		assert.Equal(t, int64(i+1), attemptAttributes["gox_http.attempt"].AsInt64())
+		assert.Equal(t, fmt.Sprintf("00-%s-%s-01", attempt.SpanContext.TraceID(), attempt.SpanContext.SpanID()), traceParents[i])
+	}
+	assert.Equal(t, int64(503), otelAttributes(attempts[0])["http.status_code"].AsInt64())
+	assert.Equal(t, codes.Error, attempts[0].Status.Code)
+	assert.Equal(t, int64(200), otelAttributes(attempts[2])["http.status_code"].AsInt64())
+}
+
+func Test_OpenTelemetry_ErrorIsRecordedWithErrorCode(t *testing.T) {
+	exporter, disableTracing := enableOtelTestTracing()
+	defer disableTracing()
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, otelTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+	_, err = goxHttpCtx.Execute(ctx, "createUser", command.NewGoxRequestBuilder("createUser").WithBody(`{}`).Build())
+	assert.Error(t, err)
+
+	var call tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "createUser" {
+			call = span
+		}
+	}
+	callAttributes := otelAttributes(call)
+	assert.Equal(t, "POST", callAttributes["http.method"].AsString())
+	assert.Equal(t, int64(400), callAttributes["http.status_code"].AsInt64())
+	assert.Equal(t, "server_response_with_error", callAttributes["gox_http.error_code"].AsString())
+	assert.Equal(t, codes.Error, call.Status.Code)
+	assert.Equal(t, 1, len(call.Events))
+	assert.Equal(t, "exception", call.Events[0].Name)
+}
+
+func Test_OpenTelemetry_NoSpansWhenDisabled(t *testing.T) {
+	var traceParent string
+	exporter, disableTracing := enableOtelTestTracing()
+	defer disableTracing()
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, otelTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent = r.Header.Get("traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": 1}`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+	httpCommand.EnableOpenTelemetryTracing = false
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+	_, err = goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").WithPathParam("id", 1).Build())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(exporter.GetSpans()))
+	assert.Equal(t, "", traceParent)
+}
@@ -0,0 +1,170 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+type paginationTestItem struct {
+	Id int `json:"id"`
+}
+
+const paginationTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  listUsers:
+    path: /users
+    server: testServer
+    timeout: 1000
+    retry_count: 1
+    pagination:
+      type: link
+      items_path: items
+  listItems:
+    path: /items
+    server: testServer
+    timeout: 1000
+    pagination:
+      type: link
+      items_path: items
+      max_pages: 5
+  getUser:
+    path: /users
+    server: testServer
+    timeout: 1000
+`
+
+func Test_Paginate_WithLinkHeaderAndRetry(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	var calls int32
+	config, closeFunc := testserver.Start(t, paginationTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		// Second page fails once - it is retried
+		if atomic.AddInt32(&calls, 1) == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, "active", r.URL.Query().Get("status"))
+		if page < 3 {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/users?status=active&page=%d>; rel="next"`, r.Host, page+1))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"items": [{"id": %d}, {"id": %d}]}`, page*10, page*10+1)
+	}))
+	defer closeFunc()
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	iterator, err := goxHttpCtx.Paginate(context.Background(), "listUsers", command.NewGoxRequestBuilder("listUsers").
+		WithQueryParam("status", "active").
+		Build())
+	assert.NoError(t, err)
+
+	ids := make([]int, 0)
+	for {
+		item := paginationTestItem{}
+		if err := iterator.NextItem(&item); err == io.EOF {
+			break
+		} else {
+			assert.NoError(t, err)
+		}
+		ids = append(ids, item.Id)
+	}
+	assert.Equal(t, []int{10, 11, 20, 21, 30, 31}, ids)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+	// Api without pagination config
+	_, err = goxHttpCtx.Paginate(context.Background(), "getUser", nil)
+	assert.Error(t, err)
+}
+
+func Test_Paginate_NextLinkWithDifferentPath(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	paths := make([]string, 0)
+	config, closeFunc := testserver.Start(t, paginationTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RequestURI())
+		switch r.URL.Path {
+		case "/items":
+			w.Header().Set("Link", `</v2/items?token=abc>; rel="next"`)
+		case "/v2/items":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/v3/items?token=def>; rel="next"`, r.Host))
+		}
+		_, _ = fmt.Fprintf(w, `{"items": [{"id": %d}]}`, len(paths))
+	}))
+	defer closeFunc()
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	iterator, err := goxHttpCtx.Paginate(context.Background(), "listItems", command.NewGoxRequestBuilder("listItems").
+		WithQueryParam("status", "active").
+		Build())
+	assert.NoError(t, err)
+
+	ids := make([]int, 0)
+	for {
+		item := paginationTestItem{}
+		if err := iterator.NextItem(&item); err == io.EOF {
+			break
+		} else if !assert.NoError(t, err) {
+			break
+		}
+		ids = append(ids, item.Id)
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+
+	// Relative and absolute next links are called as they are
+	assert.Equal(t, []string{"/items?status=active", "/v2/items?token=abc", "/v3/items?token=def"}, paths)
+}
+
+func Test_Paginate_NextLinkToOtherHostIsRejected(t *testing.T) {
+	cf, _ := test.MockCf(t)
+
+	// Server of other host must not get the call (auth and headers of the server would be sent to it)
+	var otherHostCalls int32
+	otherHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&otherHostCalls, 1)
+		_, _ = fmt.Fprint(w, `{"items": [{"id": 2}]}`)
+	}))
+	defer otherHost.Close()
+
+	config, closeFunc := testserver.Start(t, paginationTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", fmt.Sprintf(`<%s/items?token=abc>; rel="next"`, otherHost.URL))
+		_, _ = fmt.Fprint(w, `{"items": [{"id": 1}]}`)
+	}))
+	defer closeFunc()
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	iterator, err := goxHttpCtx.Paginate(context.Background(), "listItems", nil)
+	assert.NoError(t, err)
+
+	page, err := iterator.NextPage()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Number)
+
+	_, err = iterator.NextPage()
+	goxErr, ok := err.(*command.GoxHttpError)
+	if assert.True(t, ok, "expected GoxHttpError, got %v", err) {
+		assert.Equal(t, command.ErrorCodeFailedToBuildRequest, goxErr.ErrorCode)
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(&otherHostCalls))
+}
@@ -0,0 +1,121 @@
+package goxHttpApi
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_Protobuf_RequestResponseAndErrorProto(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		request := &structpb.Struct{}
+		switch r.Header.Get("Content-Type") {
+		case "application/x-protobuf":
+			assert.NoError(t, proto.Unmarshal(body, request))
+		case "application/json":
+			assert.NoError(t, protojson.Unmarshal(body, request))
+		default:
+			assert.Fail(t, "unexpected content type: "+r.Header.Get("Content-Type"))
+		}
+
+		// Fail with an error proto
+		if request.Fields["fail"].GetBoolValue() {
+			w.Header().Set("Content-Type", "application/x-protobuf")
+			w.WriteHeader(http.StatusBadRequest)
+			body, _ = proto.Marshal(wrapperspb.String("invalid order"))
+			_, _ = w.Write(body)
+			return
+		}
+
+		response, _ := structpb.NewStruct(map[string]interface{}{"id": request.Fields["id"].GetStringValue(), "status": "created"})
+		if r.Header.Get("Content-Type") == "application/json" {
+			w.Header().Set("Content-Type", "application/json")
+			body, _ = protojson.Marshal(response)
+		} else {
+			w.Header().Set("Content-Type", "application/x-protobuf")
+			body, _ = proto.Marshal(response)
+		}
+		_, _ = w.Write(body)
+	}))
+	defer ts.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+	config.Apis["delay_timeout_10"].DisableHystrix = true
+	config.Apis["delay_timeout_10"].Timeout = 1000
+	config.Apis["delay_timeout_10"].Method = "POST"
+	config.Apis["delay_timeout_10"].ErrorProto = "google.protobuf.StringValue"
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Proto body is sent as protobuf by default, and response is decoded into the given message
+	body, _ := structpb.NewStruct(map[string]interface{}{"id": "1"})
+	out := &structpb.Struct{}
+	response, err := goxHttpCtx.Execute(ctx, "delay_timeout_10", command.NewGoxRequestBuilder("delay_timeout_10").
+		WithBody(body).
+		WithResponseTarget(out).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, out, response.Response)
+	assert.Equal(t, "1", out.Fields["id"].GetStringValue())
+	assert.Equal(t, "created", out.Fields["status"].GetStringValue())
+
+	// Proto body with json content type is sent using protojson
+	out = &structpb.Struct{}
+	_, err = goxHttpCtx.Execute(ctx, "delay_timeout_10", command.NewGoxRequestBuilder("delay_timeout_10").
+		WithContentTypeJson().
+		WithBody(body).
+		WithResponseTarget(out).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "created", out.Fields["status"].GetStringValue())
+
+	// Error response is decoded into error proto
+	body, _ = structpb.NewStruct(map[string]interface{}{"id": "1", "fail": true})
+	_, err = goxHttpCtx.Execute(ctx, "delay_timeout_10", command.NewGoxRequestBuilder("delay_timeout_10").
+		WithBody(body).
+		WithResponseTarget(&structpb.Struct{}).
+		Build())
+	assert.Error(t, err)
+	if e, ok := err.(*command.GoxHttpError); ok {
+		assert.True(t, e.IsBadRequest())
+		if status, ok := e.DecodedBody.(*wrapperspb.StringValue); ok {
+			assert.Equal(t, "invalid order", status.GetValue())
+		} else {
+			assert.Fail(t, "expected error to be decoded into error proto")
+		}
+	} else {
+		assert.Fail(t, "expected GoxHttpError error")
+	}
+}
+
+func Test_Protobuf_UnknownErrorProtoFailsSetup(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(testhelper.TestConfigWithRealServer, &config)
+	assert.NoError(t, err)
+	config.Apis["delay_timeout_10"].ErrorProto = "not.a.Message"
+
+	_, err = NewGoxHttpContext(cf, &config)
+	assert.Error(t, err)
+}
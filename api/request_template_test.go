@@ -0,0 +1,128 @@
+package goxHttpApi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	httpCommand "github.com/devlibx/gox-http/command/http"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const requestTemplateTestConfig = `
+servers:
+  testServer:
+    host: localhost
+    port: 9123
+apis:
+  createUser:
+    method: POST
+    path: /tenants/{tenant}/users
+    server: testServer
+    timeout: 1000
+    query_params:
+      source: '{{ .ParamOr "source" "web" }}'
+      dry_run: '{{ if .Has "dry_run" }}true{{ end }}'
+    body_template: |
+      {
+        "name": {{ .Param "name" }},
+        "tags": {{ json (.ParamOr "tags" nil) }}
+      }
+  getUser:
+    path: /users/{id}
+    server: testServer
+    timeout: 1000
+`
+
+func Test_RequestTemplate(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	httpCommand.HystrixConfigMap = gox.StringObjectMap{}
+	hystrix.Flush()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"path":         r.URL.Path,
+			"query":        r.URL.RawQuery,
+			"content_type": r.Header.Get("Content-Type"),
+			"body":         string(body),
+		})
+	}))
+	defer ts.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(requestTemplateTestConfig, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Path, query and body are filled from template params
+	response, err := goxHttpCtx.Execute(ctx, "createUser", command.NewGoxRequestBuilder("createUser").
+		WithTemplateParam("tenant", "t1").
+		WithTemplateParam("name", `John "H"`).
+		WithTemplateParam("tags", []string{"a", "b"}).
+		Build())
+	assert.NoError(t, err)
+	result := response.AsStringObjectMapOrEmpty()
+	assert.Equal(t, "/tenants/t1/users", result.StringOrEmpty("path"))
+	assert.Equal(t, "source=web", result.StringOrEmpty("query"))
+	assert.Equal(t, "application/json", result.StringOrEmpty("content_type"))
+	assert.JSONEq(t, `{"name": "John \"H\"", "tags": ["a", "b"]}`, result.StringOrEmpty("body"))
+
+	// Params set in request override the template, and a request body is used as it is
+	response, err = goxHttpCtx.Execute(ctx, "createUser", command.NewGoxRequestBuilder("createUser").
+		WithTemplateParam("tenant", "t1").
+		WithTemplateParam("dry_run", true).
+		WithPathParam("tenant", "t2").
+		WithQueryParam("source", "mobile").
+		WithBody(map[string]string{"name": "Jane"}).
+		Build())
+	assert.NoError(t, err)
+	result = response.AsStringObjectMapOrEmpty()
+	assert.Equal(t, "/tenants/t2/users", result.StringOrEmpty("path"))
+	assert.Equal(t, "dry_run=true&source=mobile", result.StringOrEmpty("query"))
+	assert.JSONEq(t, `{"name": "Jane"}`, result.StringOrEmpty("body"))
+
+	// Missing param fails the request
+	_, err = goxHttpCtx.Execute(ctx, "createUser", command.NewGoxRequestBuilder("createUser").
+		WithTemplateParam("tenant", "t1").
+		Build())
+	goxErr, ok := err.(*command.GoxHttpError)
+	assert.True(t, ok)
+	assert.Equal(t, command.ErrorCodeFailedToBuildRequest, goxErr.ErrorCode)
+}
+
+func Test_RequestTemplate_PathParamsOfApiWithoutTemplates(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, requestTemplateTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"path": r.URL.Path})
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Path params are filled from template params even if api has no query or body template
+	response, err := goxHttpCtx.Execute(ctx, "getUser", command.NewGoxRequestBuilder("getUser").
+		WithTemplateParam("id", "u1").
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/u1", response.AsStringObjectMapOrEmpty().StringOrEmpty("path"))
+}
@@ -0,0 +1,103 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	httpCommand "github.com/devlibx/gox-http/command/http"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const serverUrlTestConfig = `
+servers:
+  testServer:
+    url: http://localhost:9123/v2
+    base_path: payments
+apis:
+  getPayment:
+    path: /{id}
+    server: testServer
+    timeout: 1000
+    allow_url_override: true
+  getRefund:
+    path: /refunds/{id}
+    server: testServer
+    timeout: 1000
+`
+
+func Test_ServerUrl(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	httpCommand.HystrixConfigMap = gox.StringObjectMap{}
+	hystrix.Flush()
+
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"server": "%s", "path": "%s"}`, name, r.URL.Path)
+		}))
+	}
+	ts := newServer("main")
+	defer ts.Close()
+	other := newServer("other")
+	defer other.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(serverUrlTestConfig, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:9123/v2", config.Servers["testServer"].Url)
+	assert.Equal(t, "payments", config.Servers["testServer"].BasePath)
+	assert.True(t, config.Apis["getPayment"].AllowUrlOverride)
+
+	// Url of httptest server can be used as it is
+	config.Servers["testServer"].Url = ts.URL + "/v2"
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	execute := func(api string, request *command.GoxRequest) (gox.StringObjectMap, error) {
+		response, err := goxHttpCtx.Execute(ctx, api, request)
+		if err != nil {
+			return nil, err
+		}
+		return response.AsStringObjectMapOrEmpty(), nil
+	}
+
+	// Base path of the server is added to api path
+	result, err := execute("getPayment", command.NewGoxRequestBuilder("getPayment").WithPathParam("id", "p1").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "/v2/payments/p1", result.StringOrEmpty("path"))
+
+	// Request overrides the path
+	result, err = execute("getPayment", command.NewGoxRequestBuilder("getPayment").
+		WithPath("/archive/{id}").
+		WithPathParam("id", "p1").
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "/v2/payments/archive/p1", result.StringOrEmpty("path"))
+
+	// Request overrides the full url
+	result, err = execute("getPayment", command.NewGoxRequestBuilder("getPayment").
+		WithUrl(other.URL+"/payments/{id}").
+		WithPathParam("id", "p1").
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "other", result.StringOrEmpty("server"))
+	assert.Equal(t, "/payments/p1", result.StringOrEmpty("path"))
+
+	// Api does not allow override
+	_, err = execute("getRefund", command.NewGoxRequestBuilder("getRefund").WithPath("/other").Build())
+	goxErr, ok := err.(*command.GoxHttpError)
+	assert.True(t, ok)
+	assert.Equal(t, command.ErrorCodeFailedToBuildRequest, goxErr.ErrorCode)
+}
@@ -0,0 +1,200 @@
+package goxHttpApi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	httpCommand "github.com/devlibx/gox-http/command/http"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+var signingTestConfig = `
+servers:
+  testServer:
+    host: localhost
+    port: 9123
+    signing:
+      type: hmac
+      key_id: partner_1
+      secret: top_secret
+      signed_headers: content-type,host
+
+apis:
+  signed_post:
+    method: POST
+    path: /orders/{id}
+    server: testServer
+    timeout: 1000
+    retry_count: 1
+  custom_signed_post:
+    method: POST
+    path: /orders/{id}
+    server: testServer
+    timeout: 1000
+    signing:
+      type: hmac
+      secret: top_secret
+      canonicalizer: body_only
+  signed_get_with_transport_headers:
+    path: /orders/{id}
+    server: testServer
+    timeout: 1000
+    response_compression: true
+    signing:
+      type: hmac
+      secret: top_secret
+      signed_headers: accept-encoding,traceparent
+  unsigned_post:
+    method: POST
+    path: /orders/{id}
+    server: testServer
+    timeout: 1000
+    signing:
+      type: none
+`
+
+// Vendor specific scheme used in tests - sign only the body
+func registerBodyOnlyCanonicalizer() {
+	command.RegisterCanonicalizer("body_only", command.CanonicalizerFunc(func(request *http.Request, body []byte, signedHeaders []string, timestamp string, nonce string) (string, error) {
+		return string(body), nil
+	}))
+}
+
+func Test_Signing_IsVerifiedAndFreshOnEveryRetry(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	registerBodyOnlyCanonicalizer()
+
+	// Verify signature of every attempt - first attempt fails to trigger a retry
+	lock := sync.Mutex{}
+	nonces := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		canonical, _ := command.DefaultCanonicalize(r, body, []string{"content-type", "host"}, r.Header.Get("X-Timestamp"), r.Header.Get("X-Nonce"))
+		mac := hmac.New(sha256.New, []byte("top_secret"))
+		mac.Write([]byte(canonical))
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get("X-Signature"))
+		assert.Equal(t, "partner_1", r.Header.Get("X-Key-Id"))
+
+		lock.Lock()
+		defer lock.Unlock()
+		nonces = append(nonces, r.Header.Get("X-Nonce"))
+		if len(nonces) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = fmt.Fprintln(w, `{"status": "ok"}`)
+	}))
+	defer ts.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(signingTestConfig, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+	config.Apis["signed_post"].DisableHystrix = true
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	request := command.NewGoxRequestBuilder("signed_post").
+		WithPathParam("id", 10).
+		WithQueryParam("b", "2").
+		WithQueryParam("a", "1 2").
+		WithBody(map[string]interface{}{"amount": 100}).
+		Build()
+	response, err := goxHttpCtx.Execute(ctx, "signed_post", request)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", response.AsStringObjectMapOrEmpty().StringOrEmpty("status"))
+	assert.Equal(t, 2, len(nonces))
+	assert.NotEqual(t, nonces[0], nonces[1])
+}
+
+func Test_Signing_CustomCanonicalizerAndDisabledSigning(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	registerBodyOnlyCanonicalizer()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"signature": "%s"}`, r.Header.Get("X-Signature"))
+	}))
+	defer ts.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(signingTestConfig, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	request := command.NewGoxRequestBuilder("custom_signed_post").WithPathParam("id", 1).WithBody([]byte("hello")).Build()
+	response, err := goxHttpCtx.Execute(ctx, "custom_signed_post", request)
+	assert.NoError(t, err)
+	mac := hmac.New(sha256.New, []byte("top_secret"))
+	mac.Write([]byte("hello"))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), response.AsStringObjectMapOrEmpty().StringOrEmpty("signature"))
+
+	request = command.NewGoxRequestBuilder("unsigned_post").WithPathParam("id", 1).WithBody([]byte("hello")).Build()
+	response, err = goxHttpCtx.Execute(ctx, "unsigned_post", request)
+	assert.NoError(t, err)
+	assert.Equal(t, "", response.AsStringObjectMapOrEmpty().StringOrEmpty("signature"))
+}
+
+func Test_Signing_HeadersSetByTransportsAreSigned(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	registerBodyOnlyCanonicalizer()
+	httpCommand.EnableOpenTelemetryTracing = true
+	httpCommand.OpenTelemetryTracerProvider = sdktrace.NewTracerProvider()
+	defer func() {
+		httpCommand.EnableOpenTelemetryTracing = false
+		httpCommand.OpenTelemetryTracerProvider = nil
+	}()
+
+	// Accept-Encoding is set by decompressing transport, and traceparent by tracing transport
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, r.Header.Get("Accept-Encoding"))
+		assert.NotEmpty(t, r.Header.Get("traceparent"))
+		body, _ := ioutil.ReadAll(r.Body)
+		canonical, _ := command.DefaultCanonicalize(r, body, []string{"accept-encoding", "traceparent"}, r.Header.Get("X-Timestamp"), r.Header.Get("X-Nonce"))
+		mac := hmac.New(sha256.New, []byte("top_secret"))
+		mac.Write([]byte(canonical))
+		if hex.EncodeToString(mac.Sum(nil)) != r.Header.Get("X-Signature") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintln(w, `{"status": "ok"}`)
+	}))
+	defer ts.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(signingTestConfig, &config)
+	assert.NoError(t, err)
+	config.Servers["testServer"].Url = ts.URL
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	request := command.NewGoxRequestBuilder("signed_get_with_transport_headers").WithPathParam("id", 1).Build()
+	response, err := goxHttpCtx.Execute(ctx, "signed_get_with_transport_headers", request)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", response.AsStringObjectMapOrEmpty().StringOrEmpty("status"))
+}
@@ -0,0 +1,217 @@
+package goxHttpApi
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	httpCommand "github.com/devlibx/gox-http/command/http"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const soapTestConfig = `
+servers:
+  testServer:
+    host: localhost
+    port: 9123
+apis:
+  getPrice:
+    type: soap
+    path: /soap
+    server: testServer
+    timeout: 1000
+    soap:
+      action: http://example.com/prices/GetPrice
+      namespace: http://example.com/prices
+  getPrice12:
+    type: soap
+    path: /soap12
+    server: testServer
+    timeout: 1000
+    soap:
+      action: http://example.com/prices/GetPrice
+      version: "1.2"
+      namespace: http://example.com/prices
+`
+
+const soapFaultTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  getPrice:
+    type: soap
+    path: /soap
+    server: testServer
+    timeout: 1000
+    retry_count: 2
+    soap:
+      action: http://example.com/prices/GetPrice
+      namespace: http://example.com/prices
+`
+
+type soapTestGetPrice struct {
+	XMLName xml.Name `xml:"GetPrice"`
+	Item    string   `xml:"Item"`
+}
+
+type soapTestGetPriceResponse struct {
+	XMLName xml.Name `xml:"GetPriceResponse"`
+	Price   float64  `xml:"Price"`
+}
+
+type soapTestEnvelope struct {
+	XMLName xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"`
+	Header  struct {
+		Token string `xml:"Auth>Token"`
+	} `xml:"Header"`
+	Body struct {
+		GetPrice struct {
+			XMLName xml.Name
+			Item    string `xml:"Item"`
+		} `xml:"http://example.com/prices GetPrice"`
+	} `xml:"Body"`
+}
+
+func Test_Soap(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	httpCommand.HystrixConfigMap = gox.StringObjectMap{}
+	hystrix.Flush()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if r.URL.Path == "/soap12" {
+			assert.Equal(t, `application/soap+xml; charset=utf-8; action="http://example.com/prices/GetPrice"`, r.Header.Get("Content-Type"))
+			assert.Equal(t, "", r.Header.Get("SOAPAction"))
+			assert.True(t, strings.Contains(string(body), `xmlns:soap="http://www.w3.org/2003/05/soap-envelope"`))
+			w.Header().Set("Content-Type", "application/soap+xml")
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = fmt.Fprint(w, `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body><env:Fault>
+<env:Code><env:Value>env:Receiver</env:Value></env:Code><env:Reason><env:Text xml:lang="en">Price service is down</env:Text></env:Reason>
+</env:Fault></env:Body></env:Envelope>`)
+			return
+		}
+
+		assert.Equal(t, "text/xml; charset=utf-8", r.Header.Get("Content-Type"))
+		envelope := &soapTestEnvelope{}
+		assert.NoError(t, xml.Unmarshal(body, envelope))
+		assert.Equal(t, "abcd", envelope.Header.Token)
+
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		switch envelope.Body.GetPrice.Item {
+		case "apple":
+			assert.Equal(t, `"http://example.com/prices/GetPrice"`, r.Header.Get("SOAPAction"))
+			_, _ = fmt.Fprint(w, `<?xml version="1.0"?><soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>
+<m:GetPriceResponse xmlns:m="http://example.com/prices"><m:Price>1.5</m:Price></m:GetPriceResponse></soap:Body></soap:Envelope>`)
+		default:
+			assert.Equal(t, `"http://example.com/prices/GetPriceV2"`, r.Header.Get("SOAPAction"))
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = fmt.Fprint(w, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>
+<faultcode>soap:Client</faultcode><faultstring>Unknown item</faultstring><detail><item>mango</item></detail>
+</soap:Fault></soap:Body></soap:Envelope>`)
+		}
+	}))
+	defer ts.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(soapTestConfig, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, "POST", config.Apis["getPrice"].Method)
+	config.Servers["testServer"].Url = ts.URL
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	// Body is wrapped in envelope and response is extracted from soap:Body
+	result := &soapTestGetPriceResponse{}
+	_, err = goxHttpCtx.Execute(ctx, "getPrice", command.NewGoxRequestBuilder("getPrice").
+		WithBody(soapTestGetPrice{Item: "apple"}).
+		WithSoapHeader([]byte(`<Auth><Token>abcd</Token></Auth>`)).
+		WithResponseTarget(result).
+		Build())
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, result.Price)
+
+	// Fault is returned as GoxHttpError
+	_, err = goxHttpCtx.Execute(ctx, "getPrice", command.NewGoxRequestBuilder("getPrice").
+		WithBody(soapTestGetPrice{Item: "mango"}).
+		WithSoapHeader([]byte(`<Auth><Token>abcd</Token></Auth>`)).
+		WithSoapAction("http://example.com/prices/GetPriceV2").
+		Build())
+	goxErr, ok := err.(*command.GoxHttpError)
+	assert.True(t, ok)
+	assert.True(t, goxErr.IsSoapFault())
+	assert.Equal(t, http.StatusInternalServerError, goxErr.StatusCode)
+	assert.Equal(t, "soap:Client", goxErr.SoapFault().Code)
+	assert.Equal(t, "Unknown item", goxErr.SoapFault().String)
+	assert.Equal(t, "<item>mango</item>", goxErr.SoapFault().Detail)
+
+	// SOAP 1.2 sends action in content type
+	_, err = goxHttpCtx.Execute(ctx, "getPrice12", command.NewGoxRequestBuilder("getPrice12").
+		WithBody(soapTestGetPrice{Item: "apple"}).
+		Build())
+	goxErr, ok = err.(*command.GoxHttpError)
+	assert.True(t, ok)
+	assert.Equal(t, "env:Receiver", goxErr.SoapFault().Code)
+	assert.Equal(t, "Price service is down", goxErr.SoapFault().String)
+}
+
+func Test_Soap_FaultIsNotRetriedAndDoesNotOpenCircuit(t *testing.T) {
+	var calls int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, soapFaultTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprint(w, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>
+<faultcode>soap:Client</faultcode><faultstring>Unknown item</faultstring></soap:Fault></soap:Body></soap:Envelope>`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxC()
+
+	// Server gives the same fault again - so it is not retried
+	_, err = goxHttpCtx.Execute(ctx, "getPrice", command.NewGoxRequestBuilder("getPrice").
+		WithBody(soapTestGetPrice{Item: "mango"}).
+		Build())
+	goxErr, ok := err.(*command.GoxHttpError)
+	if assert.True(t, ok) {
+		assert.True(t, goxErr.IsSoapFault())
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	circuitOpenCount := 0
+	for i := 0; i < 200; i++ {
+		_, err := goxHttpCtx.Execute(ctx, "getPrice", command.NewGoxRequestBuilder("getPrice").
+			WithBody(soapTestGetPrice{Item: "mango"}).
+			Build())
+		goxErr, ok := err.(*command.GoxHttpError)
+		assert.True(t, ok)
+		if goxErr.IsHystrixCircuitOpenError() {
+			circuitOpenCount++
+		} else {
+			assert.True(t, goxErr.IsSoapFault())
+		}
+	}
+	assert.Equal(t, 0, circuitOpenCount)
+}
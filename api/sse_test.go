@@ -0,0 +1,208 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const sseTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  getEvents:
+    path: /events
+    server: testServer
+    timeout: 500
+    sse:
+      retry_ms: 5000
+  getLimitedEvents:
+    path: /events
+    server: testServer
+    timeout: 500
+    sse:
+      retry_ms: 10
+      max_reconnects: 2
+  getUsers:
+    path: /users
+    server: testServer
+    timeout: 500
+`
+
+func Test_Sse_ReconnectsWithLastEventId(t *testing.T) {
+	var connects int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, sseTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		switch atomic.AddInt32(&connects, 1) {
+		case 1:
+			// Send 2 events and drop the connection - retry hint is smaller than configured retry
+			assert.Equal(t, "", r.Header.Get("Last-Event-ID"))
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, "retry: 10\n\nid: 1\ndata: one\n\nid: 2\nevent: update\ndata: two\n\n")
+		case 2:
+			// Fail once - reconnect goes through circuit breaker and is retried
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 3:
+			assert.Equal(t, "2", r.Header.Get("Last-Event-ID"))
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, "id: 3\ndata: three\n\n")
+		default:
+			// Tell client to stop reconnecting
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 3*time.Second)
+	defer ctxC()
+
+	stream, err := goxHttpCtx.ExecuteSse(ctx, "getEvents", command.NewGoxRequestBuilder("getEvents").Build())
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	events := make([]*command.SseEvent, 0)
+	for {
+		event, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		if err != nil {
+			break
+		}
+		events = append(events, event)
+	}
+
+	assert.Equal(t, []*command.SseEvent{
+		{Id: "1", Event: "message", Data: "one"},
+		{Id: "2", Event: "update", Data: "two"},
+		{Id: "3", Event: "message", Data: "three"},
+	}, events)
+	assert.Equal(t, "3", stream.LastEventId())
+	assert.Equal(t, int32(4), atomic.LoadInt32(&connects))
+
+	// Stream is done
+	_, err = stream.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func Test_Sse_CloseUnblocksNext(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, sseTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "data: hello\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	stream, err := goxHttpCtx.ExecuteSse(context.Background(), "getEvents", command.NewGoxRequestBuilder("getEvents").Build())
+	assert.NoError(t, err)
+
+	event, err := stream.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", event.Data)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = stream.Close()
+	}()
+	_, err = stream.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func Test_Sse_ErrorWhichCanNotBeRetried(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, sseTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	_, err = goxHttpCtx.ExecuteSse(context.Background(), "getEvents", command.NewGoxRequestBuilder("getEvents").Build())
+	assert.Error(t, err)
+	if e, ok := err.(*command.GoxHttpError); ok {
+		assert.True(t, e.IsNotFound())
+	} else {
+		assert.Fail(t, "expected GoxHttpError error")
+	}
+
+	// Api without sse config
+	_, err = goxHttpCtx.ExecuteSse(context.Background(), "getUsers", command.NewGoxRequestBuilder("getUsers").Build())
+	assert.Error(t, err)
+	if e, ok := err.(*command.GoxHttpError); ok {
+		assert.Equal(t, command.ErrorCodeFailedToBuildRequest, e.ErrorCode)
+	} else {
+		assert.Fail(t, "expected GoxHttpError error")
+	}
+}
+
+func Test_Sse_FailsAfterMaxReconnectsWithoutEvent(t *testing.T) {
+	var connects int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, sseTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		// First connect sends an event - later connects are dropped without an event
+		if atomic.AddInt32(&connects, 1) == 1 {
+			_, _ = fmt.Fprint(w, "id: 1\ndata: one\n\n")
+		}
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, config.Apis["getLimitedEvents"].Sse.MaxReconnects)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 3*time.Second)
+	defer ctxC()
+
+	stream, err := goxHttpCtx.ExecuteSse(ctx, "getLimitedEvents", command.NewGoxRequestBuilder("getLimitedEvents").Build())
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	event, err := stream.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "one", event.Data)
+
+	_, err = stream.Next()
+	goxErr, ok := err.(*command.GoxHttpError)
+	if assert.True(t, ok, "expected GoxHttpError, got %v", err) {
+		assert.Equal(t, command.ErrorCodeFailedToRequestServer, goxErr.ErrorCode)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&connects))
+}
+
+func Test_Sse_FailsIfResponseIsNotEventStream(t *testing.T) {
+	var connects int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, sseTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connects, 1)
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, "<html>data: not an event</html>")
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	_, err = goxHttpCtx.ExecuteSse(context.Background(), "getEvents", command.NewGoxRequestBuilder("getEvents").Build())
+	goxErr, ok := err.(*command.GoxHttpError)
+	if assert.True(t, ok, "expected GoxHttpError, got %v", err) {
+		assert.Equal(t, "unexpected_content_type", goxErr.ErrorCode)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&connects))
+}
@@ -0,0 +1,167 @@
+package goxHttpApi
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const streamTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  getEvents:
+    path: /events
+    server: testServer
+    timeout: 100
+  getData:
+    path: /data
+    server: testServer
+    timeout: 1000
+    max_response_bytes: 1000
+`
+
+func Test_Stream_BodyCanBeReadAfterApiTimeout(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, streamTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 1; i <= 5; i++ {
+			_, _ = fmt.Fprintf(w, "{\"id\": %d}\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxC()
+
+	// Reading full body takes 250ms, which is more than the api timeout of 100ms
+	response, err := goxHttpCtx.ExecuteStream(ctx, "getEvents", command.NewGoxRequestBuilder("getEvents").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Nil(t, response.Body)
+	defer response.BodyStream.Close()
+
+	ids := make([]int, 0)
+	decoder := command.NewNdJsonDecoder(response.BodyStream)
+	for {
+		item := gox.StringObjectMap{}
+		if err := decoder.Next(&item); err == io.EOF {
+			break
+		} else {
+			assert.NoError(t, err)
+		}
+		ids = append(ids, item.IntOrZero("id"))
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+}
+
+func Test_Stream_TimeoutBeforeHeaders(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, streamTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		_, _ = fmt.Fprint(w, `[]`)
+	}))
+	defer closeFunc()
+	config.Apis["getEvents"].DisableHystrix = true
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	_, err = goxHttpCtx.ExecuteStream(context.Background(), "getEvents", command.NewGoxRequestBuilder("getEvents").Build())
+	assert.Error(t, err)
+	if e, ok := err.(*command.GoxHttpError); ok {
+		assert.Equal(t, "request_timeout_on_client", e.ErrorCode)
+	} else {
+		assert.Fail(t, "expected GoxHttpError error")
+	}
+}
+
+func Test_Stream_ErrorResponseBodyIsRead(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, streamTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprint(w, `{"error": "not found"}`)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	response, err := goxHttpCtx.ExecuteStream(context.Background(), "getEvents", command.NewGoxRequestBuilder("getEvents").Build())
+	assert.Error(t, err)
+	if e, ok := err.(*command.GoxHttpError); ok {
+		assert.True(t, e.Is4xx())
+		assert.Equal(t, `{"error": "not found"}`, string(e.Body))
+	} else {
+		assert.Fail(t, "expected GoxHttpError error")
+	}
+	assert.Nil(t, response.BodyStream)
+}
+
+func Test_MaxResponseBytes(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, streamTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		if r.URL.Query().Get("chunked") == "true" {
+			w.Header().Set("Content-Type", "application/json")
+			w.(http.Flusher).Flush()
+		}
+		_, _ = fmt.Fprintf(w, `{"data": "%s"}`, strings.Repeat("a", size))
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxC()
+
+	// Small response works
+	response, err := goxHttpCtx.Execute(ctx, "getData", command.NewGoxRequestBuilder("getData").WithQueryParam("size", 10).Build())
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 10), response.AsStringObjectMapOrEmpty().StringOrEmpty("data"))
+
+	// Large response - with and without content-length - in buffered and streaming mode
+	for _, chunked := range []string{"false", "true"} {
+		_, err = goxHttpCtx.Execute(ctx, "getData", command.NewGoxRequestBuilder("getData").WithQueryParam("size", 2000).WithQueryParam("chunked", chunked).Build())
+		assert.Error(t, err, chunked)
+		if e, ok := err.(*command.GoxHttpError); ok {
+			assert.True(t, e.IsResponseTooLargeError(), chunked)
+		} else {
+			assert.Fail(t, "expected GoxHttpError error", chunked)
+		}
+	}
+
+	// Server did not give content-length so the error comes while reading the stream
+	response, err = goxHttpCtx.ExecuteStream(ctx, "getData", command.NewGoxRequestBuilder("getData").WithQueryParam("size", 2000).WithQueryParam("chunked", "true").Build())
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(response.BodyStream)
+	_ = response.BodyStream.Close()
+	assert.Equal(t, 1000, len(data))
+	if e, ok := err.(*command.GoxHttpError); ok {
+		assert.True(t, e.IsResponseTooLargeError())
+	} else {
+		assert.Fail(t, "expected GoxHttpError error")
+	}
+
+	// Server gave content-length so the error comes before we return
+	_, err = goxHttpCtx.ExecuteStream(ctx, "getData", command.NewGoxRequestBuilder("getData").WithQueryParam("size", 2000).Build())
+	if e, ok := err.(*command.GoxHttpError); ok {
+		assert.True(t, e.IsResponseTooLargeError())
+	} else {
+		assert.Fail(t, "expected GoxHttpError error")
+	}
+}
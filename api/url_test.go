@@ -0,0 +1,97 @@
+package goxHttpApi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	httpCommand "github.com/devlibx/gox-http/command/http"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const urlTestConfig = `
+servers:
+  testServer:
+    host: localhost
+    port: 9123
+apis:
+  getItem:
+    path: /users/{user}/items
+    server: testServer
+    timeout: 1000
+  getItemStrict:
+    path: /users/{user}/items
+    server: testServer
+    timeout: 1000
+    strict_url: true
+`
+
+func Test_Url_RepeatedValuesAndPathEscaping(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	httpCommand.HystrixConfigMap = gox.StringObjectMap{}
+	hystrix.Flush()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"path":   r.URL.EscapedPath(),
+			"ids":    r.URL.Query()["id"],
+			"accept": r.Header.Values("Accept"),
+		})
+	}))
+	defer ts.Close()
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(urlTestConfig, &config)
+	assert.NoError(t, err)
+	assert.True(t, config.Apis["getItemStrict"].StrictUrl)
+	config.Servers["testServer"].Url = ts.URL
+
+	goxHttpCtx, err := NewGoxHttpContext(cf, &config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 2*time.Second)
+	defer ctxC()
+
+	for _, api := range []string{"getItem", "getItemStrict"} {
+		response, err := goxHttpCtx.Execute(ctx, api, command.NewGoxRequestBuilder(api).
+			WithPathParam("user", "a/b c?").
+			WithQueryParam("id", 1).
+			WithQueryParam("id", 2).
+			WithHeader("Accept", "application/json").
+			WithHeader("Accept", "text/plain").
+			Build())
+		assert.NoError(t, err)
+		result := struct {
+			Path   string   `json:"path"`
+			Ids    []string `json:"ids"`
+			Accept []string `json:"accept"`
+		}{}
+		assert.NoError(t, json.Unmarshal(response.Body, &result))
+		assert.Equal(t, "/users/a%2Fb%20c%3F/items", result.Path)
+		assert.Equal(t, []string{"1", "2"}, result.Ids)
+		assert.Equal(t, []string{"application/json", "text/plain"}, result.Accept)
+	}
+
+	// Strict url - missing and unknown path params fail the request
+	_, err = goxHttpCtx.Execute(ctx, "getItemStrict", command.NewGoxRequestBuilder("getItemStrict").
+		WithPathParam("userId", "1").
+		Build())
+	goxErr, ok := err.(*command.GoxHttpError)
+	assert.True(t, ok)
+	assert.Equal(t, command.ErrorCodeFailedToBuildRequest, goxErr.ErrorCode)
+	assert.True(t, strings.Contains(goxErr.Message, "missing=[user], unknown=[userId]"))
+
+	// Not strict - missing path param is left as it is
+	response, err := goxHttpCtx.Execute(ctx, "getItem", command.NewGoxRequestBuilder("getItem").Build())
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/%7Buser%7D/items", response.AsStringObjectMapOrEmpty().StringOrEmpty("path"))
+}
@@ -0,0 +1,293 @@
+package goxHttpApi
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/test"
+	"github.com/devlibx/gox-http/command"
+	"github.com/devlibx/gox-http/testhelper/testserver"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type websocketTestMessage struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+const websocketTestConfig = `
+servers:
+  testServer:
+    host: localhost
+apis:
+  chat:
+    path: /chat
+    server: testServer
+    timeout: 1000
+    websocket:
+      ping_interval_ms: -1
+      reconnect_initial_wait_ms: 10
+      reconnect_max_wait_ms: 50
+  chatWithAuth:
+    path: /chat
+    server: testServer
+    timeout: 1000
+    auth:
+      type: bearer
+      token: token_1
+    websocket:
+      ping_interval_ms: -1
+  chatWithHeartbeat:
+    path: /chat
+    server: testServer
+    timeout: 1000
+    websocket:
+      ping_interval_ms: 50
+      pong_timeout_ms: 50
+      reconnect_initial_wait_ms: 10
+      reconnect_max_wait_ms: 50
+  getUsers:
+    path: /users
+    server: testServer
+    timeout: 1000
+`
+
+// Upgrade the request to a websocket connection, and call the handler with it
+func websocketTestHandler(handler func(conn *websocket.Conn, r *http.Request)) http.Handler {
+	upgrader := websocket.Upgrader{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handler(conn, r)
+	})
+}
+
+// Echo all messages back
+func websocketEcho(conn *websocket.Conn, r *http.Request) {
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := conn.WriteMessage(messageType, data); err != nil {
+			return
+		}
+	}
+}
+
+func Test_Websocket_SendAndReceiveJson(t *testing.T) {
+	var authorization, tenant atomic.Value
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, websocketTestConfig, websocketTestHandler(func(conn *websocket.Conn, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		tenant.Store(r.URL.Query().Get("tenant"))
+		websocketEcho(conn, r)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 3*time.Second)
+	defer ctxC()
+
+	conn, err := goxHttpCtx.OpenWebsocket(ctx, "chatWithAuth", command.NewGoxRequestBuilder("chatWithAuth").WithQueryParam("tenant", "t1").Build())
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "Bearer token_1", authorization.Load())
+	assert.Equal(t, "t1", tenant.Load())
+
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, conn.SendJson(ctx, websocketTestMessage{Id: i, Name: "message"}))
+		out := websocketTestMessage{}
+		assert.NoError(t, conn.ReceiveJson(ctx, &out))
+		assert.Equal(t, websocketTestMessage{Id: i, Name: "message"}, out)
+	}
+}
+
+func Test_Websocket_ReconnectWhenServerDropsConnection(t *testing.T) {
+	var connects int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, websocketTestConfig, websocketTestHandler(func(conn *websocket.Conn, r *http.Request) {
+		if atomic.AddInt32(&connects, 1) == 1 {
+			// Send one message and drop the connection
+			_ = conn.WriteMessage(websocket.TextMessage, []byte("first"))
+			return
+		}
+		websocketEcho(conn, r)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 3*time.Second)
+	defer ctxC()
+
+	conn, err := goxHttpCtx.OpenWebsocket(ctx, "chat", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	data, err := conn.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+
+	// Wait for reconnect - Send waits till the new connection is available
+	for atomic.LoadInt32(&connects) < 2 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.NoError(t, conn.Send(ctx, []byte("second")))
+	data, err = conn.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+}
+
+func Test_Websocket_ReconnectWhenPongIsNotReceived(t *testing.T) {
+	var connects int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, websocketTestConfig, websocketTestHandler(func(conn *websocket.Conn, r *http.Request) {
+		if atomic.AddInt32(&connects, 1) == 1 {
+			// Don't read from the connection - ping is never answered with a pong
+			time.Sleep(time.Second)
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("reconnected"))
+		websocketEcho(conn, r)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 3*time.Second)
+	defer ctxC()
+
+	conn, err := goxHttpCtx.OpenWebsocket(ctx, "chatWithHeartbeat", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	start := time.Now()
+	data, err := conn.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "reconnected", string(data))
+	assert.True(t, time.Since(start) < 900*time.Millisecond, "connection must be dropped by heartbeat, not by server")
+}
+
+func Test_Websocket_SlowReceiverDoesNotLoseConnection(t *testing.T) {
+	var connects int32
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, websocketTestConfig, websocketTestHandler(func(conn *websocket.Conn, r *http.Request) {
+		atomic.AddInt32(&connects, 1)
+
+		// Send more messages than the buffer can keep, and keep answering pings
+		for i := 0; i < 100; i++ {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(strconv.Itoa(i)+strings.Repeat(" ", 1000))); err != nil {
+				return
+			}
+		}
+		websocketEcho(conn, r)
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithTimeout(context.Background(), 3*time.Second)
+	defer ctxC()
+
+	conn, err := goxHttpCtx.OpenWebsocket(ctx, "chatWithHeartbeat", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// Receive nothing for longer than ping interval + pong timeout
+	time.Sleep(300 * time.Millisecond)
+	for i := 0; i < 100; i++ {
+		data, err := conn.Receive(ctx)
+		if !assert.NoError(t, err) {
+			break
+		}
+		assert.Equal(t, strconv.Itoa(i), strings.TrimSpace(string(data)))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&connects))
+}
+
+func Test_Websocket_SendFailsWhenContextIsDone(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, websocketTestConfig, websocketTestHandler(websocketEcho))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	conn, err := goxHttpCtx.OpenWebsocket(context.Background(), "chat", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// Write deadline is taken from ctx - failed message is not sent again
+	doneCtx, doneCtxC := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer doneCtxC()
+	err = conn.Send(doneCtx, []byte("late"))
+	goxErr, ok := err.(*command.GoxHttpError)
+	if assert.True(t, ok, "expected GoxHttpError, got %v", err) {
+		assert.Equal(t, "request_timeout_on_client", goxErr.ErrorCode)
+	}
+}
+
+func Test_Websocket_CloseOnContextCancel(t *testing.T) {
+	closeCode := make(chan int, 1)
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, websocketTestConfig, websocketTestHandler(func(conn *websocket.Conn, r *http.Request) {
+		_, _, err := conn.ReadMessage()
+		if closeErr, ok := err.(*websocket.CloseError); ok {
+			closeCode <- closeErr.Code
+		}
+	}))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	ctx, ctxC := context.WithCancel(context.Background())
+	conn, err := goxHttpCtx.OpenWebsocket(ctx, "chat", nil)
+	assert.NoError(t, err)
+
+	ctxC()
+	_, err = conn.Receive(context.Background())
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, io.EOF, conn.Send(context.Background(), []byte("closed")))
+
+	select {
+	case code := <-closeCode:
+		assert.Equal(t, websocket.CloseNormalClosure, code)
+	case <-time.After(time.Second):
+		assert.Fail(t, "server did not get close frame")
+	}
+}
+
+func Test_Websocket_FailsIfNotEnabledOrHandshakeIsRejected(t *testing.T) {
+	cf, _ := test.MockCf(t)
+	config, closeFunc := testserver.Start(t, websocketTestConfig, websocketTestHandler(websocketEcho))
+	defer closeFunc()
+	goxHttpCtx, err := NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+	_, err = goxHttpCtx.OpenWebsocket(context.Background(), "getUsers", nil)
+	assert.Error(t, err)
+
+	// Plain http server rejects the handshake
+	config, closeServer := testserver.Start(t, websocketTestConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer closeServer()
+	goxHttpCtx, err = NewGoxHttpContext(cf, config)
+	assert.NoError(t, err)
+
+	_, err = goxHttpCtx.OpenWebsocket(context.Background(), "chat", nil)
+	goxErr, ok := err.(*command.GoxHttpError)
+	assert.True(t, ok)
+	if ok {
+		assert.Equal(t, http.StatusForbidden, goxErr.StatusCode)
+	}
+}
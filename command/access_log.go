@@ -0,0 +1,84 @@
+package command
+
+import (
+	"github.com/devlibx/gox-base/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+// Access log config of an api - one entry is logged for every call
+// ****************************************************************************************
+// IMP NOTE - "config_parser.go -> UnmarshalYAML() method is created to do custom parsing.
+// If you change anything here (add/update/delete) you must make changes in UnmarshalYAML()
+// ****************************************************************************************
+type AccessLog struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Log level of successful calls (default=info) and failed calls (default=warn) - debug, info, warn or error
+	Level      string `yaml:"level"`
+	ErrorLevel string `yaml:"error_level"`
+
+	// Fraction (0 to 1) of successful and failed calls which are logged - nil means 1 i.e. every call is logged
+	SuccessSampleRate *float64 `yaml:"success_sample_rate"`
+	ErrorSampleRate   *float64 `yaml:"error_sample_rate"`
+
+	// Log request and response body up to these many bytes (0 = body is not logged)
+	MaxBodyBytes int `yaml:"max_body_bytes"`
+}
+
+// GetLevels gives log levels of successful and failed calls
+func (l *AccessLog) GetLevels() (zapcore.Level, zapcore.Level, error) {
+	level, err := parseLogLevel(l.Level, zapcore.InfoLevel)
+	if err != nil {
+		return level, level, errors.Wrap(err, "failed to parse access_log.level")
+	}
+	errorLevel, err := parseLogLevel(l.ErrorLevel, zapcore.WarnLevel)
+	if err != nil {
+		return level, errorLevel, errors.Wrap(err, "failed to parse access_log.error_level")
+	}
+	return level, errorLevel, nil
+}
+
+// GetSampleRates gives sample rates of successful and failed calls - 1 if it is not set
+func (l *AccessLog) GetSampleRates() (float64, float64) {
+	successSampleRate, errorSampleRate := 1.0, 1.0
+	if l.SuccessSampleRate != nil {
+		successSampleRate = *l.SuccessSampleRate
+	}
+	if l.ErrorSampleRate != nil {
+		errorSampleRate = *l.ErrorSampleRate
+	}
+	return successSampleRate, errorSampleRate
+}
+
+// Validate checks levels, sample rates and body size of the access log
+func (l *AccessLog) Validate() error {
+	if _, _, err := l.GetLevels(); err != nil {
+		return err
+	}
+	successSampleRate, errorSampleRate := l.GetSampleRates()
+	if successSampleRate < 0 || successSampleRate > 1 {
+		return errors.New("access_log.success_sample_rate must be between 0 and 1: value=%f", successSampleRate)
+	}
+	if errorSampleRate < 0 || errorSampleRate > 1 {
+		return errors.New("access_log.error_sample_rate must be between 0 and 1: value=%f", errorSampleRate)
+	}
+	if l.MaxBodyBytes < 0 {
+		return errors.New("access_log.max_body_bytes must be >=0: value=%d", l.MaxBodyBytes)
+	}
+	return nil
+}
+
+func parseLogLevel(value string, defaultLevel zapcore.Level) (zapcore.Level, error) {
+	if value == "" {
+		return defaultLevel, nil
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return defaultLevel, err
+	}
+	switch level {
+	case zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel:
+		return level, nil
+	}
+	return defaultLevel, errors.New("unsupported log level=%s - use debug, info, warn or error", value)
+}
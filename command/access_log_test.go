@@ -0,0 +1,82 @@
+package command
+
+import (
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"testing"
+)
+
+func TestAccessLog_GetLevels(t *testing.T) {
+	accessLog := &AccessLog{Enabled: true}
+	level, errorLevel, err := accessLog.GetLevels()
+	assert.NoError(t, err)
+	assert.Equal(t, zapcore.InfoLevel, level)
+	assert.Equal(t, zapcore.WarnLevel, errorLevel)
+
+	accessLog = &AccessLog{Enabled: true, Level: "debug", ErrorLevel: "ERROR"}
+	level, errorLevel, err = accessLog.GetLevels()
+	assert.NoError(t, err)
+	assert.Equal(t, zapcore.DebugLevel, level)
+	assert.Equal(t, zapcore.ErrorLevel, errorLevel)
+
+	_, _, err = (&AccessLog{Level: "verbose"}).GetLevels()
+	assert.Error(t, err)
+	_, _, err = (&AccessLog{ErrorLevel: "fatal"}).GetLevels()
+	assert.Error(t, err)
+}
+
+func TestAccessLog_GetSampleRates(t *testing.T) {
+
+	// Config built in code logs every call by default
+	successSampleRate, errorSampleRate := (&AccessLog{Enabled: true}).GetSampleRates()
+	assert.Equal(t, 1.0, successSampleRate)
+	assert.Equal(t, 1.0, errorSampleRate)
+
+	zero, half := 0.0, 0.5
+	successSampleRate, errorSampleRate = (&AccessLog{Enabled: true, SuccessSampleRate: &zero, ErrorSampleRate: &half}).GetSampleRates()
+	assert.Equal(t, 0.0, successSampleRate)
+	assert.Equal(t, 0.5, errorSampleRate)
+}
+
+func TestParseConfig_AccessLog(t *testing.T) {
+	config := Config{}
+	err := serialization.ReadYamlFromString(`
+apis:
+  getUser:
+    path: /users
+    access_log:
+      level: debug
+      success_sample_rate: 0.25
+      max_body_bytes: 512
+  getOrder:
+    path: /orders
+`, &config)
+	assert.NoError(t, err)
+	successSampleRate := 0.25
+	assert.Equal(t, &AccessLog{
+		Enabled:           true,
+		Level:             "debug",
+		SuccessSampleRate: &successSampleRate,
+		MaxBodyBytes:      512,
+	}, config.Apis["getUser"].AccessLog)
+	assert.Nil(t, config.Apis["getOrder"].AccessLog)
+
+	err = serialization.ReadYamlFromString(`
+apis:
+  getUser:
+    path: /users
+    access_log:
+      error_sample_rate: 2
+`, &Config{})
+	assert.Error(t, err)
+
+	err = serialization.ReadYamlFromString(`
+apis:
+  getUser:
+    path: /users
+    access_log:
+      error_level: loud
+`, &Config{})
+	assert.Error(t, err)
+}
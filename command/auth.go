@@ -0,0 +1,99 @@
+package command
+
+import (
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-base/util"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	AuthTypeNone                    = "none"
+	AuthTypeBasic                   = "basic"
+	AuthTypeBearer                  = "bearer"
+	AuthTypeApiKey                  = "api_key"
+	AuthTypeOAuth2ClientCredentials = "oauth2_client_credentials"
+)
+
+const (
+	AuthInHeader = "header"
+	AuthInQuery  = "query"
+)
+
+const secretPrefix = "secret:"
+
+// SecretResolverFunc resolves a secret reference e.g. "secret:payment_client_secret" -> "payment_client_secret"
+type SecretResolverFunc func(name string) (string, error)
+
+// DefaultSecretResolverFunc is used to resolve "secret:" values in auth config. The default implementation reads
+// the secret from os environment variable. Override it to read secrets from your secret store.
+var DefaultSecretResolverFunc SecretResolverFunc = func(name string) (string, error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, nil
+	}
+	return "", errors.New("secret not found: name=%s", name)
+}
+
+// ResolveSecret returns the value as it is, or resolves it using DefaultSecretResolverFunc if it is a "secret:" value
+func ResolveSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, secretPrefix) {
+		return value, nil
+	}
+	return DefaultSecretResolverFunc(strings.TrimSpace(strings.TrimPrefix(value, secretPrefix)))
+}
+
+// Resolved returns a copy of this auth (with defaults) with all "secret:" values resolved
+func (a *Auth) Resolved() (*Auth, error) {
+	resolved := *a
+	resolved.setupDefaults()
+	for _, field := range []*string{&resolved.Username, &resolved.Password, &resolved.Token, &resolved.Value, &resolved.ClientId, &resolved.ClientSecret} {
+		value, err := ResolveSecret(*field)
+		if err != nil {
+			return nil, err
+		}
+		*field = value
+	}
+	return &resolved, nil
+}
+
+// GetAuth returns the auth to be used for this api (api auth overrides server auth)
+func (a *Api) GetAuth(server *Server) *Auth {
+	var auth *Auth
+	if a.Auth != nil {
+		auth = a.Auth
+	} else if server != nil {
+		auth = server.Auth
+	}
+	if auth == nil || util.IsStringEmpty(auth.Type) || strings.EqualFold(strings.TrimSpace(auth.Type), AuthTypeNone) {
+		return nil
+	}
+	return auth
+}
+
+// GetCredentialHeaders gives headers which carry credentials of a request - Authorization, Proxy-Authorization, Cookie
+// and the header of "api_key" auth (if any). Response of a call made with one caller's credentials must not be given
+// to another caller
+func (a *Api) GetCredentialHeaders(server *Server) []string {
+	headers := []string{"Authorization", "Proxy-Authorization", "Cookie"}
+	if auth := a.GetAuth(server); auth != nil && auth.Type == AuthTypeApiKey && auth.In == AuthInHeader {
+		headers = append(headers, http.CanonicalHeaderKey(auth.Name))
+	}
+	return headers
+}
+
+func (a *Auth) setupDefaults() {
+	a.Type = strings.ToLower(strings.TrimSpace(a.Type))
+	if a.Type == "" {
+		a.Type = AuthTypeNone
+	}
+	if a.In == "" {
+		a.In = AuthInHeader
+	}
+	if a.Name == "" {
+		a.Name = "X-API-Key"
+	}
+	if a.RefreshBeforeExpirySec <= 0 {
+		a.RefreshBeforeExpirySec = 30
+	}
+}
@@ -0,0 +1,169 @@
+package command
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BatchCall is a single named call of a batch
+type BatchCall struct {
+
+	// Name of this call in the result - api name is used if it is empty. Names must be unique in a batch
+	Name    string
+	Api     string
+	Request *GoxRequest
+}
+
+// BatchOptions controls how a batch is executed
+type BatchOptions struct {
+
+	// Max calls which run in parallel (0 = all calls run in parallel)
+	Concurrency int
+
+	// Deadline of the whole batch (0 = only the deadline of ctx)
+	Timeout time.Duration
+
+	// If true, remaining calls are cancelled (and calls not started are skipped) as soon as a call fails. Otherwise
+	// all calls run and all errors are collected
+	FailFast bool
+}
+
+// BatchItemResult is the response or error of a single call
+type BatchItemResult struct {
+	Name     string
+	Api      string
+	Response *GoxResponse
+	Err      error
+	Duration time.Duration
+}
+
+// BatchResult has the result of every call in the batch (keyed by call name)
+type BatchResult struct {
+	Results map[string]*BatchItemResult
+
+	// Names in the order calls were given
+	Names []string
+}
+
+// Get gives the response and error of a call
+func (b *BatchResult) Get(name string) (*GoxResponse, error) {
+	result, ok := b.Results[name]
+	if !ok {
+		return nil, errors.New("call not found in batch: name=%s", name)
+	}
+	return result.Response, result.Err
+}
+
+// HasErrors returns true if any call failed
+func (b *BatchResult) HasErrors() bool {
+	return len(b.Errors()) > 0
+}
+
+// Errors gives the errors of failed calls (keyed by call name)
+func (b *BatchResult) Errors() map[string]error {
+	errs := map[string]error{}
+	for name, result := range b.Results {
+		if result.Err != nil {
+			errs[name] = result.Err
+		}
+	}
+	return errs
+}
+
+// BatchExecutor executes a single call of a batch
+type BatchExecutor func(ctx context.Context, api string, request *GoxRequest) (*GoxResponse, error)
+
+// ExecuteAll runs all calls in parallel (at most options.Concurrency at a time) and waits for all of them. With
+// fail-fast, the first error is returned and other calls are cancelled - the result still has every call (cancelled
+// calls have "batch_cancelled" error). With collect-all, returned error is nil and errors are in the result
+func ExecuteAll(ctx context.Context, options *BatchOptions, calls []*BatchCall, execute BatchExecutor) (*BatchResult, error) {
+	if options == nil {
+		options = &BatchOptions{}
+	}
+
+	result := &BatchResult{Results: map[string]*BatchItemResult{}, Names: make([]string, 0, len(calls))}
+	for _, call := range calls {
+		name := call.Name
+		if name == "" {
+			name = call.Api
+		}
+		if _, ok := result.Results[name]; ok {
+			return nil, &GoxHttpError{
+				Err:        errors.New("duplicate call name in batch: name=%s", name),
+				StatusCode: http.StatusBadRequest,
+				Message:    "duplicate call name in batch: name=" + name,
+				ErrorCode:  ErrorCodeFailedToBuildRequest,
+			}
+		}
+		result.Results[name] = &BatchItemResult{Name: name, Api: call.Api}
+		result.Names = append(result.Names, name)
+	}
+
+	var cancel context.CancelFunc
+	if options.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 || concurrency > len(calls) {
+		concurrency = len(calls)
+	}
+	slots := make(chan struct{}, concurrency)
+
+	var firstErr error
+	var lock sync.Mutex
+	wg := &sync.WaitGroup{}
+	for i, call := range calls {
+		item := result.Results[result.Names[i]]
+
+		// Calls are started in the given order - a call which can't start before batch is done (or cancelled) is skipped
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			item.Err = newBatchCancelledError(ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func(call *BatchCall, item *BatchItemResult) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			start := time.Now()
+			item.Response, item.Err = execute(ctx, call.Api, call.Request)
+			item.Duration = time.Since(start)
+
+			if item.Err != nil && options.FailFast {
+				lock.Lock()
+				if firstErr == nil {
+					firstErr = item.Err
+					cancel()
+				} else if ctx.Err() != nil {
+					// Call failed because batch was cancelled by another call
+					item.Err = newBatchCancelledError(item.Err)
+				}
+				lock.Unlock()
+			}
+		}(call, item)
+	}
+	wg.Wait()
+
+	return result, firstErr
+}
+
+func newBatchCancelledError(err error) error {
+	return &GoxHttpError{
+		Err:        err,
+		StatusCode: http.StatusRequestTimeout,
+		Message:    "call is cancelled because batch is done",
+		ErrorCode:  ErrorCodeBatchCancelled,
+	}
+}
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecuteAll_CollectAll(t *testing.T) {
+	var inFlight, maxInFlight int32
+	execute := func(ctx context.Context, api string, request *GoxRequest) (*GoxResponse, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if api == "bad" {
+			return nil, errors.New("failed")
+		}
+		return &GoxResponse{StatusCode: 200, Body: []byte(api)}, nil
+	}
+
+	calls := []*BatchCall{
+		{Name: "a", Api: "users"}, {Name: "b", Api: "bad"}, {Api: "orders"}, {Name: "d", Api: "users"}, {Name: "e", Api: "users"},
+	}
+	result, err := ExecuteAll(context.Background(), &BatchOptions{Concurrency: 2}, calls, execute)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "orders", "d", "e"}, result.Names)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxInFlight))
+
+	response, err := result.Get("orders")
+	assert.NoError(t, err)
+	assert.Equal(t, "orders", string(response.Body))
+	assert.True(t, result.HasErrors())
+	assert.Equal(t, 1, len(result.Errors()))
+	assert.Error(t, result.Errors()["b"])
+	_, err = result.Get("missing")
+	assert.Error(t, err)
+
+	// Names must be unique
+	_, err = ExecuteAll(context.Background(), nil, []*BatchCall{{Api: "users"}, {Api: "users"}}, execute)
+	assert.Error(t, err)
+}
+
+func TestExecuteAll_FailFastCancelsOtherCalls(t *testing.T) {
+	var started int32
+	execute := func(ctx context.Context, api string, request *GoxRequest) (*GoxResponse, error) {
+		atomic.AddInt32(&started, 1)
+		if api == "bad" {
+			return nil, errors.New("failed")
+		}
+		select {
+		case <-time.After(time.Second):
+			return &GoxResponse{StatusCode: 200}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	calls := []*BatchCall{{Name: "slow", Api: "slow"}, {Name: "bad", Api: "bad"}, {Name: "not_started", Api: "slow"}}
+	start := time.Now()
+	result, err := ExecuteAll(context.Background(), &BatchOptions{Concurrency: 2, FailFast: true}, calls, execute)
+	assert.Error(t, err)
+	assert.Equal(t, "failed", err.Error())
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+
+	_, err = result.Get("slow")
+	assert.True(t, err.(*GoxHttpError).IsBatchCancelledError())
+	_, err = result.Get("not_started")
+	assert.True(t, err.(*GoxHttpError).IsBatchCancelledError())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&started))
+}
+
+func TestExecuteAll_Timeout(t *testing.T) {
+	execute := func(ctx context.Context, api string, request *GoxRequest) (*GoxResponse, error) {
+		if api == "fast" {
+			return &GoxResponse{StatusCode: 200}, nil
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	result, err := ExecuteAll(context.Background(), &BatchOptions{Timeout: 20 * time.Millisecond}, []*BatchCall{{Api: "fast"}, {Api: "slow"}}, execute)
+	assert.NoError(t, err)
+	_, err = result.Get("fast")
+	assert.NoError(t, err)
+	_, err = result.Get("slow")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,126 @@
+package command
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CacheStatus tells if a response was served from cache
+type CacheStatus string
+
+const (
+	// CacheStatusMiss - response came from server (and may have been stored in cache)
+	CacheStatusMiss CacheStatus = "miss"
+
+	// CacheStatusHit - fresh response served from cache without calling server
+	CacheStatusHit CacheStatus = "hit"
+
+	// CacheStatusRevalidated - cached response was revalidated with server (server responded with 304)
+	CacheStatusRevalidated CacheStatus = "revalidated"
+
+	// CacheStatusStale - stale response served from cache (stale-while-revalidate or stale-if-error)
+	CacheStatusStale CacheStatus = "stale"
+)
+
+// CacheEntry is a response stored in cache
+type CacheEntry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// Request header values for all headers listed in the "Vary" response header
+	VaryHeader http.Header
+
+	// Response is fresh till this time. Zero means it must be revalidated before use
+	FreshUntil time.Time
+
+	// Durations after FreshUntil in which a stale response can be used
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+}
+
+// MatchesVary returns true if this entry was stored for a request with same values of "Vary" headers
+func (e *CacheEntry) MatchesVary(header http.Header) bool {
+	for name, values := range e.VaryHeader {
+		got := header.Values(name)
+		if len(got) != len(values) {
+			return false
+		}
+		for i := range values {
+			if got[i] != values[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CacheStore is used to store cached responses. Implement this to use an external store
+type CacheStore interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+}
+
+type lruCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// In-memory LRU cache store
+type lruCacheStore struct {
+	lock       *sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	order      *list.List
+}
+
+func (l *lruCacheStore) Get(key string) (*CacheEntry, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if e, ok := l.items[key]; ok {
+		l.order.MoveToFront(e)
+		return e.Value.(*lruCacheItem).entry, true
+	}
+	return nil, false
+}
+
+func (l *lruCacheStore) Set(key string, entry *CacheEntry) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if e, ok := l.items[key]; ok {
+		e.Value.(*lruCacheItem).entry = entry
+		l.order.MoveToFront(e)
+		return
+	}
+	l.items[key] = l.order.PushFront(&lruCacheItem{key: key, entry: entry})
+	for l.order.Len() > l.maxEntries {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruCacheItem).key)
+	}
+}
+
+func (l *lruCacheStore) Delete(key string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if e, ok := l.items[key]; ok {
+		l.order.Remove(e)
+		delete(l.items, key)
+	}
+}
+
+// NewLruCacheStore creates an in-memory cache store which keeps at most maxEntries responses
+func NewLruCacheStore(maxEntries int) CacheStore {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &lruCacheStore{
+		lock:       &sync.Mutex{},
+		maxEntries: maxEntries,
+		items:      map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
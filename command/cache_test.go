@@ -0,0 +1,28 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLruCacheStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewLruCacheStore(2)
+	store.Set("a", &CacheEntry{StatusCode: 200})
+	store.Set("b", &CacheEntry{StatusCode: 200})
+
+	// "a" is used recently - "b" will be evicted
+	_, found := store.Get("a")
+	assert.True(t, found)
+	store.Set("c", &CacheEntry{StatusCode: 200})
+
+	_, found = store.Get("b")
+	assert.False(t, found)
+	_, found = store.Get("a")
+	assert.True(t, found)
+	_, found = store.Get("c")
+	assert.True(t, found)
+
+	store.Delete("a")
+	_, found = store.Get("a")
+	assert.False(t, found)
+}
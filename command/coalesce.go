@@ -0,0 +1,233 @@
+package command
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Coalescer merges identical in-flight requests into one upstream call. All callers get the result of this call, but
+// each caller still waits only till its own context is done
+type Coalescer struct {
+	server            *Server
+	api               *Api
+	template          *RequestTemplate
+	headers           []string
+	credentialHeaders []string
+	lock              *sync.Mutex
+	calls             map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done     chan struct{}
+	response *GoxResponse
+	err      error
+	waiters  int
+	cancel   context.CancelFunc
+}
+
+// Execute runs the handler for this request, or waits for an identical in-flight request to finish
+func (c *Coalescer) Execute(ctx context.Context, request *GoxRequest, handler Handler) (*GoxResponse, error) {
+	if request == nil || request.Stream || !strings.EqualFold(c.api.Method, http.MethodGet) {
+		return handler(ctx, request)
+	}
+
+	// Request which can not be resolved is not merged - handler gives the error to the caller
+	key, err := c.key(ctx, request)
+	if err != nil {
+		return handler(ctx, request)
+	}
+
+	c.lock.Lock()
+	call, inFlight := c.calls[key]
+	if !inFlight {
+
+		// Upstream call must not be cancelled if the first caller goes away - it is cancelled when all callers leave
+		upstreamCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, time.Duration(c.api.GetTimeoutWithRetryIncluded())*time.Millisecond)
+		call = &coalescedCall{done: make(chan struct{}), cancel: cancel}
+		c.calls[key] = call
+		go func() {
+			defer cancel()
+			call.response, call.err = handler(upstreamCtx, request)
+			c.lock.Lock()
+			if c.calls[key] == call {
+				delete(c.calls, key)
+			}
+			c.lock.Unlock()
+			close(call.done)
+		}()
+	}
+	call.waiters++
+	c.lock.Unlock()
+
+	select {
+	case <-call.done:
+		if !inFlight {
+			return call.response, call.err
+		}
+		return copyCoalescedResponse(request, call.response, call.err)
+	case <-ctx.Done():
+		c.lock.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+
+			// Cancelled call must not be joined by a new request
+			call.cancel()
+			if c.calls[key] == call {
+				delete(c.calls, key)
+			}
+		}
+		c.lock.Unlock()
+		return nil, &GoxHttpError{
+			Err:        ctx.Err(),
+			StatusCode: http.StatusRequestTimeout,
+			Message:    "request timeout while waiting for coalesced request",
+			ErrorCode:  "request_timeout_on_client",
+		}
+	}
+}
+
+// Requests are identical if they have same method, resolved url, selected headers and credential headers. Url is
+// resolved in the same way as it is done for the request sent to the server (query params, path params and body from
+// templates are included). Credentials are kept as a hash in the key
+func (c *Coalescer) key(ctx context.Context, request *GoxRequest) (string, error) {
+	queryParams, pathParams := url.Values{}, MultivaluedMap{}
+	var body []byte
+	if c.template != nil {
+		templateQueryParams, err := c.template.QueryParams(ctx, request)
+		if err != nil {
+			return "", err
+		}
+		for name, values := range templateQueryParams {
+			queryParams[name] = values
+		}
+		for name, value := range c.template.PathParams(request) {
+			pathParams[name] = []string{value}
+		}
+		if request.Body == nil && request.BodyProvider == nil && c.template.HasBody() {
+			if body, err = c.template.Body(ctx, request); err != nil {
+				return "", err
+			}
+		}
+	}
+	for name, values := range request.QueryParam {
+		queryParams[name] = values
+	}
+	for name, values := range request.PathParam {
+		pathParams[name] = values
+	}
+
+	requestUrl, err := c.api.GetRequestUrlWithContext(ctx, c.server, request)
+	if err != nil {
+		return "", err
+	}
+	resolvedPathParams, err := c.api.ResolvePathParams(requestUrl, pathParams)
+	if err != nil {
+		return "", err
+	}
+	for name, value := range resolvedPathParams {
+		requestUrl = strings.ReplaceAll(requestUrl, "{"+name+"}", url.PathEscape(value))
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(strings.ToUpper(c.api.Method))
+	sb.WriteString(" ")
+	sb.WriteString(requestUrl)
+	sb.WriteString("?")
+	sb.WriteString(queryParams.Encode())
+
+	for _, name := range c.headers {
+		values := append([]string{}, request.Header.Values(name)...)
+		sort.Strings(values)
+		sb.WriteString("\n")
+		sb.WriteString(name)
+		sb.WriteString(":")
+		sb.WriteString(strings.Join(values, ","))
+	}
+
+	// Requests of callers with different credentials are never merged
+	hash := sha256.New()
+	for _, name := range c.credentialHeaders {
+		for _, value := range request.Header.Values(name) {
+			_, _ = hash.Write([]byte(name + ": " + value + "\n"))
+		}
+	}
+	sb.WriteString("\n")
+	sb.WriteString(hex.EncodeToString(hash.Sum(nil)))
+
+	if body != nil {
+		sb.WriteString("\n\n")
+		sb.Write(body)
+	}
+	return sb.String(), nil
+}
+
+// Each caller gets its own copy of response (header and body are copied) - built with its own response builder
+func copyCoalescedResponse(request *GoxRequest, response *GoxResponse, err error) (*GoxResponse, error) {
+	if response == nil {
+		return nil, err
+	}
+	out := *response
+	out.Header = response.Header.Clone()
+	if response.Body != nil {
+		out.Body = append([]byte{}, response.Body...)
+	}
+	if responseBuilder := request.GetResponseBuilder(response.Header); err == nil && responseBuilder != nil && response.Body != nil {
+		if processed, buildErr := responseBuilder.Response(response.Body); buildErr == nil {
+			out.Response = processed
+		} else {
+			out.Err = &GoxHttpError{
+				Err:        buildErr,
+				StatusCode: response.StatusCode,
+				Message:    "failed to create response using response builder",
+				ErrorCode:  "failed_to_build_response_using_response_builder",
+				Body:       out.Body,
+			}
+			return &out, out.Err
+		}
+	}
+	return &out, err
+}
+
+// detachedContext keeps the values of parent (e.g. tracing span) but not its deadline or cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (d detachedContext) Deadline() (deadline time.Time, ok bool) { return time.Time{}, false }
+func (d detachedContext) Done() <-chan struct{}                   { return nil }
+func (d detachedContext) Err() error                              { return nil }
+func (d detachedContext) Value(key interface{}) interface{}       { return d.parent.Value(key) }
+
+// NewCoalescer creates a coalescer for this api. Returns nil if coalescing is not enabled
+func NewCoalescer(server *Server, api *Api) (*Coalescer, error) {
+	if !api.Coalesce {
+		return nil, nil
+	}
+	template, err := NewRequestTemplate(api)
+	if err != nil {
+		return nil, err
+	}
+	headers := make([]string, 0)
+	for _, h := range strings.Split(api.CoalesceHeaders, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			headers = append(headers, http.CanonicalHeaderKey(h))
+		}
+	}
+	sort.Strings(headers)
+	return &Coalescer{
+		server:            server,
+		api:               api,
+		template:          template,
+		headers:           headers,
+		credentialHeaders: api.GetCredentialHeaders(server),
+		lock:              &sync.Mutex{},
+		calls:             map[string]*coalescedCall{},
+	}, nil
+}
@@ -0,0 +1,249 @@
+package command
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	ContentTypeJson      = "application/json"
+	ContentTypeXml       = "application/xml"
+	ContentTypeTextXml   = "text/xml"
+	ContentTypeMsgPack   = "application/msgpack"
+	ContentTypeXMsgPack  = "application/x-msgpack"
+	ContentTypeTextPlain = "text/plain"
+)
+
+// Codec converts request and response bodies of a media type
+type Codec interface {
+	Marshal(in interface{}) ([]byte, error)
+	Unmarshal(data []byte, out interface{}) error
+}
+
+var codecs = map[string]Codec{
+	ContentTypeJson:           &jsonCodec{},
+	ContentTypeXml:            &xmlCodec{},
+	ContentTypeTextXml:        &xmlCodec{},
+	ContentTypeFormUrlEncoded: &formCodec{},
+	ContentTypeMsgPack:        &msgPackCodec{},
+	ContentTypeProtobuf:       &protobufCodec{},
+	ContentTypeXProtobuf:      &protobufCodec{},
+	ContentTypeXMsgPack:       &msgPackCodec{},
+	ContentTypeTextPlain:      &textCodec{},
+}
+var codecsLock = &sync.RWMutex{}
+
+// RegisterCodec registers a codec for a media type e.g. "application/yaml". It replaces the existing codec (if any)
+func RegisterCodec(mediaType string, codec Codec) {
+	codecsLock.Lock()
+	defer codecsLock.Unlock()
+	codecs[strings.ToLower(mediaType)] = codec
+}
+
+// FindCodec gives the codec for a content type. Parameters are ignored (e.g. "application/json; charset=utf-8"), and
+// a structured syntax suffix is used if there is no codec for the full type (e.g. "application/problem+json")
+func FindCodec(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+	if codec, ok := codecs[mediaType]; ok {
+		return codec, true
+	}
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+		switch mediaType[i+1:] {
+		case "json":
+			return codecs[ContentTypeJson], true
+		case "xml":
+			return codecs[ContentTypeXml], true
+		case "msgpack":
+			return codecs[ContentTypeMsgPack], true
+		case "proto", "protobuf":
+			return codecs[ContentTypeProtobuf], true
+		}
+	}
+	return nil, false
+}
+
+// Json codec - strings and bytes are sent as they are (same as serialization.Stringify)
+type jsonCodec struct {
+}
+
+func (j *jsonCodec) Marshal(in interface{}) ([]byte, error) {
+	if message, ok := in.(proto.Message); ok {
+		return protojson.Marshal(message)
+	}
+	out, err := serialization.Stringify(in)
+	return []byte(out), err
+}
+
+func (j *jsonCodec) Unmarshal(data []byte, out interface{}) error {
+	if message, ok := out.(proto.Message); ok {
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, message)
+	}
+	return json.Unmarshal(data, out)
+}
+
+type xmlCodec struct {
+}
+
+func (x *xmlCodec) Marshal(in interface{}) ([]byte, error) {
+	switch v := in.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	}
+	return xml.Marshal(in)
+}
+
+func (x *xmlCodec) Unmarshal(data []byte, out interface{}) error {
+	return xml.Unmarshal(data, out)
+}
+
+type msgPackCodec struct {
+}
+
+func (m *msgPackCodec) Marshal(in interface{}) ([]byte, error) {
+	if v, ok := in.([]byte); ok {
+		return v, nil
+	}
+	return msgpack.Marshal(in)
+}
+
+func (m *msgPackCodec) Unmarshal(data []byte, out interface{}) error {
+	return msgpack.Unmarshal(data, out)
+}
+
+// Form codec - supports url.Values, MultivaluedMap, map[string][]string, map[string]string and map[string]interface{}
+type formCodec struct {
+}
+
+func (f *formCodec) Marshal(in interface{}) ([]byte, error) {
+	values := url.Values{}
+	switch v := in.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	case url.Values:
+		values = v
+	case MultivaluedMap:
+		values = url.Values(v)
+	case map[string][]string:
+		values = v
+	case map[string]string:
+		for name, value := range v {
+			values.Set(name, value)
+		}
+	case map[string]interface{}:
+		for name, value := range v {
+			values.Set(name, serialization.StringifySuppressError(value, ""))
+		}
+	default:
+		return nil, errors.New("form body must be a map or url.Values, got %T", in)
+	}
+	return []byte(values.Encode()), nil
+}
+
+func (f *formCodec) Unmarshal(data []byte, out interface{}) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	switch v := out.(type) {
+	case *url.Values:
+		*v = values
+	case *MultivaluedMap:
+		*v = MultivaluedMap(values)
+	case *map[string][]string:
+		*v = values
+	case *map[string]string:
+		*v = map[string]string{}
+		for name := range values {
+			(*v)[name] = values.Get(name)
+		}
+	default:
+		return errors.New("form response can only be read into *url.Values or a pointer to map, got %T", out)
+	}
+	return nil
+}
+
+type textCodec struct {
+}
+
+func (t *textCodec) Marshal(in interface{}) ([]byte, error) {
+	switch v := in.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	case fmt.Stringer:
+		return []byte(v.String()), nil
+	}
+
+	// Other values are sent as json - same as the body of a request without a codec
+	out, err := serialization.Stringify(in)
+	return []byte(out), err
+}
+
+func (t *textCodec) Unmarshal(data []byte, out interface{}) error {
+	switch v := out.(type) {
+	case *string:
+		*v = string(data)
+	case *[]byte:
+		*v = data
+	default:
+		return errors.New("text response can only be read into *string or *[]byte, got %T", out)
+	}
+	return nil
+}
+
+// ResponseBuilder which decodes the response into "out" using the codec registered for the response content type
+type codecResponseBuilder struct {
+	contentType string
+	out         interface{}
+}
+
+func (c *codecResponseBuilder) Response(data []byte) (interface{}, error) {
+	codec, ok := FindCodec(c.contentType)
+	if !ok {
+		return nil, errors.New("no codec registered for response content type=%s", c.contentType)
+	}
+	if err := codec.Unmarshal(data, c.out); err != nil {
+		return nil, err
+	}
+	return c.out, nil
+}
+
+// NewCodecResponseBuilder gives a ResponseBuilder which decodes the response into "out" using the codec registered for
+// given content type. If content type is empty, protobuf is used for a proto.Message and json for everything else
+func NewCodecResponseBuilder(contentType string, out interface{}) ResponseBuilder {
+	if strings.TrimSpace(contentType) == "" {
+		contentType = DefaultContentType(out)
+	}
+	return &codecResponseBuilder{contentType: contentType, out: out}
+}
+
+// GetResponseBuilder gives the ResponseBuilder to use for a response with given headers - the ResponseBuilder of the
+// request, or a codec based builder if request has a ResponseTarget
+func (req *GoxRequest) GetResponseBuilder(header http.Header) ResponseBuilder {
+	if req.ResponseBuilder != nil || req.ResponseTarget == nil {
+		return req.ResponseBuilder
+	}
+	return NewCodecResponseBuilder(header.Get("Content-Type"), req.ResponseTarget)
+}
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type codecTestObject struct {
+	Name  string `json:"name" xml:"name" msgpack:"name"`
+	Count int    `json:"count" xml:"count" msgpack:"count"`
+}
+
+func TestFindCodec(t *testing.T) {
+	codec, ok := FindCodec("application/json; charset=utf-8")
+	assert.True(t, ok)
+	assert.IsType(t, &jsonCodec{}, codec)
+
+	codec, ok = FindCodec("application/problem+json")
+	assert.True(t, ok)
+	assert.IsType(t, &jsonCodec{}, codec)
+
+	codec, ok = FindCodec("application/atom+xml")
+	assert.True(t, ok)
+	assert.IsType(t, &xmlCodec{}, codec)
+
+	_, ok = FindCodec("application/octet-stream")
+	assert.False(t, ok)
+
+	_, ok = FindCodec("")
+	assert.False(t, ok)
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	for _, contentType := range []string{ContentTypeJson, ContentTypeXml, ContentTypeMsgPack} {
+		codec, ok := FindCodec(contentType)
+		assert.True(t, ok, contentType)
+
+		data, err := codec.Marshal(codecTestObject{Name: "harish", Count: 10})
+		assert.NoError(t, err, contentType)
+
+		out := codecTestObject{}
+		assert.NoError(t, codec.Unmarshal(data, &out), contentType)
+		assert.Equal(t, codecTestObject{Name: "harish", Count: 10}, out, contentType)
+	}
+}
+
+func TestCodec_FormAndText(t *testing.T) {
+	codec, _ := FindCodec(ContentTypeFormUrlEncoded)
+	data, err := codec.Marshal(map[string]interface{}{"name": "a b", "count": 10})
+	assert.NoError(t, err)
+	assert.Equal(t, "count=10&name=a+b", string(data))
+
+	values := url.Values{}
+	assert.NoError(t, codec.Unmarshal([]byte("name=a+b&tag=1&tag=2"), &values))
+	assert.Equal(t, "a b", values.Get("name"))
+	assert.Equal(t, []string{"1", "2"}, values["tag"])
+
+	_, err = codec.Marshal(codecTestObject{})
+	assert.Error(t, err)
+
+	codec, _ = FindCodec("text/plain; charset=utf-8")
+	data, err = codec.Marshal(10)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", string(data))
+
+	out := ""
+	assert.NoError(t, codec.Unmarshal([]byte("hello"), &out))
+	assert.Equal(t, "hello", out)
+}
+
+type upperCaseCodec struct {
+}
+
+func (u *upperCaseCodec) Marshal(in interface{}) ([]byte, error) {
+	return []byte(strings.ToUpper(in.(string))), nil
+}
+
+func (u *upperCaseCodec) Unmarshal(data []byte, out interface{}) error {
+	*(out.(*string)) = strings.ToLower(string(data))
+	return nil
+}
+
+func TestRegisterCodec(t *testing.T) {
+	RegisterCodec("Application/X-Upper", &upperCaseCodec{})
+	out := ""
+	response, err := NewCodecResponseBuilder("application/x-upper; v=1", &out).Response([]byte("HELLO"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", out)
+	assert.Equal(t, &out, response)
+
+	_, err = NewCodecResponseBuilder("application/x-unknown", &out).Response([]byte("HELLO"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package command
+
+import (
+	"github.com/devlibx/gox-base/errors"
+	"strings"
+)
+
+const (
+	CompressionNone    = "none"
+	CompressionGzip    = "gzip"
+	CompressionDeflate = "deflate"
+	CompressionZstd    = "zstd"
+)
+
+// GetRequestCompression gives the encoding to use for request body. Empty means request body is not compressed
+func (a *Api) GetRequestCompression() (string, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(a.RequestCompression)); encoding {
+	case "", CompressionNone:
+		return "", nil
+	case CompressionGzip, CompressionDeflate, CompressionZstd:
+		return encoding, nil
+	default:
+		return "", errors.New("unsupported request_compression=%s for api=%s", a.RequestCompression, a.Name)
+	}
+}
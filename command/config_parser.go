@@ -37,6 +37,8 @@ func (e *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			var _port = serialization.ParameterizedValue(valueMap.StringOrDefault("port", "80"))
 			var _connectTimeout = serialization.ParameterizedValue(valueMap.StringOrDefault("connect_timeout", "50"))
 			var connectionRequestTimeout = serialization.ParameterizedValue(valueMap.StringOrDefault("connection_request_timeout", "50"))
+			var _url = serialization.ParameterizedValue(valueMap.StringOrEmpty("url"))
+			var basePath = serialization.ParameterizedValue(valueMap.StringOrEmpty("base_path"))
 
 			if s.Host, err = _host.GetString(e.Env); err != nil {
 				return errors.Wrap(err, "error is parsing host property for server=%s", name)
@@ -47,12 +49,36 @@ func (e *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			if s.Https, err = _https.GetBool(e.Env); err != nil {
 				return errors.Wrap(err, "error is parsing https property for server=%s", name)
 			}
+			if _, ok := valueMap["port"]; !ok && s.Https {
+				s.Port = 443
+			}
 			if s.ConnectTimeout, err = _connectTimeout.GetInt(e.Env); err != nil {
 				return errors.Wrap(err, "error is parsing connect_timeout property for server=%s", name)
 			}
 			if s.ConnectionRequestTimeout, err = connectionRequestTimeout.GetInt(e.Env); err != nil {
 				return errors.Wrap(err, "error is parsing connection_request_timeout property for server=%s", name)
 			}
+			if s.Url, err = _url.GetString(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing url property for server=%s", name)
+			}
+			if s.BasePath, err = basePath.GetString(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing base_path property for server=%s", name)
+			}
+			if _, err = s.GetBaseUrl(); err != nil {
+				return errors.Wrap(err, "error is parsing url property for server=%s", name)
+			}
+			if s.Auth, err = parseAuth(e.Env, valueMap["auth"]); err != nil {
+				return errors.Wrap(err, "error is parsing auth property for server=%s", name)
+			}
+			if s.Signing, err = parseSigning(e.Env, valueMap["signing"]); err != nil {
+				return errors.Wrap(err, "error is parsing signing property for server=%s", name)
+			}
+			if s.Headers, err = parseHeaders(e.Env, valueMap["headers"]); err != nil {
+				return errors.Wrap(err, "error is parsing headers property for server=%s", name)
+			}
+			if _, err = NewHeaderTemplate(s.Headers); err != nil {
+				return errors.Wrap(err, "error is parsing headers property for server=%s", name)
+			}
 		}
 	}
 
@@ -67,7 +93,12 @@ func (e *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			e.Apis[name] = a
 
 			var valueMap gox.StringObjectMap = values.(map[string]interface{})
-			a.Method = valueMap.StringOrDefault("method", "GET")
+			a.Type = valueMap.StringOrDefault("type", ApiTypeHttp)
+			if a.Type == ApiTypeGraphQL || a.Type == ApiTypeJsonRpc || a.Type == ApiTypeSoap {
+				a.Method = valueMap.StringOrDefault("method", "POST")
+			} else {
+				a.Method = valueMap.StringOrDefault("method", "GET")
+			}
 			var path = serialization.ParameterizedValue(valueMap.StringOrDefault("path", "/"))
 			var server = serialization.ParameterizedValue(valueMap.StringOrEmpty("server"))
 			var timeout = serialization.ParameterizedValue(valueMap.StringOrDefault("timeout", "100"))
@@ -77,6 +108,19 @@ func (e *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			var acceptable_codes = serialization.ParameterizedValue(valueMap.StringOrDefault("acceptable_codes", "200,201"))
 			var retry_count = serialization.ParameterizedValue(valueMap.StringOrDefault("retry_count", "0"))
 			var retry_initial_wait_time_ms = serialization.ParameterizedValue(valueMap.StringOrDefault("retry_initial_wait_time_ms", "1"))
+			var high_priority_concurrency_percent = serialization.ParameterizedValue(valueMap.StringOrDefault("high_priority_concurrency_percent", "0"))
+			var coalesce = serialization.ParameterizedValue(valueMap.StringOrDefault("coalesce", "false"))
+			var coalesce_headers = serialization.ParameterizedValue(valueMap.StringOrEmpty("coalesce_headers"))
+			var request_compression = serialization.ParameterizedValue(valueMap.StringOrEmpty("request_compression"))
+			var request_compression_min_bytes = serialization.ParameterizedValue(valueMap.StringOrDefault("request_compression_min_bytes", "0"))
+			var response_compression = serialization.ParameterizedValue(valueMap.StringOrDefault("response_compression", "false"))
+			var content_type = serialization.ParameterizedValue(valueMap.StringOrEmpty("content_type"))
+			var error_proto = serialization.ParameterizedValue(valueMap.StringOrEmpty("error_proto"))
+			var max_response_bytes = serialization.ParameterizedValue(valueMap.StringOrDefault("max_response_bytes", "0"))
+			var strict_url = serialization.ParameterizedValue(valueMap.StringOrDefault("strict_url", "false"))
+			var allow_url_override = serialization.ParameterizedValue(valueMap.StringOrDefault("allow_url_override", "false"))
+			var body_template = serialization.ParameterizedValue(valueMap.StringOrEmpty("body_template"))
+			var body_template_type = serialization.ParameterizedValue(valueMap.StringOrEmpty("body_template_type"))
 
 			if a.Path, err = path.GetString(e.Env); err != nil {
 				return errors.Wrap(err, "error is parsing path property for api=%s", name)
@@ -105,8 +149,484 @@ func (e *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			if a.InitialRetryWaitTimeMs, err = retry_initial_wait_time_ms.GetInt(e.Env); err != nil {
 				return errors.Wrap(err, "error is parsing retry_initial_wait_time_ms property for api=%s", name)
 			}
+			if a.HighPriorityConcurrencyPercent, err = high_priority_concurrency_percent.GetInt(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing high_priority_concurrency_percent property for api=%s", name)
+			}
+			if a.Coalesce, err = coalesce.GetBool(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing coalesce property for api=%s", name)
+			}
+			if a.CoalesceHeaders, err = coalesce_headers.GetString(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing coalesce_headers property for api=%s", name)
+			}
+			if a.RequestCompression, err = request_compression.GetString(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing request_compression property for api=%s", name)
+			}
+			if a.RequestCompressionMinBytes, err = request_compression_min_bytes.GetInt(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing request_compression_min_bytes property for api=%s", name)
+			}
+			if a.ResponseCompression, err = response_compression.GetBool(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing response_compression property for api=%s", name)
+			}
+			if a.ContentType, err = content_type.GetString(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing content_type property for api=%s", name)
+			}
+			if a.ErrorProto, err = error_proto.GetString(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing error_proto property for api=%s", name)
+			}
+			if a.MaxResponseBytes, err = max_response_bytes.GetInt(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing max_response_bytes property for api=%s", name)
+			}
+			if a.StrictUrl, err = strict_url.GetBool(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing strict_url property for api=%s", name)
+			}
+			if a.AllowUrlOverride, err = allow_url_override.GetBool(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing allow_url_override property for api=%s", name)
+			}
+			if a.BodyTemplate, err = body_template.GetString(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing body_template property for api=%s", name)
+			}
+			if a.BodyTemplateType, err = body_template_type.GetString(e.Env); err != nil {
+				return errors.Wrap(err, "error is parsing body_template_type property for api=%s", name)
+			}
+			if a.Auth, err = parseAuth(e.Env, valueMap["auth"]); err != nil {
+				return errors.Wrap(err, "error is parsing auth property for api=%s", name)
+			}
+			if a.Signing, err = parseSigning(e.Env, valueMap["signing"]); err != nil {
+				return errors.Wrap(err, "error is parsing signing property for api=%s", name)
+			}
+			if a.Headers, err = parseHeaders(e.Env, valueMap["headers"]); err != nil {
+				return errors.Wrap(err, "error is parsing headers property for api=%s", name)
+			}
+			if _, err = NewHeaderTemplate(a.Headers); err != nil {
+				return errors.Wrap(err, "error is parsing headers property for api=%s", name)
+			}
+			if a.Cache, err = parseCache(e.Env, valueMap["cache"]); err != nil {
+				return errors.Wrap(err, "error is parsing cache property for api=%s", name)
+			}
+			if a.Sse, err = parseSse(e.Env, valueMap["sse"]); err != nil {
+				return errors.Wrap(err, "error is parsing sse property for api=%s", name)
+			}
+			if a.Websocket, err = parseWebsocket(e.Env, valueMap["websocket"]); err != nil {
+				return errors.Wrap(err, "error is parsing websocket property for api=%s", name)
+			}
+			if a.GraphQL, err = parseGraphQL(e.Env, valueMap["graphql"]); err != nil {
+				return errors.Wrap(err, "error is parsing graphql property for api=%s", name)
+			}
+			if a.Soap, err = parseSoap(e.Env, valueMap["soap"]); err != nil {
+				return errors.Wrap(err, "error is parsing soap property for api=%s", name)
+			}
+			if a.Pagination, err = parsePagination(e.Env, valueMap["pagination"]); err != nil {
+				return errors.Wrap(err, "error is parsing pagination property for api=%s", name)
+			}
+			if a.Metrics, err = parseMetrics(e.Env, valueMap["metrics"]); err != nil {
+				return errors.Wrap(err, "error is parsing metrics property for api=%s", name)
+			}
+			if _, err = a.GetLatencyBuckets(); err != nil {
+				return err
+			}
+			if _, err = a.GetResponseSizeBuckets(); err != nil {
+				return err
+			}
+			if a.AccessLog, err = parseAccessLog(e.Env, valueMap["access_log"]); err != nil {
+				return errors.Wrap(err, "error is parsing access_log property for api=%s", name)
+			}
+			if a.AccessLog != nil {
+				if err = a.AccessLog.Validate(); err != nil {
+					return errors.Wrap(err, "error is parsing access_log property for api=%s", name)
+				}
+			}
+			if a.QueryParams, err = parseStringMap(e.Env, valueMap["query_params"], "query_params"); err != nil {
+				return errors.Wrap(err, "error is parsing query_params property for api=%s", name)
+			}
+			if _, err = NewRequestTemplate(a); err != nil {
+				return errors.Wrap(err, "error is parsing request templates for api=%s", name)
+			}
 		}
 	}
 
 	return nil
 }
+
+// Parse auth block of server or api - returns nil if auth is not defined
+func parseAuth(env string, data interface{}) (*Auth, error) {
+	if data == nil {
+		return nil, nil
+	}
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected auth to be type of map")
+	}
+
+	var err error
+	var valueMap gox.StringObjectMap = values
+	a := &Auth{}
+	stringFields := map[string]*string{
+		"type":          &a.Type,
+		"username":      &a.Username,
+		"password":      &a.Password,
+		"token":         &a.Token,
+		"in":            &a.In,
+		"name":          &a.Name,
+		"value":         &a.Value,
+		"token_url":     &a.TokenUrl,
+		"client_id":     &a.ClientId,
+		"client_secret": &a.ClientSecret,
+		"scopes":        &a.Scopes,
+	}
+	for key, field := range stringFields {
+		var value = serialization.ParameterizedValue(valueMap.StringOrEmpty(key))
+		if *field, err = value.GetString(env); err != nil {
+			return nil, errors.Wrap(err, "error is parsing auth.%s property", key)
+		}
+	}
+
+	var refreshBeforeExpirySec = serialization.ParameterizedValue(valueMap.StringOrDefault("refresh_before_expiry_sec", "30"))
+	if a.RefreshBeforeExpirySec, err = refreshBeforeExpirySec.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing auth.refresh_before_expiry_sec property")
+	}
+	return a, nil
+}
+
+// Parse signing block of server or api - returns nil if signing is not defined
+func parseSigning(env string, data interface{}) (*Signing, error) {
+	if data == nil {
+		return nil, nil
+	}
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected signing to be type of map")
+	}
+
+	var err error
+	var valueMap gox.StringObjectMap = values
+	s := &Signing{}
+	stringFields := map[string]*string{
+		"type":             &s.Type,
+		"algorithm":        &s.Algorithm,
+		"encoding":         &s.Encoding,
+		"key_id":           &s.KeyId,
+		"secret":           &s.Secret,
+		"signature_header": &s.SignatureHeader,
+		"key_id_header":    &s.KeyIdHeader,
+		"timestamp_header": &s.TimestampHeader,
+		"nonce_header":     &s.NonceHeader,
+		"signed_headers":   &s.SignedHeaders,
+		"canonicalizer":    &s.Canonicalizer,
+	}
+	for key, field := range stringFields {
+		var value = serialization.ParameterizedValue(valueMap.StringOrEmpty(key))
+		if *field, err = value.GetString(env); err != nil {
+			return nil, errors.Wrap(err, "error is parsing signing.%s property", key)
+		}
+	}
+	return s, nil
+}
+
+// Parse headers block of server or api - returns nil if headers are not defined
+func parseHeaders(env string, data interface{}) (map[string]string, error) {
+	return parseStringMap(env, data, "headers")
+}
+
+// Parse a block of string values e.g. headers - returns nil if block is not defined
+func parseStringMap(env string, data interface{}, property string) (map[string]string, error) {
+	if data == nil {
+		return nil, nil
+	}
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected %s to be type of map", property)
+	}
+
+	var err error
+	var valueMap gox.StringObjectMap = values
+	result := map[string]string{}
+	for name := range values {
+		var value = serialization.ParameterizedValue(valueMap.StringOrEmpty(name))
+		if result[name], err = value.GetString(env); err != nil {
+			return nil, errors.Wrap(err, "error is parsing %s.%s property", property, name)
+		}
+	}
+	return result, nil
+}
+
+// Parse cache block of api - returns nil if cache is not defined
+func parseCache(env string, data interface{}) (*Cache, error) {
+	if data == nil {
+		return nil, nil
+	}
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected cache to be type of map")
+	}
+
+	var err error
+	var valueMap gox.StringObjectMap = values
+	c := &Cache{}
+	var enabled = serialization.ParameterizedValue(valueMap.StringOrDefault("enabled", "true"))
+	var maxEntries = serialization.ParameterizedValue(valueMap.StringOrDefault("max_entries", "1000"))
+	if c.Enabled, err = enabled.GetBool(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing cache.enabled property")
+	}
+	if c.MaxEntries, err = maxEntries.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing cache.max_entries property")
+	}
+	return c, nil
+}
+
+// Parse sse block of api - returns nil if sse is not defined
+func parseSse(env string, data interface{}) (*Sse, error) {
+	if data == nil {
+		return nil, nil
+	}
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected sse to be type of map")
+	}
+
+	var err error
+	var valueMap gox.StringObjectMap = values
+	s := &Sse{}
+	var enabled = serialization.ParameterizedValue(valueMap.StringOrDefault("enabled", "true"))
+	var retryMs = serialization.ParameterizedValue(valueMap.StringOrDefault("retry_ms", "3000"))
+	var maxReconnects = serialization.ParameterizedValue(valueMap.StringOrDefault("max_reconnects", "0"))
+	if s.Enabled, err = enabled.GetBool(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing sse.enabled property")
+	}
+	if s.RetryMs, err = retryMs.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing sse.retry_ms property")
+	}
+	if s.MaxReconnects, err = maxReconnects.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing sse.max_reconnects property")
+	}
+	return s, nil
+}
+
+// Parse websocket block of api - returns nil if websocket is not defined
+func parseWebsocket(env string, data interface{}) (*Websocket, error) {
+	if data == nil {
+		return nil, nil
+	}
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected websocket to be type of map")
+	}
+
+	var err error
+	var valueMap gox.StringObjectMap = values
+	w := &Websocket{}
+	var enabled = serialization.ParameterizedValue(valueMap.StringOrDefault("enabled", "true"))
+	var pingIntervalMs = serialization.ParameterizedValue(valueMap.StringOrDefault("ping_interval_ms", "30000"))
+	var pongTimeoutMs = serialization.ParameterizedValue(valueMap.StringOrDefault("pong_timeout_ms", "10000"))
+	var reconnectInitialWaitMs = serialization.ParameterizedValue(valueMap.StringOrDefault("reconnect_initial_wait_ms", "500"))
+	var reconnectMaxWaitMs = serialization.ParameterizedValue(valueMap.StringOrDefault("reconnect_max_wait_ms", "30000"))
+	var maxReconnectAttempts = serialization.ParameterizedValue(valueMap.StringOrDefault("max_reconnect_attempts", "0"))
+	if w.Enabled, err = enabled.GetBool(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing websocket.enabled property")
+	}
+	if w.PingIntervalMs, err = pingIntervalMs.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing websocket.ping_interval_ms property")
+	}
+	if w.PongTimeoutMs, err = pongTimeoutMs.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing websocket.pong_timeout_ms property")
+	}
+	if w.ReconnectInitialWaitMs, err = reconnectInitialWaitMs.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing websocket.reconnect_initial_wait_ms property")
+	}
+	if w.ReconnectMaxWaitMs, err = reconnectMaxWaitMs.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing websocket.reconnect_max_wait_ms property")
+	}
+	if w.MaxReconnectAttempts, err = maxReconnectAttempts.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing websocket.max_reconnect_attempts property")
+	}
+	return w, nil
+}
+
+// Parse graphql block of api - returns nil if graphql is not defined
+func parseGraphQL(env string, data interface{}) (*GraphQL, error) {
+	if data == nil {
+		return nil, nil
+	}
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected graphql to be type of map")
+	}
+
+	var err error
+	var valueMap gox.StringObjectMap = values
+	g := &GraphQL{}
+	var query = serialization.ParameterizedValue(valueMap.StringOrEmpty("query"))
+	var queryFile = serialization.ParameterizedValue(valueMap.StringOrEmpty("query_file"))
+	var operationName = serialization.ParameterizedValue(valueMap.StringOrEmpty("operation_name"))
+	var persistedQuery = serialization.ParameterizedValue(valueMap.StringOrDefault("persisted_query", "false"))
+	if g.Query, err = query.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing graphql.query property")
+	}
+	if g.QueryFile, err = queryFile.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing graphql.query_file property")
+	}
+	if g.OperationName, err = operationName.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing graphql.operation_name property")
+	}
+	if g.PersistedQuery, err = persistedQuery.GetBool(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing graphql.persisted_query property")
+	}
+	return g, nil
+}
+
+// Parse soap block of api - returns nil if soap is not defined
+func parseSoap(env string, data interface{}) (*Soap, error) {
+	if data == nil {
+		return nil, nil
+	}
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected soap to be type of map")
+	}
+
+	var err error
+	var valueMap gox.StringObjectMap = values
+	s := &Soap{}
+	var action = serialization.ParameterizedValue(valueMap.StringOrEmpty("action"))
+	var version = serialization.ParameterizedValue(valueMap.StringOrDefault("version", SoapVersion11))
+	var namespace = serialization.ParameterizedValue(valueMap.StringOrEmpty("namespace"))
+	if s.Action, err = action.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing soap.action property")
+	}
+	if s.Version, err = version.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing soap.version property")
+	}
+	if s.Namespace, err = namespace.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing soap.namespace property")
+	}
+	return s, nil
+}
+
+// Parse pagination block of api - returns nil if pagination is not defined
+func parsePagination(env string, data interface{}) (*Pagination, error) {
+	if data == nil {
+		return nil, nil
+	}
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected pagination to be type of map")
+	}
+
+	var err error
+	var valueMap gox.StringObjectMap = values
+	p := &Pagination{}
+	var paginationType = serialization.ParameterizedValue(valueMap.StringOrEmpty("type"))
+	var limit = serialization.ParameterizedValue(valueMap.StringOrDefault("limit", "0"))
+	var limitParam = serialization.ParameterizedValue(valueMap.StringOrDefault("limit_param", "limit"))
+	var offsetParam = serialization.ParameterizedValue(valueMap.StringOrDefault("offset_param", "offset"))
+	var pageParam = serialization.ParameterizedValue(valueMap.StringOrDefault("page_param", "page"))
+	var startPage = serialization.ParameterizedValue(valueMap.StringOrDefault("start_page", "1"))
+	var cursorParam = serialization.ParameterizedValue(valueMap.StringOrDefault("cursor_param", "cursor"))
+	var cursorPath = serialization.ParameterizedValue(valueMap.StringOrEmpty("cursor_path"))
+	var itemsPath = serialization.ParameterizedValue(valueMap.StringOrEmpty("items_path"))
+	var maxPages = serialization.ParameterizedValue(valueMap.StringOrDefault("max_pages", "0"))
+	if p.Type, err = paginationType.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing pagination.type property")
+	}
+	if p.Limit, err = limit.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing pagination.limit property")
+	}
+	if p.LimitParam, err = limitParam.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing pagination.limit_param property")
+	}
+	if p.OffsetParam, err = offsetParam.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing pagination.offset_param property")
+	}
+	if p.PageParam, err = pageParam.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing pagination.page_param property")
+	}
+	if p.StartPage, err = startPage.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing pagination.start_page property")
+	}
+	if p.CursorParam, err = cursorParam.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing pagination.cursor_param property")
+	}
+	if p.CursorPath, err = cursorPath.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing pagination.cursor_path property")
+	}
+	if p.ItemsPath, err = itemsPath.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing pagination.items_path property")
+	}
+	if p.MaxPages, err = maxPages.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing pagination.max_pages property")
+	}
+	return p, nil
+}
+
+// Parse metrics block of api - returns nil if metrics is not defined
+func parseMetrics(env string, data interface{}) (*Metrics, error) {
+	if data == nil {
+		return nil, nil
+	}
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected metrics to be type of map")
+	}
+
+	var err error
+	var valueMap gox.StringObjectMap = values
+	m := &Metrics{}
+	var latencyBucketsMs = serialization.ParameterizedValue(valueMap.StringOrEmpty("latency_buckets_ms"))
+	var responseSizeBuckets = serialization.ParameterizedValue(valueMap.StringOrEmpty("response_size_buckets"))
+	if m.LatencyBucketsMs, err = latencyBucketsMs.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing metrics.latency_buckets_ms property")
+	}
+	if m.ResponseSizeBuckets, err = responseSizeBuckets.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing metrics.response_size_buckets property")
+	}
+	return m, nil
+}
+
+// Parse access_log block of api - returns nil if access_log is not defined
+func parseAccessLog(env string, data interface{}) (*AccessLog, error) {
+	if data == nil {
+		return nil, nil
+	}
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected access_log to be type of map")
+	}
+
+	var err error
+	var valueMap gox.StringObjectMap = values
+	l := &AccessLog{}
+	var enabled = serialization.ParameterizedValue(valueMap.StringOrDefault("enabled", "true"))
+	var level = serialization.ParameterizedValue(valueMap.StringOrEmpty("level"))
+	var errorLevel = serialization.ParameterizedValue(valueMap.StringOrEmpty("error_level"))
+	var maxBodyBytes = serialization.ParameterizedValue(valueMap.StringOrDefault("max_body_bytes", "0"))
+	if l.Enabled, err = enabled.GetBool(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing access_log.enabled property")
+	}
+	if l.Level, err = level.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing access_log.level property")
+	}
+	if l.ErrorLevel, err = errorLevel.GetString(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing access_log.error_level property")
+	}
+
+	// Sample rates are left nil if not set - default is given by AccessLog.GetSampleRates()
+	if _, ok := valueMap["success_sample_rate"]; ok {
+		var successSampleRate = serialization.ParameterizedValue(valueMap.StringOrEmpty("success_sample_rate"))
+		rate, err := successSampleRate.GetFloat(env)
+		if err != nil {
+			return nil, errors.Wrap(err, "error is parsing access_log.success_sample_rate property")
+		}
+		l.SuccessSampleRate = &rate
+	}
+	if _, ok := valueMap["error_sample_rate"]; ok {
+		var errorSampleRate = serialization.ParameterizedValue(valueMap.StringOrEmpty("error_sample_rate"))
+		rate, err := errorSampleRate.GetFloat(env)
+		if err != nil {
+			return nil, errors.Wrap(err, "error is parsing access_log.error_sample_rate property")
+		}
+		l.ErrorSampleRate = &rate
+	}
+	if l.MaxBodyBytes, err = maxBodyBytes.GetInt(env); err != nil {
+		return nil, errors.Wrap(err, "error is parsing access_log.max_body_bytes property")
+	}
+	return l, nil
+}
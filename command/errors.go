@@ -8,6 +8,14 @@ import (
 
 const ErrorCodeFailedToBuildRequest = "failed_to_build_request"
 const ErrorCodeFailedToRequestServer = "failed_to_request_server"
+const ErrorCodeRequestShed = "request_shed"
+const ErrorCodeFailedToFetchAuthToken = "failed_to_fetch_auth_token"
+const ErrorCodeResponseTooLarge = "response_too_large"
+const ErrorCodeGraphQLError = "graphql_error"
+const ErrorCodeGraphQLPartialError = "graphql_partial_error"
+const ErrorCodeJsonRpcError = "jsonrpc_error"
+const ErrorCodeSoapFault = "soap_fault"
+const ErrorCodeBatchCancelled = "batch_cancelled"
 
 // Gox Http Module error
 // Err 			- underlying error thrown by http or lib
@@ -17,12 +25,15 @@ const ErrorCodeFailedToRequestServer = "failed_to_request_server"
 // Body			- data from http response
 //
 //	This may be nil if we got local errors e.g. hystrix timeout, or some other errors
+//
+// DecodedBody	- Body decoded into "error_proto" message of the api (if it is configured)
 type GoxHttpError struct {
-	Err        error
-	StatusCode int
-	Message    string
-	ErrorCode  string
-	Body       []byte
+	Err         error
+	StatusCode  int
+	Message     string
+	ErrorCode   string
+	Body        []byte
+	DecodedBody interface{}
 }
 
 // Build string representation
@@ -129,3 +140,34 @@ func (e *GoxHttpError) IsHystrixRejectedError() bool {
 func (e *GoxHttpError) IsHystrixError() bool {
 	return e.IsHystrixTimeoutError() || e.IsHystrixCircuitOpenError() || e.IsHystrixRejectedError()
 }
+
+// Indicates that this request was dropped to make room for higher priority requests (or because the queue was full)
+func (e *GoxHttpError) IsRequestShedError() bool {
+	return e.ErrorCode == ErrorCodeRequestShed
+}
+
+// Indicates that the response body was larger than "max_response_bytes" of this API
+func (e *GoxHttpError) IsResponseTooLargeError() bool {
+	return e.ErrorCode == ErrorCodeResponseTooLarge
+}
+
+// Indicates that a GraphQL server returned "errors" - use GraphQLErrors() to get them. For a partial error the response
+// also has the "data" which server returned
+func (e *GoxHttpError) IsGraphQLError() bool {
+	return e.ErrorCode == ErrorCodeGraphQLError || e.ErrorCode == ErrorCodeGraphQLPartialError
+}
+
+// Indicates that a JSON-RPC server returned an "error" object - use JsonRpcError() to get its code, message and data
+func (e *GoxHttpError) IsJsonRpcError() bool {
+	return e.ErrorCode == ErrorCodeJsonRpcError
+}
+
+// Indicates that a SOAP server returned a Fault - use SoapFault() to get its code, string, actor and detail
+func (e *GoxHttpError) IsSoapFault() bool {
+	return e.ErrorCode == ErrorCodeSoapFault
+}
+
+// Indicates that a call of ExecuteAll was cancelled (or not started) because the batch failed fast or timed out
+func (e *GoxHttpError) IsBatchCancelledError() bool {
+	return e.ErrorCode == ErrorCodeBatchCancelled
+}
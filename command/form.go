@@ -0,0 +1,105 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/devlibx/gox-base/errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	ContentTypeFormUrlEncoded = "application/x-www-form-urlencoded"
+	ContentTypeOctetStream    = "application/octet-stream"
+)
+
+// MultipartFile is a file part of a multipart/form-data request. Content is read from Path, or from Reader if Path is
+// not set. A Reader is read only once and kept in memory, so the same content is sent again on retry
+type MultipartFile struct {
+	Field       string
+	FileName    string
+	ContentType string
+	Path        string
+	Reader      io.Reader
+
+	once    sync.Once
+	content []byte
+	err     error
+}
+
+// Content gives the content of this file part
+func (f *MultipartFile) Content() ([]byte, error) {
+	if f.Path != "" {
+		return ioutil.ReadFile(f.Path)
+	}
+	if f.Reader == nil {
+		return nil, errors.New("file part must have a path or a reader: field=%s", f.Field)
+	}
+	f.once.Do(func() {
+		f.content, f.err = ioutil.ReadAll(f.Reader)
+	})
+	return f.content, f.err
+}
+
+// HasForm returns true if this request has form fields or files i.e. its body is a form
+func (req *GoxRequest) HasForm() bool {
+	return len(req.FormData) > 0 || len(req.Files) > 0
+}
+
+// BuildFormBody creates the body of a form request - multipart/form-data if request has files, otherwise
+// application/x-www-form-urlencoded. Returns the body and the content type to use
+func (req *GoxRequest) BuildFormBody() ([]byte, string, error) {
+	if len(req.Files) == 0 {
+		return []byte(url.Values(req.FormData).Encode()), ContentTypeFormUrlEncoded, nil
+	}
+
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+	for name, values := range req.FormData {
+		for _, value := range values {
+			if err := writer.WriteField(name, value); err != nil {
+				return nil, "", errors.Wrap(err, "failed to write form field: name=%s", name)
+			}
+		}
+	}
+
+	for _, file := range req.Files {
+		content, err := file.Content()
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to read file part: field=%s", file.Field)
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(file.Field), quoteEscaper.Replace(file.FileName)))
+		header.Set("Content-Type", file.ContentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to create file part: field=%s", file.Field)
+		}
+		if _, err = part.Write(content); err != nil {
+			return nil, "", errors.Wrap(err, "failed to write file part: field=%s", file.Field)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", errors.Wrap(err, "failed to create multipart body")
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// Content type of a file from its extension, or application/octet-stream if it is not known
+func contentTypeFromFileName(fileName string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(fileName)); contentType != "" {
+		return contentType
+	}
+	return ContentTypeOctetStream
+}
@@ -0,0 +1,28 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMultipartFile_ContentIsReadOnceFromReader(t *testing.T) {
+	file := &MultipartFile{Field: "image", Reader: strings.NewReader("png-bytes")}
+
+	// Concurrent calls (e.g. retry of a request which is also sent by another goroutine) read the reader only once
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			content, err := file.Content()
+			assert.NoError(t, err)
+			assert.Equal(t, "png-bytes", string(content))
+		}()
+	}
+	wg.Wait()
+
+	_, err := (&MultipartFile{Field: "image"}).Content()
+	assert.Error(t, err)
+}
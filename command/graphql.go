@@ -0,0 +1,150 @@
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/devlibx/gox-base/errors"
+	"io/ioutil"
+	"strings"
+)
+
+// Error (in "extensions.code" or message) returned by server when it does not know a persisted query hash
+const graphQLPersistedQueryNotFound = "PERSISTED_QUERY_NOT_FOUND"
+
+// GraphQL config of an api with "type: graphql"
+// ****************************************************************************************
+// IMP NOTE - "config_parser.go -> UnmarshalYAML() method is created to do custom parsing.
+// If you change anything here (add/update/delete) you must make changes in UnmarshalYAML()
+// ****************************************************************************************
+type GraphQL struct {
+
+	// Query document to send - or a file to read it from. Query given in request overrides it
+	Query     string `yaml:"query"`
+	QueryFile string `yaml:"query_file"`
+
+	OperationName string `yaml:"operation_name"`
+
+	// Send only the sha256 hash of the query (automatic persisted queries). Query is sent with the hash if server
+	// does not know the hash
+	PersistedQuery bool `yaml:"persisted_query"`
+}
+
+// GraphQLRequest has the query, variables and operation name of a GraphQL call
+type GraphQLRequest struct {
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+}
+
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLError is a single error from the "errors" array of a GraphQL response
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLErrorLocation `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Code gives "extensions.code" of this error (empty if it is not set)
+func (e *GraphQLError) Code() string {
+	if code, ok := e.Extensions["code"].(string); ok {
+		return code
+	}
+	return ""
+}
+
+type GraphQLErrors []*GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Message)
+	}
+	return "graphql errors: " + strings.Join(messages, "; ")
+}
+
+// IsPersistedQueryNotFound returns true if server asked for the full query of a persisted query hash
+func (e GraphQLErrors) IsPersistedQueryNotFound() bool {
+	for _, err := range e {
+		if err.Code() == graphQLPersistedQueryNotFound || err.Message == "PersistedQueryNotFound" {
+			return true
+		}
+	}
+	return false
+}
+
+// GraphQLResponse is the envelope of a GraphQL response
+type GraphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// HasData returns true if response has a non-null "data"
+func (r *GraphQLResponse) HasData() bool {
+	return len(r.Data) > 0 && string(r.Data) != "null"
+}
+
+// IsGraphQL returns true if this is a "type: graphql" api
+func (a *Api) IsGraphQL() bool {
+	return a.Type == ApiTypeGraphQL
+}
+
+// GetGraphQLQuery gives the query document configured for this api (reads "query_file" if query is not given)
+func (a *Api) GetGraphQLQuery() (string, error) {
+	if !a.IsGraphQL() || a.GraphQL == nil {
+		return "", nil
+	}
+	if a.GraphQL.Query != "" || a.GraphQL.QueryFile == "" {
+		return a.GraphQL.Query, nil
+	}
+	query, err := ioutil.ReadFile(a.GraphQL.QueryFile)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read graphql query_file: api=%s, file=%s", a.Name, a.GraphQL.QueryFile)
+	}
+	return string(query), nil
+}
+
+// BuildGraphQLBody builds the json body of a GraphQL call. If persistedQuery is true, sha256 hash of the query is sent
+// in "extensions.persistedQuery", and the query itself is sent only if includeQuery is true
+func BuildGraphQLBody(request *GraphQLRequest, persistedQuery bool, includeQuery bool) ([]byte, error) {
+	if request.Query == "" {
+		return nil, errors.New("graphql query is not given in request or api config")
+	}
+	body := map[string]interface{}{}
+	if !persistedQuery || includeQuery {
+		body["query"] = request.Query
+	}
+	if request.OperationName != "" {
+		body["operationName"] = request.OperationName
+	}
+	if len(request.Variables) > 0 {
+		body["variables"] = request.Variables
+	}
+	if persistedQuery {
+		hash := sha256.Sum256([]byte(request.Query))
+		body["extensions"] = map[string]interface{}{
+			"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hex.EncodeToString(hash[:])},
+		}
+	}
+	return json.Marshal(body)
+}
+
+// DecodeGraphQLResponse reads the "data" and "errors" of a GraphQL response
+func DecodeGraphQLResponse(body []byte) (*GraphQLResponse, error) {
+	response := &GraphQLResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, errors.Wrap(err, "failed to decode graphql response")
+	}
+	return response, nil
+}
+
+// GraphQLErrors gives the errors returned by a GraphQL server (nil if this is not a GraphQL error)
+func (e *GoxHttpError) GraphQLErrors() GraphQLErrors {
+	errs, _ := e.DecodedBody.(GraphQLErrors)
+	return errs
+}
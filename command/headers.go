@@ -0,0 +1,117 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base/errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// ContextValueFunc extracts a value from context - used in header templates e.g. {{ .Ctx "tenant_id" }}
+type ContextValueFunc func(ctx context.Context) (string, bool)
+
+var contextValues = map[string]ContextValueFunc{}
+var contextValuesLock = &sync.RWMutex{}
+
+// RegisterContextValue registers a function to read a value from context, which can be used by name in header templates
+func RegisterContextValue(name string, f ContextValueFunc) {
+	contextValuesLock.Lock()
+	defer contextValuesLock.Unlock()
+	contextValues[name] = f
+}
+
+// Data available to header templates
+type headerTemplateData struct {
+	ctx context.Context
+}
+
+// Ctx returns a value registered with RegisterContextValue(). If it is not registered then ctx.Value(name) is used
+func (d *headerTemplateData) Ctx(name string) string {
+	contextValuesLock.RLock()
+	f, ok := contextValues[name]
+	contextValuesLock.RUnlock()
+	if ok {
+		if value, found := f(d.ctx); found {
+			return value
+		}
+		return ""
+	}
+
+	switch v := d.ctx.Value(name).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// Env returns the os environment variable
+func (d *headerTemplateData) Env(name string) string {
+	return os.Getenv(name)
+}
+
+// HeaderTemplate holds default headers of a server/api. Values with "{{" are go templates which are evaluated for
+// every request e.g. "my-app/{{ .Env "APP_VERSION" }}" or "{{ .Ctx "tenant_id" }}"
+type HeaderTemplate struct {
+	static    http.Header
+	templates map[string]*template.Template
+}
+
+// NewHeaderTemplate parses the given headers. Returns nil if there are no headers
+func NewHeaderTemplate(headers map[string]string) (*HeaderTemplate, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	h := &HeaderTemplate{static: http.Header{}, templates: map[string]*template.Template{}}
+	for name, value := range headers {
+		if !strings.Contains(value, "{{") {
+			h.static.Set(name, value)
+			continue
+		}
+		t, err := template.New(name).Option("missingkey=zero").Parse(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse header template: header=%s", name)
+		}
+		h.templates[http.CanonicalHeaderKey(name)] = t
+	}
+	return h, nil
+}
+
+// Build returns headers for a request. A templated header which evaluates to empty string is not added
+func (h *HeaderTemplate) Build(ctx context.Context) (http.Header, error) {
+	headers := h.static.Clone()
+	data := &headerTemplateData{ctx: ctx}
+	for name, t := range h.templates {
+		sb := strings.Builder{}
+		if err := t.Execute(&sb, data); err != nil {
+			return nil, errors.Wrap(err, "failed to build header from template: header=%s", name)
+		}
+		if value := sb.String(); value != "" {
+			headers.Set(name, value)
+		}
+	}
+	return headers, nil
+}
+
+// GetHeaders returns default headers of this api - api headers override server headers with same name
+func (a *Api) GetHeaders(server *Server) map[string]string {
+	headers := map[string]string{}
+	if server != nil {
+		for name, value := range server.Headers {
+			headers[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	for name, value := range a.Headers {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+	return headers
+}
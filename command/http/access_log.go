@@ -0,0 +1,108 @@
+package httpCommand
+
+import (
+	"context"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-http/command"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// accessLogger logs one entry for every call of an api (only if access_log is enabled for the api)
+type accessLogger struct {
+	logger            *zap.Logger
+	server            *command.Server
+	api               *command.Api
+	level             zapcore.Level
+	errorLevel        zapcore.Level
+	successSampleRate float64
+	errorSampleRate   float64
+	maxBodyBytes      int
+}
+
+// Returns nil if access log is not enabled for the api
+func newAccessLogger(cf gox.CrossFunction, server *command.Server, api *command.Api) (*accessLogger, error) {
+	config := api.AccessLog
+	if config == nil || !config.Enabled {
+		return nil, nil
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	level, errorLevel, _ := config.GetLevels()
+	successSampleRate, errorSampleRate := config.GetSampleRates()
+	return &accessLogger{
+		logger:            cf.Logger().Named("goxHttp").Named("access"),
+		server:            server,
+		api:               api,
+		level:             level,
+		errorLevel:        errorLevel,
+		successSampleRate: successSampleRate,
+		errorSampleRate:   errorSampleRate,
+		maxBodyBytes:      config.MaxBodyBytes,
+	}, nil
+}
+
+// Called when a request starts - returns the func to call when request is done
+func (l *accessLogger) requestStarted(ctx context.Context, request *command.GoxRequest, stats *callStats) func(response *command.GoxResponse, err error) {
+	if l == nil {
+		return func(response *command.GoxResponse, err error) {}
+	}
+
+	start := time.Now()
+	stats.maxBodyBytes = l.maxBodyBytes
+	return func(response *command.GoxResponse, err error) {
+		duration := time.Since(start)
+
+		level, sampleRate := l.level, l.successSampleRate
+		if err != nil {
+			level, sampleRate = l.errorLevel, l.errorSampleRate
+		}
+		if sampleRate < 1 && rand.Float64() >= sampleRate {
+			return
+		}
+		entry := l.logger.Check(level, "http call")
+		if entry == nil {
+			return
+		}
+
+		// Url with {name} path params as it is, so that calls of an api have the same url
+		urlTemplate, _ := l.api.GetRequestUrlWithContext(ctx, l.server, request)
+
+		statusCode, errorCode := requestOutcome(response, err)
+		fields := []zap.Field{
+			zap.String("api", l.api.Name),
+			zap.String("server", l.server.Name),
+			zap.String("method", strings.ToUpper(l.api.Method)),
+			zap.String("url", urlTemplate),
+			zap.Int("status", statusCode),
+			zap.Duration("duration", duration),
+			zap.Int("attempts", stats.attemptCount()),
+			zap.Int64("request_bytes", stats.requestBytes),
+			zap.String("error_code", errorCode),
+		}
+		if response != nil && response.BodyStream == nil {
+			fields = append(fields, zap.Int("response_bytes", len(response.Body)))
+		}
+		if response != nil && response.CacheStatus != "" {
+			fields = append(fields, zap.String("cache_status", string(response.CacheStatus)))
+		}
+		if l.maxBodyBytes > 0 {
+			fields = append(fields, zap.ByteString("request_body", stats.requestBody))
+			if response != nil && response.BodyStream == nil {
+				fields = append(fields, zap.ByteString("response_body", truncateBody(response.Body, l.maxBodyBytes)))
+			}
+		}
+		entry.Write(fields...)
+	}
+}
+
+func truncateBody(body []byte, maxBytes int) []byte {
+	if len(body) > maxBytes {
+		return body[:maxBytes]
+	}
+	return body
+}
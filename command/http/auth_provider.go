@@ -0,0 +1,210 @@
+package httpCommand
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-http/command"
+	"github.com/go-resty/resty/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// authProvider adds credentials to an outgoing request
+type authProvider interface {
+
+	// Add auth to headers/query params of a request. Headers/query params which are already set are not overwritten
+	apply(ctx context.Context, header http.Header, query url.Values) error
+
+	// Called with headers of the request which got 401 from server. Returns true if credentials are refreshed and
+	// request should be retried
+	invalidate(header http.Header) bool
+}
+
+// Used for basic, bearer and api key auth
+type staticAuthProvider struct {
+	in    string
+	name  string
+	value string
+}
+
+func (s *staticAuthProvider) apply(ctx context.Context, header http.Header, query url.Values) error {
+	if s.in == command.AuthInQuery {
+		if _, ok := query[s.name]; !ok {
+			query.Set(s.name, s.value)
+		}
+	} else if header.Get(s.name) == "" {
+		header.Set(s.name, s.value)
+	}
+	return nil
+}
+
+func (s *staticAuthProvider) invalidate(header http.Header) bool {
+	return false
+}
+
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Timeout of token url call if api has no timeout
+const defaultAuthTokenTimeout = 10 * time.Second
+
+// oauth2ClientCredentialsProvider fetches a token using client credentials grant and caches it till it is about to
+// expire. Only one call to token url is made at a time - token url is not called while holding the lock
+type oauth2ClientCredentialsProvider struct {
+	auth          *command.Auth
+	client        *resty.Client
+	lock          *sync.Mutex
+	token         string
+	expiry        time.Time
+	refreshBefore time.Duration
+
+	// Not nil while a token is being fetched - it is closed when fetch is done
+	fetching chan struct{}
+}
+
+func (o *oauth2ClientCredentialsProvider) apply(ctx context.Context, header http.Header, query url.Values) error {
+	if header.Get("Authorization") != "" {
+		return nil
+	}
+	token, err := o.getToken(ctx)
+	if err != nil {
+		return err
+	}
+	header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Token is dropped only if it is the token which got 401 - it may be already refreshed by another request
+func (o *oauth2ClientCredentialsProvider) invalidate(header http.Header) bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.token != "" && header.Get("Authorization") == "Bearer "+o.token {
+		o.token = ""
+	}
+	return true
+}
+
+func (o *oauth2ClientCredentialsProvider) getToken(ctx context.Context) (string, error) {
+	for {
+		o.lock.Lock()
+
+		// Use cached token if it is not close to expiry
+		if o.token != "" && (o.expiry.IsZero() || time.Now().Add(o.refreshBefore).Before(o.expiry)) {
+			token := o.token
+			o.lock.Unlock()
+			return token, nil
+		}
+
+		// Wait for the token which is being fetched by another request
+		if fetching := o.fetching; fetching != nil {
+			o.lock.Unlock()
+			select {
+			case <-fetching:
+				continue
+			case <-ctx.Done():
+				return "", newAuthError(http.StatusRequestTimeout, errors.Wrap(ctx.Err(), "context is done while waiting for token"), nil)
+			}
+		}
+		fetching := make(chan struct{})
+		o.fetching = fetching
+		o.lock.Unlock()
+
+		token, expiry, err := o.fetchToken(ctx)
+
+		o.lock.Lock()
+		if err == nil {
+			o.token, o.expiry = token, expiry
+		}
+		o.fetching = nil
+		o.lock.Unlock()
+		close(fetching)
+		return token, err
+	}
+}
+
+// Call token url - gives the token and its expiry (zero if server did not give expires_in)
+func (o *oauth2ClientCredentialsProvider) fetchToken(ctx context.Context) (string, time.Time, error) {
+	form := map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     o.auth.ClientId,
+		"client_secret": o.auth.ClientSecret,
+	}
+	if o.auth.Scopes != "" {
+		form["scope"] = strings.Join(strings.Split(strings.ReplaceAll(o.auth.Scopes, " ", ""), ","), " ")
+	}
+
+	response, err := o.client.R().SetContext(ctx).SetFormData(form).Post(o.auth.TokenUrl)
+	if err != nil {
+		return "", time.Time{}, newAuthError(http.StatusUnauthorized, errors.Wrap(err, "failed to call token url"), nil)
+	}
+	if response.IsError() {
+		return "", time.Time{}, newAuthError(response.StatusCode(), errors.New("token url returned status=%d", response.StatusCode()), response.Body())
+	}
+
+	token := oauth2Token{}
+	if err := serialization.JsonBytesToObject(response.Body(), &token); err != nil || token.AccessToken == "" {
+		return "", time.Time{}, newAuthError(http.StatusUnauthorized, errors.New("token url did not return access_token"), response.Body())
+	}
+
+	expiry := time.Time{}
+	if token.ExpiresIn > 0 {
+		expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token.AccessToken, expiry, nil
+}
+
+func newAuthError(statusCode int, err error, body []byte) error {
+	return &command.GoxHttpError{
+		Err:        err,
+		StatusCode: statusCode,
+		Message:    "failed to fetch auth token",
+		ErrorCode:  command.ErrorCodeFailedToFetchAuthToken,
+		Body:       body,
+	}
+}
+
+// Returns nil if no auth is configured for this api
+func newAuthProvider(server *command.Server, api *command.Api) (authProvider, error) {
+	auth := api.GetAuth(server)
+	if auth == nil {
+		return nil, nil
+	}
+
+	auth, err := auth.Resolved()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve auth secrets for api=%s", api.Name)
+	}
+
+	switch auth.Type {
+	case command.AuthTypeBasic:
+		value := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+		return &staticAuthProvider{in: command.AuthInHeader, name: "Authorization", value: "Basic " + value}, nil
+	case command.AuthTypeBearer:
+		return &staticAuthProvider{in: command.AuthInHeader, name: "Authorization", value: "Bearer " + auth.Token}, nil
+	case command.AuthTypeApiKey:
+		return &staticAuthProvider{in: auth.In, name: auth.Name, value: auth.Value}, nil
+	case command.AuthTypeOAuth2ClientCredentials:
+		if auth.TokenUrl == "" {
+			return nil, errors.New("token_url is required for oauth2 auth: api=%s", api.Name)
+		}
+		timeout := defaultAuthTokenTimeout
+		if api.Timeout > 0 {
+			timeout = time.Duration(api.Timeout) * time.Millisecond
+		}
+		return &oauth2ClientCredentialsProvider{
+			auth:          auth,
+			client:        resty.New().SetTimeout(timeout),
+			lock:          &sync.Mutex{},
+			refreshBefore: time.Duration(auth.RefreshBeforeExpirySec) * time.Second,
+		}, nil
+	}
+	return nil, errors.New("unknown auth type: type=%s api=%s", auth.Type, api.Name)
+}
@@ -0,0 +1,330 @@
+package httpCommand
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/devlibx/gox-http/command"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Internal header used to pass cache status from transport to the command. It is removed before response is returned
+const cacheStatusHeader = "X-Gox-Http-Cache-Status"
+
+// How long a background revalidation (stale-while-revalidate) can take
+const backgroundRevalidationTimeout = 10 * time.Second
+
+// cachingTransport is a http.RoundTripper which caches GET responses following Cache-Control/Expires headers
+type cachingTransport struct {
+	base         http.RoundTripper
+	store        command.CacheStore
+	logger       *zap.Logger
+	revalidating *sync.Map
+
+	// Headers which identify the caller - their values are part of the cache key, so that a response fetched
+	// with one caller's credentials is never given to another caller
+	credentialHeaders []string
+}
+
+func (c *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestCacheControl := parseCacheControl(req.Header.Get("Cache-Control"))
+	if req.Method != http.MethodGet || requestCacheControl.has("no-store") {
+		return c.base.RoundTrip(req)
+	}
+
+	key := cacheKey(req, c.credentialHeaders)
+	entry, found := c.store.Get(key)
+	if found && !entry.MatchesVary(req.Header) {
+		found = false
+	}
+
+	now := time.Now()
+	if found && !requestCacheControl.has("no-cache") {
+
+		// Fresh response - no need to call server
+		if now.Before(entry.FreshUntil) {
+			return entryToResponse(req, entry, command.CacheStatusHit), nil
+		}
+
+		// Stale but we are allowed to use it - revalidate in background
+		if canServeStale(entry, requestCacheControl) && now.Before(entry.FreshUntil.Add(entry.StaleWhileRevalidate)) {
+			c.revalidateInBackground(req, key, entry)
+			return entryToResponse(req, entry, command.CacheStatusStale), nil
+		}
+	}
+
+	// Call server - with conditional headers if we have a cached response
+	outgoing := req
+	if found {
+		outgoing = withConditionalHeaders(req, entry)
+	}
+	response, err := c.base.RoundTrip(outgoing)
+
+	// Use stale response if server is not working
+	useStaleOnError := found && canServeStale(entry, requestCacheControl) && now.Before(entry.FreshUntil.Add(entry.StaleIfError))
+	if err != nil {
+		if useStaleOnError {
+			return entryToResponse(req, entry, command.CacheStatusStale), nil
+		}
+		return nil, err
+	}
+	if response.StatusCode >= http.StatusInternalServerError && useStaleOnError {
+		drainAndClose(response)
+		return entryToResponse(req, entry, command.CacheStatusStale), nil
+	}
+
+	return c.handleResponse(req, key, entry, found, response)
+}
+
+// Store (or refresh) the cached response based on the response from server
+func (c *cachingTransport) handleResponse(req *http.Request, key string, entry *command.CacheEntry, found bool, response *http.Response) (*http.Response, error) {
+
+	// Cached response is still valid - update its headers and freshness
+	if response.StatusCode == http.StatusNotModified && found {
+		drainAndClose(response)
+		updated := *entry
+		updated.Header = entry.Header.Clone()
+		for name, values := range response.Header {
+			updated.Header[name] = values
+		}
+		setFreshness(&updated, updated.Header, time.Now())
+		c.store.Set(key, &updated)
+		return entryToResponse(req, &updated, command.CacheStatusRevalidated), nil
+	}
+
+	newEntry, cacheable := newCacheEntry(req, response)
+	if !cacheable {
+		if found {
+			c.store.Delete(key)
+		}
+		response.Header.Set(cacheStatusHeader, string(command.CacheStatusMiss))
+		return response, nil
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	newEntry.Body = body
+	c.store.Set(key, newEntry)
+
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	response.Header.Set(cacheStatusHeader, string(command.CacheStatusMiss))
+	return response, nil
+}
+
+// Revalidate a stale response in background. Only one revalidation per key runs at a time
+func (c *cachingTransport) revalidateInBackground(req *http.Request, key string, entry *command.CacheEntry) {
+	if _, running := c.revalidating.LoadOrStore(key, true); running {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backgroundRevalidationTimeout)
+	outgoing := withConditionalHeaders(req.Clone(ctx), entry)
+	go func() {
+		defer cancel()
+		defer c.revalidating.Delete(key)
+		response, err := c.base.RoundTrip(outgoing)
+		if err != nil {
+			c.logger.Debug("background revalidation failed", zap.String("url", req.URL.String()), zap.Error(err))
+			return
+		}
+		if response.StatusCode >= http.StatusInternalServerError {
+			drainAndClose(response)
+			return
+		}
+		if response, err = c.handleResponse(outgoing, key, entry, true, response); err == nil {
+			drainAndClose(response)
+		}
+	}()
+}
+
+func newCacheEntry(req *http.Request, response *http.Response) (*command.CacheEntry, bool) {
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNonAuthoritativeInfo {
+		return nil, false
+	}
+	cacheControl := parseCacheControl(response.Header.Get("Cache-Control"))
+	if cacheControl.has("no-store") || response.Header.Get("Vary") == "*" {
+		return nil, false
+	}
+
+	entry := &command.CacheEntry{
+		StatusCode: response.StatusCode,
+		Header:     response.Header.Clone(),
+		VaryHeader: http.Header{},
+	}
+	for _, name := range varyHeaders(response.Header) {
+		entry.VaryHeader[name] = req.Header.Values(name)
+	}
+
+	// Only cache if we know how long it is fresh, or if we can revalidate it
+	hasFreshness := setFreshness(entry, response.Header, time.Now())
+	hasValidator := response.Header.Get("ETag") != "" || response.Header.Get("Last-Modified") != ""
+	return entry, hasFreshness || hasValidator
+}
+
+// Compute freshness from Cache-Control/Expires. Returns false if response has no freshness information
+func setFreshness(entry *command.CacheEntry, header http.Header, now time.Time) bool {
+	cacheControl := parseCacheControl(header.Get("Cache-Control"))
+	entry.StaleWhileRevalidate = cacheControl.seconds("stale-while-revalidate")
+	entry.StaleIfError = cacheControl.seconds("stale-if-error")
+
+	if cacheControl.has("no-cache") {
+		entry.FreshUntil = time.Time{}
+		return true
+	}
+
+	age := time.Duration(0)
+	if v, err := strconv.Atoi(header.Get("Age")); err == nil && v > 0 {
+		age = time.Duration(v) * time.Second
+	}
+
+	if cacheControl.has("max-age") {
+		entry.FreshUntil = now.Add(cacheControl.seconds("max-age") - age)
+		return true
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			// Invalid Expires means already expired
+			entry.FreshUntil = time.Time{}
+			return true
+		}
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		entry.FreshUntil = now.Add(expiresAt.Sub(date) - age)
+		return true
+	}
+
+	entry.FreshUntil = time.Time{}
+	return false
+}
+
+// A stale response must not be used if server asked to always revalidate it, or if the request wants a validated response
+func canServeStale(entry *command.CacheEntry, requestCacheControl cacheControl) bool {
+	if requestCacheControl.has("no-cache") {
+		return false
+	}
+	cacheControl := parseCacheControl(entry.Header.Get("Cache-Control"))
+	return !cacheControl.has("must-revalidate") && !cacheControl.has("proxy-revalidate") && !cacheControl.has("no-cache")
+}
+
+func entryToResponse(req *http.Request, entry *command.CacheEntry, status command.CacheStatus) *http.Response {
+	header := entry.Header.Clone()
+	header.Set(cacheStatusHeader, string(status))
+	return &http.Response{
+		Status:        strconv.Itoa(entry.StatusCode) + " " + http.StatusText(entry.StatusCode),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
+
+func withConditionalHeaders(req *http.Request, entry *command.CacheEntry) *http.Request {
+	outgoing := req.Clone(req.Context())
+	if etag := entry.Header.Get("ETag"); etag != "" && outgoing.Header.Get("If-None-Match") == "" {
+		outgoing.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" && outgoing.Header.Get("If-Modified-Since") == "" {
+		outgoing.Header.Set("If-Modified-Since", lastModified)
+	}
+	return outgoing
+}
+
+// Key is method and url, with hash of credential headers (if any) - credentials are not kept as it is in the store
+func cacheKey(req *http.Request, credentialHeaders []string) string {
+	key := req.Method + " " + req.URL.String()
+	hash := sha256.New()
+	hasCredentials := false
+	for _, name := range credentialHeaders {
+		for _, value := range req.Header.Values(name) {
+			hasCredentials = true
+			_, _ = hash.Write([]byte(name + ": " + value + "\n"))
+		}
+	}
+	if hasCredentials {
+		key += " " + hex.EncodeToString(hash.Sum(nil))
+	}
+	return key
+}
+
+func varyHeaders(header http.Header) []string {
+	names := make([]string, 0)
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func drainAndClose(response *http.Response) {
+	_, _ = ioutil.ReadAll(response.Body)
+	_ = response.Body.Close()
+}
+
+// Parsed Cache-Control header
+type cacheControl map[string]string
+
+func parseCacheControl(value string) cacheControl {
+	cc := cacheControl{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if i := strings.Index(part, "="); i >= 0 {
+			cc[strings.ToLower(strings.TrimSpace(part[:i]))] = strings.Trim(strings.TrimSpace(part[i+1:]), "\"")
+		} else {
+			cc[strings.ToLower(part)] = ""
+		}
+	}
+	return cc
+}
+
+func (c cacheControl) has(name string) bool {
+	_, ok := c[name]
+	return ok
+}
+
+func (c cacheControl) seconds(name string) time.Duration {
+	if v, err := strconv.Atoi(c[name]); err == nil && v > 0 {
+		return time.Duration(v) * time.Second
+	}
+	return 0
+}
+
+func newCachingTransport(server *command.Server, api *command.Api, base http.RoundTripper, logger *zap.Logger) http.RoundTripper {
+	if api.Cache == nil || !api.Cache.Enabled {
+		return base
+	}
+	store := api.Cache.Store
+	if store == nil {
+		store = command.NewLruCacheStore(api.Cache.MaxEntries)
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &cachingTransport{base: base, store: store, logger: logger, revalidating: &sync.Map{}, credentialHeaders: api.GetCredentialHeaders(server)}
+}
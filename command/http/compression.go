@@ -0,0 +1,137 @@
+package httpCommand
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/andybalholm/brotli"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-http/command"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const acceptEncodingAll = "gzip, deflate, br, zstd"
+
+var zstdEncoder *zstd.Encoder
+var zstdEncoderErr error
+var zstdEncoderOnce = &sync.Once{}
+
+// Encoder shared by all requests (EncodeAll can be used concurrently) - created on first use
+func sharedZstdEncoder() (*zstd.Encoder, error) {
+	zstdEncoderOnce.Do(func() {
+		if zstdEncoder, zstdEncoderErr = zstd.NewWriter(nil); zstdEncoderErr != nil {
+			zstdEncoderErr = errors.Wrap(zstdEncoderErr, "failed to create zstd encoder")
+		}
+	})
+	return zstdEncoder, zstdEncoderErr
+}
+
+// Compress the request body with the given encoding (gzip, deflate or zstd)
+func compressBody(encoding string, body []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	switch encoding {
+	case command.CompressionGzip:
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case command.CompressionDeflate:
+		w := zlib.NewWriter(buf)
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case command.CompressionZstd:
+		encoder, err := sharedZstdEncoder()
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(body, nil), nil
+	default:
+		return nil, errors.New("unsupported request compression: %s", encoding)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressingTransport advertises all supported encodings and decodes the response body
+type decompressingTransport struct {
+	base http.RoundTripper
+}
+
+func (d *decompressingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	outgoing := req
+	if req.Header.Get("Accept-Encoding") == "" {
+		outgoing = req.Clone(req.Context())
+		outgoing.Header.Set("Accept-Encoding", acceptEncodingAll)
+	}
+
+	response, err := d.base.RoundTrip(outgoing)
+	if err != nil || response.Body == nil || response.Body == http.NoBody {
+		return response, err
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding")))
+	var reader io.ReadCloser
+	switch encoding {
+	case "", "identity":
+		return response, nil
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(response.Body)
+	case "deflate":
+		reader, err = zlib.NewReader(response.Body)
+	case "br":
+		reader = io.NopCloser(brotli.NewReader(response.Body))
+	case "zstd":
+		var decoder *zstd.Decoder
+		if decoder, err = zstd.NewReader(response.Body); err == nil {
+			reader = decoder.IOReadCloser()
+		}
+	default:
+		return response, nil
+	}
+	if err != nil {
+		_ = response.Body.Close()
+		return nil, errors.Wrap(err, "failed to decode response with content-encoding=%s", encoding)
+	}
+
+	response.Body = &decodedBody{reader: reader, body: response.Body}
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = -1
+	response.Uncompressed = true
+	return response, nil
+}
+
+// Closes both the decoder and the original body
+type decodedBody struct {
+	reader io.ReadCloser
+	body   io.ReadCloser
+}
+
+func (d *decodedBody) Read(p []byte) (int, error) {
+	return d.reader.Read(p)
+}
+
+func (d *decodedBody) Close() error {
+	_ = d.reader.Close()
+	return d.body.Close()
+}
+
+func newDecompressingTransport(api *command.Api, base http.RoundTripper) http.RoundTripper {
+	if !api.ResponseCompression {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &decompressingTransport{base: base}
+}
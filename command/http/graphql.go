@@ -0,0 +1,139 @@
+package httpCommand
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-http/command"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// Execute a GraphQL call. With persisted queries, only the query hash is sent first - the call is made again with the
+// full query if server does not know the hash
+func (h *HttpCommand) internalExecuteGraphQL(ctx context.Context, request *command.GoxRequest) (*command.GoxResponse, error) {
+	graphQLRequest := h.graphQLRequest(request)
+	persistedQuery := h.api.GraphQL != nil && h.api.GraphQL.PersistedQuery
+
+	response, err := h.executeGraphQL(ctx, request, graphQLRequest, persistedQuery, !persistedQuery)
+	var goxErr *command.GoxHttpError
+	if persistedQuery && errors.As(err, &goxErr) && goxErr.GraphQLErrors().IsPersistedQueryNotFound() {
+		h.logger.Debug("server does not know the persisted query, sending full query")
+		response, err = h.executeGraphQL(ctx, request, graphQLRequest, true, true)
+	}
+	return response, err
+}
+
+// Query and operation name of the request, or the ones from api config if query is not given in request
+func (h *HttpCommand) graphQLRequest(request *command.GoxRequest) *command.GraphQLRequest {
+	graphQLRequest := command.GraphQLRequest{}
+	if request.GraphQL != nil {
+		graphQLRequest = *request.GraphQL
+	}
+	if graphQLRequest.Query == "" {
+		graphQLRequest.Query = h.graphQLQuery
+		if graphQLRequest.OperationName == "" && h.api.GraphQL != nil {
+			graphQLRequest.OperationName = h.api.GraphQL.OperationName
+		}
+	}
+	return &graphQLRequest
+}
+
+func (h *HttpCommand) executeGraphQL(ctx context.Context, request *command.GoxRequest, graphQLRequest *command.GraphQLRequest, persistedQuery bool, includeQuery bool) (*command.GoxResponse, error) {
+	body, err := command.BuildGraphQLBody(graphQLRequest, persistedQuery, includeQuery)
+	if err != nil {
+		return nil, &command.GoxHttpError{
+			Err:        err,
+			StatusCode: http.StatusBadRequest,
+			Message:    "failed to build graphql request",
+			ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+		}
+	}
+
+	// Response is decoded after reading "data" and "errors"
+	response, err := h.internalExecute(ctx, envelopeRequest(request, body, command.ContentTypeJson, command.ContentTypeJson))
+	if response == nil || response.Body == nil {
+		return response, err
+	}
+	return h.processGraphQLResponse(request, response, err)
+}
+
+// Copy of the request which sends the given envelope (graphql, jsonrpc or soap) as body, and does not decode response.
+// Content-Type and Accept headers are set if they are not given in request
+func envelopeRequest(request *command.GoxRequest, body []byte, contentType string, accept string) *command.GoxRequest {
+	httpRequest := *request
+	httpRequest.Body = body
+	httpRequest.BodyProvider = nil
+	httpRequest.ResponseBuilder = nil
+	httpRequest.ResponseTarget = nil
+	httpRequest.FormData = nil
+	httpRequest.Files = nil
+	httpRequest.Stream = false
+	httpRequest.Header = http.Header{}
+	for name, values := range request.Header {
+		httpRequest.Header[name] = append([]string{}, values...)
+	}
+	if httpRequest.Header.Get("Content-Type") == "" {
+		httpRequest.Header.Set("Content-Type", contentType)
+	}
+	if httpRequest.Header.Get("Accept") == "" {
+		httpRequest.Header.Set("Accept", accept)
+	}
+	return &httpRequest
+}
+
+// Decode "data" of the response using the response builder of the request. If response has "errors", a GoxHttpError
+// with the errors is returned - along with the decoded data if server returned partial data
+func (h *HttpCommand) processGraphQLResponse(request *command.GoxRequest, response *command.GoxResponse, err error) (*command.GoxResponse, error) {
+	graphQLResponse, decodeErr := command.DecodeGraphQLResponse(response.Body)
+
+	// Error response (non 2xx) can also have graphql errors
+	if err != nil {
+		var goxErr *command.GoxHttpError
+		if decodeErr == nil && len(graphQLResponse.Errors) > 0 && errors.As(err, &goxErr) && goxErr.DecodedBody == nil {
+			goxErr.DecodedBody = graphQLResponse.Errors
+		}
+		return response, err
+	}
+
+	if decodeErr != nil {
+		response.Err = &command.GoxHttpError{
+			Err:        decodeErr,
+			StatusCode: response.StatusCode,
+			Message:    "failed to decode graphql response",
+			ErrorCode:  "failed_to_build_response_using_response_builder",
+			Body:       response.Body,
+		}
+		return response, response.Err
+	}
+
+	if responseBuilder := request.GetResponseBuilder(response.Header); responseBuilder != nil && graphQLResponse.HasData() {
+		if response.Response, err = responseBuilder.Response(graphQLResponse.Data); err != nil {
+			response.Err = &command.GoxHttpError{
+				Err:        errors.Wrap(err, "failed to create response using response builder"),
+				StatusCode: response.StatusCode,
+				Message:    "failed to create response using response builder",
+				ErrorCode:  "failed_to_build_response_using_response_builder",
+				Body:       response.Body,
+			}
+			return response, response.Err
+		}
+	}
+
+	if len(graphQLResponse.Errors) > 0 {
+		h.logger.Debug("graphql server returned errors", zap.Error(graphQLResponse.Errors))
+		goxErr := &command.GoxHttpError{
+			Err:         graphQLResponse.Errors,
+			StatusCode:  response.StatusCode,
+			Message:     "graphql server returned errors",
+			ErrorCode:   command.ErrorCodeGraphQLError,
+			Body:        response.Body,
+			DecodedBody: graphQLResponse.Errors,
+		}
+		if graphQLResponse.HasData() {
+			goxErr.Message = "graphql server returned partial data with errors"
+			goxErr.ErrorCode = command.ErrorCodeGraphQLPartialError
+		}
+		response.Err = goxErr
+	}
+	return response, response.Err
+}
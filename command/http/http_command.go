@@ -11,6 +11,8 @@ import (
 	_ "github.com/go-resty/resty/v2"
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"net"
 	"net/http"
 	"strings"
@@ -35,7 +37,18 @@ type HttpCommand struct {
 	api              *command.Api
 	logger           *zap.Logger
 	client           *resty.Client
+	streamClient     *resty.Client
 	setRetryFuncOnce *sync.Once
+	auth             authProvider
+	headers          *command.HeaderTemplate
+	requestTemplate  *command.RequestTemplate
+	compression      string
+	errorProto       protoreflect.MessageType
+	graphQLQuery     string
+	soapVersion      string
+	metrics          *httpMetrics
+	tracing          *httpTracing
+	accessLog        *accessLogger
 }
 
 func (h *HttpCommand) ExecuteAsync(ctx context.Context, request *command.GoxRequest) chan *command.GoxResponse {
@@ -51,8 +64,25 @@ func (h *HttpCommand) ExecuteAsync(ctx context.Context, request *command.GoxRequ
 }
 
 func (h *HttpCommand) Execute(ctx context.Context, request *command.GoxRequest) (*command.GoxResponse, error) {
+	ctx, stats := withCallStats(ctx)
+	requestDone := h.metrics.requestStarted()
+	ctx, traceDone := h.tracing.requestStarted(ctx)
+	accessDone := h.accessLog.requestStarted(ctx, request, stats)
 
-	response, err := h.internalExecute(ctx, request)
+	var response *command.GoxResponse
+	var err error
+	if h.api.IsGraphQL() {
+		response, err = h.internalExecuteGraphQL(ctx, request)
+	} else if h.api.IsJsonRpc() {
+		response, err = h.internalExecuteJsonRpc(ctx, request)
+	} else if h.api.IsSoap() {
+		response, err = h.internalExecuteSoap(ctx, request)
+	} else {
+		response, err = h.internalExecute(ctx, request)
+	}
+	requestDone(response, err)
+	traceDone(response, err)
+	accessDone(response, err)
 
 	// Log HTTP metrics
 	if EnableGoxHttpMetricLogging {
@@ -83,6 +113,10 @@ func (h *HttpCommand) Execute(ctx context.Context, request *command.GoxRequest)
 }
 
 func (h *HttpCommand) internalExecute(ctx context.Context, request *command.GoxRequest) (*command.GoxResponse, error) {
+	if request.Stream {
+		return h.internalExecuteStream(ctx, request)
+	}
+
 	// sp, ctxWithSpan := opentracing.StartSpanFromContext(ctx, h.api.Name)
 	sp, ctxWithSpan := DefaultStartSpanFromContextFunc(ctx, h.api.Name)
 	defer sp.Finish()
@@ -97,21 +131,22 @@ func (h *HttpCommand) internalExecute(ctx context.Context, request *command.GoxR
 		return nil, err
 	}
 
-	// Create the url to call
-	finalUrlToRequest := h.api.GetPath(h.server)
+	// Url to call (set by buildRequest)
+	finalUrlToRequest := r.URL
 	h.logger.Debug("url to use", zap.String("url", finalUrlToRequest))
 
 	start := time.Now()
-	switch strings.ToUpper(h.api.Method) {
-	case "GET":
-		response, err = r.Get(finalUrlToRequest)
-	case "POST":
-		response, err = r.Post(finalUrlToRequest)
-	case "PUT":
-		response, err = r.Put(finalUrlToRequest)
-	case "DELETE":
-		response, err = r.Delete(finalUrlToRequest)
+	response, err = h.send(r, finalUrlToRequest)
+
+	// Server rejected our credentials - refresh them and retry once
+	if err == nil && response.StatusCode() == http.StatusUnauthorized && h.auth != nil && h.auth.invalidate(r.Header) {
+		h.logger.Debug("got 401 from server, retrying with refreshed credentials")
+		if r, err = h.buildRequest(ctxWithSpan, request, sp); err != nil {
+			return nil, err
+		}
+		response, err = h.send(r, finalUrlToRequest)
 	}
+	h.metrics.retried(r.Attempt - 1)
 	end := time.Now()
 	if EnableTimeTakenByHttpCall {
 		h.logger.Info("Time taken: ", zap.Int64("time_taken", end.UnixMilli()-start.UnixMilli()), zap.Int64("start", start.UnixMilli()), zap.Int64("end", end.UnixMilli()), zap.String("url", finalUrlToRequest))
@@ -122,12 +157,45 @@ func (h *HttpCommand) internalExecute(ctx context.Context, request *command.GoxR
 		return responseObject, responseObject.Err
 	} else {
 		responseObject := h.processResponse(request, response)
+		if status := response.Header().Get(cacheStatusHeader); status != "" {
+			response.Header().Del(cacheStatusHeader)
+			responseObject.CacheStatus = command.CacheStatus(status)
+		}
+		responseObject.Header = response.Header()
 		return responseObject, responseObject.Err
 	}
 }
 
+func (h *HttpCommand) send(r *resty.Request, url string) (response *resty.Response, err error) {
+	switch strings.ToUpper(h.api.Method) {
+	case "GET":
+		response, err = r.Get(url)
+	case "POST":
+		response, err = r.Post(url)
+	case "PUT":
+		response, err = r.Put(url)
+	case "DELETE":
+		response, err = r.Delete(url)
+	case "PATCH":
+		response, err = r.Patch(url)
+	case "HEAD":
+		response, err = r.Head(url)
+	case "OPTIONS":
+		response, err = r.Options(url)
+	default:
+		err = errors.New("unsupported http method=%s for api=%s", h.api.Method, h.api.Name)
+	}
+	return
+}
+
 func (h *HttpCommand) buildRequest(ctx context.Context, request *command.GoxRequest, sp opentracing.Span) (*resty.Request, error) {
-	r := h.client.R()
+	var r *resty.Request
+	if request.Stream {
+		r = h.streamClient.R()
+		r.SetDoNotParseResponse(true)
+	} else {
+		r = h.client.R()
+	}
 	r.SetContext(ctx)
 
 	// If retry is enabled then we will setup retrying
@@ -147,12 +215,25 @@ func (h *HttpCommand) buildRequest(ctx context.Context, request *command.GoxRequ
 				if response != nil && h.api.IsHttpCodeAcceptable(response.StatusCode()) {
 					return false
 				}
+
+				// Server will give the same large response again - no need to retry
+				var goxErr *command.GoxHttpError
+				if errors.As(err, &goxErr) && goxErr.IsResponseTooLargeError() {
+					return false
+				}
+
+				// SOAP Fault (sent with http 500) is an answer of the server - it will give the same fault again
+				if h.api.IsSoap() && response != nil && len(response.Body()) > 0 {
+					if _, fault, _ := command.DecodeSoapResponse(response.Body()); fault != nil {
+						return false
+					}
+				}
 				if response != nil {
-					h.logger.Info("retrying api after error", zap.Any("response", response))
+					h.logger.Debug("retrying api after error", zap.Int("status", response.StatusCode()))
 				} else if err != nil {
-					h.logger.Info("retrying api after error", zap.String("err", err.Error()))
+					h.logger.Debug("retrying api after error", zap.String("err", err.Error()))
 				} else {
-					h.logger.Info("retrying api after error")
+					h.logger.Debug("retrying api after error")
 				}
 				return true
 			})
@@ -163,45 +244,128 @@ func (h *HttpCommand) buildRequest(ctx context.Context, request *command.GoxRequ
 	tracer := opentracing.GlobalTracer()
 	_ = tracer.Inject(sp.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header))
 
-	// Set header
+	// Set default headers from server and api config - request headers will override them
+	if h.headers != nil {
+		headers, err := h.headers.Build(ctx)
+		if err != nil {
+			return nil, &command.GoxHttpError{
+				Err:        err,
+				StatusCode: http.StatusInternalServerError,
+				Message:    "failed to build default headers",
+				ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+			}
+		}
+		for name, values := range headers {
+			for _, value := range values {
+				r.SetHeader(name, value)
+			}
+		}
+	}
+
+	// Set header - all values of a repeated header are sent
 	if request.Header != nil {
 		for name, headers := range request.Header {
+			r.Header.Del(name)
 			for _, value := range headers {
-				r.SetHeader(name, value)
+				r.Header.Add(name, value)
 			}
 		}
 	}
 
-	// Auto set application/json as default
-	if _, ok := request.Header["Content-Type"]; !ok {
-		if _, ok := request.Header["content-type"]; !ok {
-			r.SetHeader("content-type", "application/json")
+	// Set query and path params from templates of api - params set in request will override them
+	pathParams := command.MultivaluedMap{}
+	if h.requestTemplate != nil {
+		queryParams, err := h.requestTemplate.QueryParams(ctx, request)
+		if err != nil {
+			return nil, &command.GoxHttpError{
+				Err:        err,
+				StatusCode: http.StatusInternalServerError,
+				Message:    "failed to build query params from template",
+				ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+			}
+		}
+		for name, values := range queryParams {
+			for _, value := range values {
+				r.SetQueryParam(name, value)
+			}
+		}
+		for name, value := range h.requestTemplate.PathParams(request) {
+			pathParams[name] = []string{value}
 		}
 	}
 
-	// Set query param
+	// Set query param - all values of a repeated query param are sent
 	if request.QueryParam != nil {
 		for name, values := range request.QueryParam {
+			r.QueryParam.Del(name)
 			for _, value := range values {
-				r.SetQueryParam(name, value)
+				r.QueryParam.Add(name, value)
 			}
 		}
 	}
 
-	// Set path param
-	if request.PathParam != nil {
-		for name, values := range request.PathParam {
-			for _, value := range values {
-				r.SetPathParam(name, value)
+	// Url to call - request can override path or url of the api
+	requestUrl, err := h.api.GetRequestUrlWithContext(ctx, h.server, request)
+	if err != nil {
+		return nil, err
+	}
+	r.URL = requestUrl
+
+	// Set path param - values are escaped by resty
+	for name, values := range request.PathParam {
+		pathParams[name] = values
+	}
+	resolvedPathParams, err := h.api.ResolvePathParams(requestUrl, pathParams)
+	if err != nil {
+		return nil, err
+	}
+	r.SetPathParams(resolvedPathParams)
+
+	if request.HasForm() {
+		b, contentType, err := request.BuildFormBody()
+		if err != nil {
+			return nil, &command.GoxHttpError{
+				Err:        err,
+				StatusCode: http.StatusInternalServerError,
+				Message:    "failed to build form body",
+				ErrorCode:  command.ErrorCodeFailedToBuildRequest,
 			}
 		}
-	}
 
-	if b, ok := request.Body.([]byte); ok {
-		r.SetBody(b)
+		// Multipart content type has the boundary used in this body, so it must be set by us
+		if r.Header.Get("Content-Type") == "" || len(request.Files) > 0 {
+			r.SetHeader("Content-Type", contentType)
+		}
+		if err := h.setBody(r, b); err != nil {
+			return nil, err
+		}
+	} else if b, ok := request.Body.([]byte); ok {
+		if err := h.setBody(r, b); err != nil {
+			return nil, err
+		}
+	} else if request.Body == nil && request.BodyProvider == nil && h.requestTemplate.HasBody() {
+		b, err := h.requestTemplate.Body(ctx, request)
+		if err != nil {
+			return nil, &command.GoxHttpError{
+				Err:        err,
+				StatusCode: http.StatusInternalServerError,
+				Message:    "failed to build body from template",
+				ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+			}
+		}
+		if r.Header.Get("Content-Type") == "" && h.requestTemplate.BodyType() == command.BodyTemplateTypeJson {
+			r.SetHeader("Content-Type", command.ContentTypeJson)
+		} else if r.Header.Get("Content-Type") == "" && h.api.ContentType != "" {
+			r.SetHeader("Content-Type", h.api.ContentType)
+		}
+		if err := h.setBody(r, b); err != nil {
+			return nil, err
+		}
 	} else if request.BodyProvider != nil {
 		if b, err := request.BodyProvider.Body(request.Body); err == nil {
-			r.SetBody(b)
+			if err := h.setBody(r, b); err != nil {
+				return nil, err
+			}
 		} else {
 			return nil, &command.GoxHttpError{
 				Err:        err,
@@ -210,9 +374,25 @@ func (h *HttpCommand) buildRequest(ctx context.Context, request *command.GoxRequ
 				ErrorCode:  command.ErrorCodeFailedToBuildRequest,
 			}
 		}
+	} else if codec, contentType, ok := h.requestCodec(r, request.Body); ok {
+		if b, err := codec.Marshal(request.Body); err == nil {
+			r.SetHeader("Content-Type", contentType)
+			if err := h.setBody(r, b); err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, &command.GoxHttpError{
+				Err:        err,
+				StatusCode: http.StatusInternalServerError,
+				Message:    "failed to encode body using codec for content type " + contentType,
+				ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+			}
+		}
 	} else {
 		if b, err := serialization.Stringify(request.Body); err == nil {
-			r.SetBody(b)
+			if err := h.setBody(r, []byte(b)); err != nil {
+				return nil, err
+			}
 		} else {
 			return nil, &command.GoxHttpError{
 				Err:        err,
@@ -223,18 +403,78 @@ func (h *HttpCommand) buildRequest(ctx context.Context, request *command.GoxRequ
 		}
 	}
 
+	// Auto set application/json (or protobuf for a proto.Message body) as default
+	if r.Header.Get("Content-Type") == "" {
+		r.SetHeader("content-type", command.DefaultContentType(request.Body))
+	}
+
+	// Add auth configured for this server/api
+	if h.auth != nil {
+		if err := h.auth.apply(ctx, r.Header, r.QueryParam); err != nil {
+			return nil, err
+		}
+	}
+
 	return r, nil
 }
 
+// Codec to encode request body - picked using Content-Type header of request, or content type of the api (protobuf is
+// used for a proto.Message if neither is set). Returns false if no codec is registered for it (body is converted using
+// serialization.Stringify in this case)
+func (h *HttpCommand) requestCodec(r *resty.Request, body interface{}) (command.Codec, string, bool) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = h.api.ContentType
+	}
+	if contentType == "" {
+		if _, ok := body.(proto.Message); !ok {
+			return nil, "", false
+		}
+		contentType = command.DefaultContentType(body)
+	}
+	codec, ok := command.FindCodec(contentType)
+	return codec, contentType, ok
+}
+
+// Set request body - compressed if request compression is enabled and body is large enough
+func (h *HttpCommand) setBody(r *resty.Request, body []byte) error {
+	callStatsFromContext(r.Context()).requestBodyBuilt(body)
+	if h.compression == "" || len(body) == 0 || len(body) < h.api.RequestCompressionMinBytes || r.Header.Get("Content-Encoding") != "" {
+		r.SetBody(body)
+		return nil
+	}
+	compressed, err := compressBody(h.compression, body)
+	if err != nil {
+		return &command.GoxHttpError{
+			Err:        err,
+			StatusCode: http.StatusInternalServerError,
+			Message:    "failed to compress request body",
+			ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+		}
+	}
+	r.SetHeader("Content-Encoding", h.compression)
+	r.SetBody(compressed)
+	return nil
+}
+
+// Decode error response into "error_proto" of the api (if configured)
+func (h *HttpCommand) decodeErrorBody(header http.Header, body []byte) interface{} {
+	if h.errorProto == nil {
+		return nil
+	}
+	return command.DecodeErrorBody(h.errorProto, header.Get("Content-Type"), body)
+}
+
 func (h *HttpCommand) processResponse(request *command.GoxRequest, response *resty.Response) *command.GoxResponse {
 	var processedResponse interface{}
 	var err error
+	responseBuilder := request.GetResponseBuilder(response.Header())
 
 	if response.IsError() {
 
 		if h.api.IsHttpCodeAcceptable(response.StatusCode()) {
-			if request.ResponseBuilder != nil && response.Body() != nil {
-				processedResponse, err = request.ResponseBuilder.Response(response.Body())
+			if responseBuilder != nil && response.Body() != nil {
+				processedResponse, err = responseBuilder.Response(response.Body())
 				if err != nil {
 					return &command.GoxResponse{
 						Body:       response.Body(),
@@ -254,11 +494,12 @@ func (h *HttpCommand) processResponse(request *command.GoxRequest, response *res
 				Body:       response.Body(),
 				StatusCode: response.StatusCode(),
 				Err: &command.GoxHttpError{
-					Err:        errors.Wrap(err, "got response with server with error"),
-					StatusCode: response.StatusCode(),
-					Message:    "got response from server with error",
-					ErrorCode:  "server_response_with_error",
-					Body:       response.Body(),
+					Err:         errors.Wrap(err, "got response with server with error"),
+					StatusCode:  response.StatusCode(),
+					Message:     "got response from server with error",
+					ErrorCode:   "server_response_with_error",
+					Body:        response.Body(),
+					DecodedBody: h.decodeErrorBody(response.Header(), response.Body()),
 				},
 			}
 		}
@@ -271,8 +512,8 @@ func (h *HttpCommand) processResponse(request *command.GoxRequest, response *res
 
 	} else {
 
-		if request.ResponseBuilder != nil && response.Body() != nil {
-			processedResponse, err = request.ResponseBuilder.Response(response.Body())
+		if responseBuilder != nil && response.Body() != nil {
+			processedResponse, err = responseBuilder.Response(response.Body())
 			if err != nil {
 				return &command.GoxResponse{
 					Body:       response.Body(),
@@ -299,6 +540,12 @@ func (h *HttpCommand) processResponse(request *command.GoxRequest, response *res
 func (h *HttpCommand) handleError(err error) *command.GoxResponse {
 	var responseObject *command.GoxResponse
 
+	// Errors created by us e.g. response body too large
+	var goxErr *command.GoxHttpError
+	if errors.As(err, &goxErr) {
+		return &command.GoxResponse{StatusCode: goxErr.StatusCode, Err: goxErr}
+	}
+
 	// Timeout errors are handled here
 	switch e := err.(type) {
 	case net.Error:
@@ -332,6 +579,69 @@ func (h *HttpCommand) handleError(err error) *command.GoxResponse {
 }
 
 func NewHttpCommand(cf gox.CrossFunction, server *command.Server, api *command.Api) (command.Command, error) {
+	auth, err := newAuthProvider(server, api)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := command.NewSigner(api.GetSigning(server))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request signer for api=%s", api.Name)
+	}
+
+	headers, err := command.NewHeaderTemplate(api.GetHeaders(server))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create default headers for api=%s", api.Name)
+	}
+
+	requestTemplate, err := command.NewRequestTemplate(api)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = server.GetBaseUrl(); err != nil {
+		return nil, err
+	}
+
+	compression, err := api.GetRequestCompression()
+	if err != nil {
+		return nil, err
+	}
+	if compression == command.CompressionZstd {
+		if _, err = sharedZstdEncoder(); err != nil {
+			return nil, err
+		}
+	}
+
+	errorProto, err := api.GetErrorProtoType()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = api.ValidateType(); err != nil {
+		return nil, err
+	}
+
+	graphQLQuery, err := api.GetGraphQLQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	soapVersion, err := api.GetSoapVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	httpMetrics, err := newHttpMetrics(cf, server, api)
+	if err != nil {
+		return nil, err
+	}
+
+	accessLog, err := newAccessLogger(cf, server, api)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create access log for api=%s", api.Name)
+	}
+
 	c := &HttpCommand{
 		CrossFunction:    cf,
 		server:           server,
@@ -339,8 +649,25 @@ func NewHttpCommand(cf gox.CrossFunction, server *command.Server, api *command.A
 		logger:           cf.Logger().Named("goxHttp").Named(api.Name),
 		client:           resty.New(),
 		setRetryFuncOnce: &sync.Once{},
+		auth:             auth,
+		headers:          headers,
+		requestTemplate:  requestTemplate,
+		compression:      compression,
+		errorProto:       errorProto,
+		graphQLQuery:     graphQLQuery,
+		soapVersion:      soapVersion,
+		metrics:          httpMetrics,
+		tracing:          newHttpTracing(server, api),
+		accessLog:        accessLog,
 	}
+	transport := newLimitingTransport(api, newDecompressingTransport(api, newMetricsTransport(httpMetrics, newTracingTransport(newSigningTransport(signer, c.client.GetClient().Transport)))))
+	c.client.SetTransport(newCachingTransport(server, api, transport, c.logger))
 	c.client.SetAllowGetMethodPayload(true)
 	c.client.SetTimeout(time.Duration(api.Timeout) * time.Millisecond)
+
+	// Streaming requests use a client without timeout (body is read by the caller) and without response cache
+	c.streamClient = resty.New()
+	c.streamClient.SetTransport(transport)
+	c.streamClient.SetAllowGetMethodPayload(true)
 	return c, nil
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/zap"
 	"net/http"
+	"sync"
 )
 
 var HystrixConfigMap = gox.StringObjectMap{}
@@ -20,13 +21,23 @@ type HttpHystrixCommand struct {
 	command            command.Command
 	hystrixCommandName string
 	api                *command.Api
+	limiter            *priorityLimiter
 
 	serverName string
 	apiName    string
+	metrics    *httpMetrics
 }
 
+// UpdateCommand replaces the underlying command. If it is a HttpCommand, the api settings used by this command (priority
+// limiter and hystrix config) are also rebuilt from the api of the new command
 func (h *HttpHystrixCommand) UpdateCommand(command command.Command) {
 	h.command = command
+	if httpCmd, ok := command.(*HttpCommand); ok {
+		h.api = httpCmd.api
+		h.limiter = newPriorityLimiter(httpCmd.api)
+		h.metrics = httpCmd.metrics
+		configureHystrixCommand(h.hystrixCommandName, httpCmd.api)
+	}
 }
 
 type result struct {
@@ -35,13 +46,36 @@ type result struct {
 }
 
 func (h *HttpHystrixCommand) Execute(ctx context.Context, request *command.GoxRequest) (*command.GoxResponse, error) {
+	// Wait for a slot if priority based admission is enabled for this api. Keep the limiter we got the slot from, the
+	// limiter is replaced if this api is reloaded
+	if limiter := h.limiter; limiter != nil && request != nil {
+		if err := limiter.acquire(ctx, request.Priority); err != nil {
+			h.logNotAdmittedRequest(request, err)
+			return nil, err
+		}
+		defer limiter.release()
+	}
+
+	defer h.logCircuitState()
+
 	r := &result{}
+	abandoned := &abandonedStream{lock: &sync.Mutex{}}
 	if err := hystrix.Do(h.hystrixCommandName, func() error {
-		r.response, r.err = h.command.Execute(ctx, request)
+		response, err := h.command.Execute(ctx, request)
+		if abandoned.closeIfAbandoned(response) {
+			return err
+		}
+		r.response, r.err = response, err
 		h.logHystrixError(ctx, request, r.err)
+		if isApplicationError(r.err) {
+			return nil
+		}
 		return r.err
 	}, nil); err != nil {
 		h.logHystrixError(ctx, request, err)
+		if request != nil && request.Stream {
+			abandoned.abandon(r)
+		}
 		return r.response, h.errorCreator(err)
 	} else {
 		h.logHystrixError(ctx, request, r.err)
@@ -49,6 +83,14 @@ func (h *HttpHystrixCommand) Execute(ctx context.Context, request *command.GoxRe
 	}
 }
 
+// Errors which a healthy server sends as a well formed response (e.g. graphql "errors" or jsonrpc "error" object with
+// http 200, or a SOAP Fault) are returned to the caller, but are not counted as failures by hystrix - they must not
+// open the circuit
+func isApplicationError(err error) bool {
+	var goxErr *command.GoxHttpError
+	return errors.As(err, &goxErr) && (goxErr.IsGraphQLError() || goxErr.IsJsonRpcError() || goxErr.IsSoapFault())
+}
+
 // If this is a hystrix error then log it
 func (h *HttpHystrixCommand) logHystrixError(ctx context.Context, request *command.GoxRequest, err error) {
 	if e, ok := err.(hystrix.CircuitError); ok {
@@ -71,6 +113,52 @@ func (h *HttpHystrixCommand) logHystrixError(ctx context.Context, request *comma
 	}
 }
 
+// Emit the state of circuit of this api
+func (h *HttpHystrixCommand) logCircuitState() {
+	if h.metrics == nil || !EnableGoxHttpMetricLogging {
+		return
+	}
+	if circuit, _, err := hystrix.GetCircuit(h.hystrixCommandName); err == nil {
+		h.metrics.circuitState(circuit.IsOpen())
+	}
+}
+
+func (h *HttpHystrixCommand) logNotAdmittedRequest(request *command.GoxRequest, err error) {
+	h.logger.Debug("request not admitted", zap.Int("priority", int(request.Priority)), zap.Error(err))
+	if EnableGoxHttpMetricLogging {
+		if e, ok := err.(*command.GoxHttpError); ok {
+			h.Metric().Tagged(map[string]string{"server": h.serverName, "api": h.apiName, "status": fmt.Sprintf("%d", e.StatusCode), "error": e.ErrorCode, "priority": fmt.Sprintf("%d", request.Priority)}).Counter("gox_http_call").Inc(1)
+		}
+	}
+}
+
+// A streaming response which comes after hystrix has given up (e.g. hystrix timeout) is not used by anyone - it must be
+// closed to release the connection
+type abandonedStream struct {
+	lock      *sync.Mutex
+	abandoned bool
+}
+
+func (a *abandonedStream) closeIfAbandoned(response *command.GoxResponse) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.abandoned && response != nil && response.BodyStream != nil {
+		_ = response.BodyStream.Close()
+	}
+	return a.abandoned
+}
+
+// Mark the call as abandoned. If the stream already came (hystrix failed after the command returned), it is closed
+func (a *abandonedStream) abandon(r *result) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.abandoned = true
+	if r.response != nil && r.response.BodyStream != nil {
+		_ = r.response.BodyStream.Close()
+		r.response.BodyStream = nil
+	}
+}
+
 func (h *HttpHystrixCommand) ExecuteAsync(ctx context.Context, request *command.GoxRequest) chan *command.GoxResponse {
 	return nil
 }
@@ -142,10 +230,20 @@ func NewHttpHystrixCommand(cf gox.CrossFunction, server *command.Server, api *co
 		command:            hc,
 		hystrixCommandName: commandName,
 		api:                api,
+		limiter:            newPriorityLimiter(api),
 		serverName:         server.Name,
 		apiName:            api.Name,
 	}
+	if httpCmd, ok := hc.(*HttpCommand); ok {
+		c.metrics = httpCmd.metrics
+	}
+
+	configureHystrixCommand(commandName, api)
+
+	return c, nil
+}
 
+func configureHystrixCommand(name string, api *command.Api) {
 	// Set timeout + 10% delta
 	timeout := api.Timeout
 
@@ -166,11 +264,9 @@ func NewHttpHystrixCommand(cf gox.CrossFunction, server *command.Server, api *co
 		timeout = config.IntOrZero("timeout")
 	}
 
-	hystrix.ConfigureCommand(commandName, hystrix.CommandConfig{
+	hystrix.ConfigureCommand(name, hystrix.CommandConfig{
 		Timeout:               timeout,
 		MaxConcurrentRequests: api.Concurrency,
 		ErrorPercentThreshold: 25,
 	})
-
-	return c, nil
 }
@@ -0,0 +1,136 @@
+package httpCommand
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-http/command"
+	"net/http"
+)
+
+// Execute a JSON-RPC call, or a batch of calls. Result of a single call is decoded using the response builder of the
+// request; results and errors of a batch are returned in GoxResponse.JsonRpcResults (matched by id)
+func (h *HttpCommand) internalExecuteJsonRpc(ctx context.Context, request *command.GoxRequest) (*command.GoxResponse, error) {
+	batch := len(request.JsonRpcBatch) > 0
+	calls := request.JsonRpcBatch
+	if !batch {
+		calls = []*command.JsonRpcCall{request.JsonRpc}
+	}
+
+	body, ids, err := command.BuildJsonRpcBody(calls, batch)
+	if err != nil {
+		return nil, &command.GoxHttpError{
+			Err:        err,
+			StatusCode: http.StatusBadRequest,
+			Message:    "failed to build jsonrpc request",
+			ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+		}
+	}
+
+	response, err := h.internalExecute(ctx, envelopeRequest(request, body, command.ContentTypeJson, command.ContentTypeJson))
+	if response == nil {
+		return response, err
+	}
+
+	// Error response (non 2xx) can also have the jsonrpc error object
+	if err != nil {
+		var goxErr *command.GoxHttpError
+		if errors.As(err, &goxErr) && goxErr.DecodedBody == nil {
+			if responses, decodeErr := command.DecodeJsonRpcResponse(response.Body); decodeErr == nil && len(responses) == 1 && responses[0].Error != nil {
+				goxErr.DecodedBody = responses[0].Error
+			}
+		}
+		return response, err
+	}
+
+	// Server does not respond to notifications
+	if len(response.Body) == 0 {
+		return response, nil
+	}
+
+	responses, decodeErr := command.DecodeJsonRpcResponse(response.Body)
+	if decodeErr != nil {
+		response.Err = &command.GoxHttpError{
+			Err:        decodeErr,
+			StatusCode: response.StatusCode,
+			Message:    "failed to decode jsonrpc response",
+			ErrorCode:  "failed_to_build_response_using_response_builder",
+			Body:       response.Body,
+		}
+		return response, response.Err
+	}
+
+	if batch {
+		response.Err = h.processJsonRpcBatchResponse(calls, ids, response, responses)
+	} else {
+		response.Err = h.processJsonRpcResponse(request, response, responses[0])
+	}
+	return response, response.Err
+}
+
+func (h *HttpCommand) processJsonRpcResponse(request *command.GoxRequest, response *command.GoxResponse, rpcResponse *command.JsonRpcResponse) error {
+	if rpcResponse.Error != nil {
+		return command.NewJsonRpcError(response.StatusCode, rpcResponse.Error, response.Body)
+	}
+	if responseBuilder := request.GetResponseBuilder(response.Header); responseBuilder != nil && len(rpcResponse.Result) > 0 {
+		var err error
+		if response.Response, err = responseBuilder.Response(rpcResponse.Result); err != nil {
+			return &command.GoxHttpError{
+				Err:        errors.Wrap(err, "failed to create response using response builder"),
+				StatusCode: response.StatusCode,
+				Message:    "failed to create response using response builder",
+				ErrorCode:  "failed_to_build_response_using_response_builder",
+				Body:       response.Body,
+			}
+		}
+	}
+	return nil
+}
+
+// Set result or error of each call of the batch on the response. If server could not read the batch at all, it sends a
+// single error - this is returned as the error of the whole call
+func (h *HttpCommand) processJsonRpcBatchResponse(calls []*command.JsonRpcCall, ids []interface{}, response *command.GoxResponse, rpcResponses []*command.JsonRpcResponse) error {
+	byId := map[string]*command.JsonRpcResponse{}
+	for _, rpcResponse := range rpcResponses {
+		if len(rpcResponse.Id) == 0 || string(rpcResponse.Id) == "null" {
+			if rpcResponse.Error != nil {
+				return command.NewJsonRpcError(response.StatusCode, rpcResponse.Error, response.Body)
+			}
+			continue
+		}
+		byId[command.JsonRpcIdKey(rpcResponse.Id)] = rpcResponse
+	}
+
+	response.JsonRpcResults = make([]command.JsonRpcResult, len(calls))
+	for i, call := range calls {
+		result := &response.JsonRpcResults[i]
+		result.Method, result.Id = call.Method, ids[i]
+		if call.Notification {
+			continue
+		}
+		rpcResponse, ok := byId[command.JsonRpcIdKey(ids[i])]
+		if !ok {
+			result.Err = command.NewJsonRpcError(response.StatusCode, &command.JsonRpcError{
+				Code:    command.JsonRpcCodeInternalError,
+				Message: "no response for call in batch",
+			}, nil)
+			continue
+		}
+		if rpcResponse.Error != nil {
+			result.Err = command.NewJsonRpcError(response.StatusCode, rpcResponse.Error, nil)
+			continue
+		}
+		result.RawResult = rpcResponse.Result
+		if call.Result != nil && len(rpcResponse.Result) > 0 {
+			if _, err := command.NewCodecResponseBuilder(command.ContentTypeJson, call.Result).Response(rpcResponse.Result); err != nil {
+				result.Err = &command.GoxHttpError{
+					Err:        errors.Wrap(err, "failed to decode jsonrpc result"),
+					StatusCode: response.StatusCode,
+					Message:    "failed to decode jsonrpc result",
+					ErrorCode:  "failed_to_build_response_using_response_builder",
+					Body:       rpcResponse.Result,
+				}
+			}
+		}
+	}
+	return nil
+}
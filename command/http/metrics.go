@@ -0,0 +1,197 @@
+package httpCommand
+
+import (
+	"context"
+	"errors"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/metrics"
+	"github.com/devlibx/gox-http/command"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// httpMetrics emits request metrics of an api (only if EnableGoxHttpMetricLogging is true). All metrics are tagged with
+// server, api and method - request and attempt metrics also have status_class and error tags
+type httpMetrics struct {
+	gox.CrossFunction
+	tags                map[string]string
+	latencyBuckets      metrics.Buckets
+	responseSizeBuckets metrics.Buckets
+	inFlight            int64
+}
+
+func newHttpMetrics(cf gox.CrossFunction, server *command.Server, api *command.Api) (*httpMetrics, error) {
+	latencyBuckets, err := api.GetLatencyBuckets()
+	if err != nil {
+		return nil, err
+	}
+	responseSizeBuckets, err := api.GetResponseSizeBuckets()
+	if err != nil {
+		return nil, err
+	}
+	return &httpMetrics{
+		CrossFunction:       cf,
+		tags:                map[string]string{"server": server.Name, "api": api.Name, "method": strings.ToUpper(api.Method)},
+		latencyBuckets:      latencyBuckets,
+		responseSizeBuckets: responseSizeBuckets,
+	}, nil
+}
+
+func (m *httpMetrics) scope(statusCode int, errorCode string) metrics.Scope {
+	tags := map[string]string{"status_class": command.StatusClass(statusCode), "error": errorCode}
+	for name, value := range m.tags {
+		tags[name] = value
+	}
+	return m.Metric().Tagged(tags)
+}
+
+// Called when a request starts - returns the func to call when request is done
+func (m *httpMetrics) requestStarted() func(response *command.GoxResponse, err error) {
+	if !EnableGoxHttpMetricLogging {
+		return func(response *command.GoxResponse, err error) {}
+	}
+
+	start := time.Now()
+	m.Metric().Tagged(m.tags).Gauge("gox_http_in_flight").Update(float64(atomic.AddInt64(&m.inFlight, 1)))
+	return func(response *command.GoxResponse, err error) {
+		duration := time.Since(start)
+		m.Metric().Tagged(m.tags).Gauge("gox_http_in_flight").Update(float64(atomic.AddInt64(&m.inFlight, -1)))
+
+		statusCode, errorCode := requestOutcome(response, err)
+		scope := m.scope(statusCode, errorCode)
+		scope.Histogram("gox_http_request_duration", m.latencyBuckets).RecordDuration(duration)
+		if response != nil && response.BodyStream == nil {
+			scope.Histogram("gox_http_response_size", m.responseSizeBuckets).RecordValue(float64(len(response.Body)))
+		}
+	}
+}
+
+// Status code and error code of a request - error code is "none" if there is no error
+func requestOutcome(response *command.GoxResponse, err error) (int, string) {
+	statusCode, errorCode := 0, "none"
+	if response != nil {
+		statusCode = response.StatusCode
+	}
+	if err != nil {
+		errorCode = "unknown"
+		var goxErr *command.GoxHttpError
+		if errors.As(err, &goxErr) {
+			errorCode = goxErr.ErrorCode
+			if statusCode == 0 {
+				statusCode = goxErr.StatusCode
+			}
+		}
+	}
+	return statusCode, errorCode
+}
+
+// Called after every attempt (first call and retries) to the server
+func (m *httpMetrics) attemptDone(duration time.Duration, response *http.Response, err error) {
+	if !EnableGoxHttpMetricLogging {
+		return
+	}
+	statusCode, errorCode := 0, "none"
+	if response != nil {
+		statusCode = response.StatusCode
+	}
+	if err != nil {
+		errorCode = "request_failed_on_client"
+		if errors.Is(err, context.DeadlineExceeded) {
+			errorCode = "request_timeout_on_client"
+		}
+	}
+	m.scope(statusCode, errorCode).Histogram("gox_http_attempt_duration", m.latencyBuckets).RecordDuration(duration)
+}
+
+// Called with number of retries done for a request
+func (m *httpMetrics) retried(retries int) {
+	if EnableGoxHttpMetricLogging && retries > 0 {
+		m.Metric().Tagged(m.tags).Counter("gox_http_retry").Inc(int64(retries))
+	}
+}
+
+// Called with state of the circuit after a request
+func (m *httpMetrics) circuitState(open bool) {
+	if !EnableGoxHttpMetricLogging {
+		return
+	}
+	value := 0.0
+	if open {
+		value = 1
+	}
+	m.Metric().Tagged(m.tags).Gauge("gox_http_circuit_open").Update(value)
+}
+
+// callStats are collected for a single call by the transport - kept in context of the call
+type callStats struct {
+	attempts     int32
+	requestBytes int64
+
+	// Request body is captured up to these many bytes (0 = body is not captured) - it is captured when the request is
+	// built, so that it is logged before it is compressed
+	maxBodyBytes int
+	requestBody  []byte
+}
+
+type callStatsKey struct{}
+
+func withCallStats(ctx context.Context) (context.Context, *callStats) {
+	stats := &callStats{}
+	return context.WithValue(ctx, callStatsKey{}, stats), stats
+}
+
+// Stats of the call - nil if context does not have stats
+func callStatsFromContext(ctx context.Context) *callStats {
+	stats, _ := ctx.Value(callStatsKey{}).(*callStats)
+	return stats
+}
+
+// Called before every attempt is sent to the server
+func (s *callStats) attemptStarted(req *http.Request) {
+	if s == nil {
+		return
+	}
+	atomic.AddInt32(&s.attempts, 1)
+	if req.ContentLength > 0 {
+		s.requestBytes = req.ContentLength
+	}
+}
+
+// Called when body of the request is built (before it is compressed)
+func (s *callStats) requestBodyBuilt(body []byte) {
+	if s == nil || s.maxBodyBytes <= 0 {
+		return
+	}
+	s.requestBody = truncateBody(body, s.maxBodyBytes)
+}
+
+// Number of attempts sent to the server till now
+func (s *callStats) attemptCount() int {
+	if s == nil {
+		return 0
+	}
+	return int(atomic.LoadInt32(&s.attempts))
+}
+
+// metricsTransport records duration of every attempt sent to the server, and updates stats of the call
+type metricsTransport struct {
+	base    http.RoundTripper
+	metrics *httpMetrics
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	callStatsFromContext(req.Context()).attemptStarted(req)
+	start := time.Now()
+	response, err := t.base.RoundTrip(req)
+	t.metrics.attemptDone(time.Since(start), response, err)
+	return response, err
+}
+
+func newMetricsTransport(m *httpMetrics, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &metricsTransport{base: base, metrics: m}
+}
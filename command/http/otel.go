@@ -0,0 +1,152 @@
+package httpCommand
+
+import (
+	"context"
+	"errors"
+	"github.com/devlibx/gox-http/command"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+)
+
+// EnableOpenTelemetryTracing turns on OpenTelemetry spans - a client span for every call, and a child span for every
+// attempt (first call and retries) sent to the server. Span context of the attempt is sent to the server using
+// OpenTelemetryPropagator
+var EnableOpenTelemetryTracing = false
+
+// OpenTelemetryTracerProvider is used to create spans - global provider (otel.GetTracerProvider) is used if it is nil
+var OpenTelemetryTracerProvider trace.TracerProvider
+
+// OpenTelemetryPropagator injects span context in the outgoing request - W3C "traceparent" header by default
+var OpenTelemetryPropagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+const openTelemetryInstrumentationName = "github.com/devlibx/gox-http"
+
+// Attributes added by us - other attributes are from OpenTelemetry semantic conventions
+const (
+	otelServerKey     = attribute.Key("gox_http.server")
+	otelApiKey        = attribute.Key("gox_http.api")
+	otelErrorCodeKey  = attribute.Key("gox_http.error_code")
+	otelRetryCountKey = attribute.Key("gox_http.retry_count")
+	otelAttemptKey    = attribute.Key("gox_http.attempt")
+)
+
+// httpTracing creates OpenTelemetry spans of an api (only if EnableOpenTelemetryTracing is true)
+type httpTracing struct {
+	name       string
+	attributes []attribute.KeyValue
+}
+
+// Client span of a call is kept in context so that attempt spans can update it
+type otelCallSpanKey struct{}
+
+func newHttpTracing(server *command.Server, api *command.Api) *httpTracing {
+	return &httpTracing{
+		name: api.Name,
+		attributes: []attribute.KeyValue{
+			semconv.HTTPMethodKey.String(strings.ToUpper(api.Method)),
+			otelServerKey.String(server.Name),
+			otelApiKey.String(api.Name),
+		},
+	}
+}
+
+func openTelemetryTracer() trace.Tracer {
+	provider := OpenTelemetryTracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(openTelemetryInstrumentationName)
+}
+
+// Called when a request starts - returns the context with client span, and the func to call when request is done
+func (t *httpTracing) requestStarted(ctx context.Context) (context.Context, func(response *command.GoxResponse, err error)) {
+	if !EnableOpenTelemetryTracing {
+		return ctx, func(response *command.GoxResponse, err error) {}
+	}
+
+	ctx, span := openTelemetryTracer().Start(ctx, t.name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(t.attributes...),
+	)
+	stats := callStatsFromContext(ctx)
+	ctx = context.WithValue(ctx, otelCallSpanKey{}, span)
+
+	return ctx, func(response *command.GoxResponse, err error) {
+		defer span.End()
+
+		statusCode, errorCode := requestOutcome(response, err)
+		if statusCode > 0 {
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(statusCode))
+		}
+		if retries := stats.attemptCount() - 1; retries > 0 {
+			span.SetAttributes(otelRetryCountKey.Int(retries))
+		}
+		if err != nil {
+			message := err.Error()
+			var goxErr *command.GoxHttpError
+			if errors.As(err, &goxErr) && goxErr.Message != "" {
+				message = goxErr.Message
+			}
+			span.SetAttributes(otelErrorCodeKey.String(errorCode))
+			span.RecordError(err, trace.WithAttributes(otelErrorCodeKey.String(errorCode)))
+			span.SetStatus(codes.Error, message)
+		}
+	}
+}
+
+// tracingTransport creates a span for every attempt sent to the server, and sends its context to the server
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !EnableOpenTelemetryTracing {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	attributes := semconv.HTTPClientAttributesFromHTTPRequest(req)
+	if stats := callStatsFromContext(ctx); stats != nil {
+		attributes = append(attributes, otelAttemptKey.Int(stats.attemptCount()))
+	}
+	if call, ok := ctx.Value(otelCallSpanKey{}).(trace.Span); ok {
+		for _, a := range attributes {
+			if a.Key == semconv.HTTPURLKey {
+				call.SetAttributes(a)
+			}
+		}
+	}
+
+	ctx, span := openTelemetryTracer().Start(ctx, "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+	defer span.End()
+
+	// Request must not be changed by a transport - headers are set on a copy
+	req = req.Clone(ctx)
+	OpenTelemetryPropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	response, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return response, err
+	}
+	span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(response.StatusCode)...)
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(response.StatusCode, trace.SpanKindClient))
+	return response, err
+}
+
+func newTracingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tracingTransport{base: base}
+}
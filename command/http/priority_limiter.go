@@ -0,0 +1,169 @@
+package httpCommand
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-http/command"
+	"net/http"
+	"sync"
+)
+
+var errRequestShed = errors.New("request shed due to load")
+
+// priorityLimiter admits requests in priority order. It keeps a share of the concurrency for high priority requests
+// and, when the wait queue is full, drops the lowest priority waiting request to make room for a higher priority one.
+type priorityLimiter struct {
+	lock      *sync.Mutex
+	capacity  int
+	reserved  int
+	queueSize int
+	inFlight  int
+	waiters   []*priorityWaiter
+}
+
+type priorityWaiter struct {
+	priority command.Priority
+	ready    chan error
+}
+
+// Wait till this request is allowed to run. A nil error means caller must call release() once done
+func (l *priorityLimiter) acquire(ctx context.Context, priority command.Priority) error {
+	l.lock.Lock()
+
+	// Run immediately if we have a free slot for this priority and no one with same or higher priority is waiting
+	if l.canRun(priority) && !l.hasWaiterWithPriorityAtLeast(priority) {
+		l.inFlight++
+		l.lock.Unlock()
+		return nil
+	}
+
+	// Queue is full - evict the lowest priority waiter if this request is more important, otherwise shed this one
+	if len(l.waiters) >= l.queueSize {
+		idx := l.lowestPriorityWaiter()
+		if idx < 0 || l.waiters[idx].priority >= priority {
+			l.lock.Unlock()
+			return newRequestShedError()
+		}
+		l.waiters[idx].ready <- newRequestShedError()
+		l.waiters = append(l.waiters[:idx], l.waiters[idx+1:]...)
+	}
+
+	w := &priorityWaiter{priority: priority, ready: make(chan error, 1)}
+	l.waiters = append(l.waiters, w)
+	l.lock.Unlock()
+
+	select {
+	case err := <-w.ready:
+		return err
+	case <-ctx.Done():
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		for i, _w := range l.waiters {
+			if _w == w {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				return newQueueTimeoutError(ctx.Err())
+			}
+		}
+
+		// We were already granted (or shed) before we could remove ourselves
+		if err := <-w.ready; err != nil {
+			return err
+		}
+		l.inFlight--
+		l.dispatch()
+		return newQueueTimeoutError(ctx.Err())
+	}
+}
+
+// Release a slot taken by acquire() and hand it over to the best waiting request
+func (l *priorityLimiter) release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.inFlight--
+	l.dispatch()
+}
+
+// Must be called with lock held
+func (l *priorityLimiter) dispatch() {
+	for len(l.waiters) > 0 {
+		idx := l.highestPriorityWaiter()
+		if !l.canRun(l.waiters[idx].priority) {
+			return
+		}
+		l.inFlight++
+		l.waiters[idx].ready <- nil
+		l.waiters = append(l.waiters[:idx], l.waiters[idx+1:]...)
+	}
+}
+
+func (l *priorityLimiter) canRun(priority command.Priority) bool {
+	if priority >= command.PriorityHigh {
+		return l.inFlight < l.capacity
+	}
+	return l.inFlight < l.capacity-l.reserved
+}
+
+func (l *priorityLimiter) hasWaiterWithPriorityAtLeast(priority command.Priority) bool {
+	for _, w := range l.waiters {
+		if w.priority >= priority {
+			return true
+		}
+	}
+	return false
+}
+
+// Oldest waiter with the highest priority
+func (l *priorityLimiter) highestPriorityWaiter() int {
+	idx := -1
+	for i, w := range l.waiters {
+		if idx < 0 || w.priority > l.waiters[idx].priority {
+			idx = i
+		}
+	}
+	return idx
+}
+
+// Newest waiter with the lowest priority
+func (l *priorityLimiter) lowestPriorityWaiter() int {
+	idx := -1
+	for i, w := range l.waiters {
+		if idx < 0 || w.priority <= l.waiters[idx].priority {
+			idx = i
+		}
+	}
+	return idx
+}
+
+func newRequestShedError() error {
+	return &command.GoxHttpError{
+		Err:        errRequestShed,
+		StatusCode: http.StatusBadRequest,
+		Message:    "request shed due to load",
+		ErrorCode:  command.ErrorCodeRequestShed,
+		Body:       nil,
+	}
+}
+
+func newQueueTimeoutError(err error) error {
+	return &command.GoxHttpError{
+		Err:        err,
+		StatusCode: http.StatusRequestTimeout,
+		Message:    "request timeout while waiting in queue",
+		ErrorCode:  "request_timeout_on_client",
+		Body:       nil,
+	}
+}
+
+// Returns nil if priority based admission is not enabled for this api
+func newPriorityLimiter(api *command.Api) *priorityLimiter {
+	if api.HighPriorityConcurrencyPercent <= 0 {
+		return nil
+	}
+	return &priorityLimiter{
+		lock:      &sync.Mutex{},
+		capacity:  api.Concurrency,
+		reserved:  api.GetHighPriorityReservedConcurrency(),
+		queueSize: api.QueueSize,
+		waiters:   make([]*priorityWaiter, 0),
+	}
+}
@@ -0,0 +1,87 @@
+package httpCommand
+
+import (
+	"context"
+	"github.com/devlibx/gox-http/command"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPriorityLimiter_ReservedSlotIsOnlyForHighPriority(t *testing.T) {
+	l := newPriorityLimiter(&command.Api{Concurrency: 2, QueueSize: 0, HighPriorityConcurrencyPercent: 50})
+	ctx := context.Background()
+
+	assert.NoError(t, l.acquire(ctx, command.PriorityNormal))
+
+	// Normal request can not take the reserved slot and there is no queue
+	err := l.acquire(ctx, command.PriorityNormal)
+	assert.Error(t, err)
+	assert.True(t, err.(*command.GoxHttpError).IsRequestShedError())
+
+	// High priority request gets the reserved slot
+	assert.NoError(t, l.acquire(ctx, command.PriorityHigh))
+}
+
+func TestPriorityLimiter_ReservedSlotsLeaveOneSlotForOtherRequests(t *testing.T) {
+	ctx := context.Background()
+
+	// Nothing is reserved with a single slot
+	l := newPriorityLimiter(&command.Api{Concurrency: 1, QueueSize: 0, HighPriorityConcurrencyPercent: 100})
+	assert.NoError(t, l.acquire(ctx, command.PriorityNormal))
+
+	// 100% reserves all but one slot
+	l = newPriorityLimiter(&command.Api{Concurrency: 4, QueueSize: 0, HighPriorityConcurrencyPercent: 100})
+	assert.Equal(t, 3, l.reserved)
+	assert.NoError(t, l.acquire(ctx, command.PriorityLow))
+	err := l.acquire(ctx, command.PriorityLow)
+	assert.Error(t, err)
+	assert.True(t, err.(*command.GoxHttpError).IsRequestShedError())
+}
+
+func TestPriorityLimiter_LowPriorityIsShedFirstWhenQueueIsFull(t *testing.T) {
+	l := newPriorityLimiter(&command.Api{Concurrency: 2, QueueSize: 1, HighPriorityConcurrencyPercent: 50})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// All slots are taken by high priority requests
+	assert.NoError(t, l.acquire(ctx, command.PriorityHigh))
+	assert.NoError(t, l.acquire(ctx, command.PriorityHigh))
+
+	lowResult := make(chan error, 1)
+	go func() { lowResult <- l.acquire(ctx, command.PriorityLow) }()
+	time.Sleep(20 * time.Millisecond)
+
+	// High priority request will evict low priority request from queue
+	highResult := make(chan error, 1)
+	go func() { highResult <- l.acquire(ctx, command.PriorityHigh) }()
+
+	err := <-lowResult
+	assert.Error(t, err)
+	assert.True(t, err.(*command.GoxHttpError).IsRequestShedError())
+
+	// Normal request can not evict high priority request
+	err = l.acquire(ctx, command.PriorityNormal)
+	assert.Error(t, err)
+	assert.True(t, err.(*command.GoxHttpError).IsRequestShedError())
+
+	// Queued high priority request runs once the slot is released
+	l.release()
+	assert.NoError(t, <-highResult)
+}
+
+func TestPriorityLimiter_QueuedRequestTimeout(t *testing.T) {
+	l := newPriorityLimiter(&command.Api{Concurrency: 2, QueueSize: 1, HighPriorityConcurrencyPercent: 50})
+	assert.NoError(t, l.acquire(context.Background(), command.PriorityHigh))
+	assert.NoError(t, l.acquire(context.Background(), command.PriorityHigh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := l.acquire(ctx, command.PriorityHigh)
+	assert.Error(t, err)
+	assert.Equal(t, "request_timeout_on_client", err.(*command.GoxHttpError).ErrorCode)
+
+	// Slot must be usable after the timed out waiter is gone
+	l.release()
+	assert.NoError(t, l.acquire(context.Background(), command.PriorityHigh))
+}
@@ -0,0 +1,81 @@
+package httpCommand
+
+import (
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-http/command"
+	"io"
+	"net/http"
+)
+
+// limitingTransport fails the response if its (decoded) body is larger than "max_response_bytes" of the api
+type limitingTransport struct {
+	base     http.RoundTripper
+	maxBytes int64
+}
+
+func (l *limitingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	response, err := l.base.RoundTrip(req)
+	if err != nil || response.Body == nil || response.Body == http.NoBody {
+		return response, err
+	}
+
+	// No need to read the body if server already told us it is too large
+	if response.ContentLength > l.maxBytes {
+		_ = response.Body.Close()
+		return nil, responseTooLargeError(l.maxBytes)
+	}
+
+	response.Body = &limitedBody{body: response.Body, remaining: l.maxBytes, maxBytes: l.maxBytes}
+	return response, nil
+}
+
+// limitedBody gives an error (instead of io.EOF) once more than maxBytes are read
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	maxBytes  int64
+	exceeded  bool
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, responseTooLargeError(l.maxBytes)
+	}
+
+	// Read one extra byte to know if the body is larger than the limit
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.body.Read(p)
+	if int64(n) > l.remaining {
+		n = int(l.remaining)
+		l.remaining = 0
+		l.exceeded = true
+		return n, responseTooLargeError(l.maxBytes)
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+func (l *limitedBody) Close() error {
+	return l.body.Close()
+}
+
+func responseTooLargeError(maxBytes int64) *command.GoxHttpError {
+	return &command.GoxHttpError{
+		Err:        errors.New("response body is larger than %d bytes", maxBytes),
+		StatusCode: http.StatusInternalServerError,
+		Message:    "response body exceeds max_response_bytes",
+		ErrorCode:  command.ErrorCodeResponseTooLarge,
+	}
+}
+
+func newLimitingTransport(api *command.Api, base http.RoundTripper) http.RoundTripper {
+	if api.MaxResponseBytes <= 0 {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &limitingTransport{base: base, maxBytes: int64(api.MaxResponseBytes)}
+}
@@ -0,0 +1,39 @@
+package httpCommand
+
+import (
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-http/command"
+	"net/http"
+)
+
+// signingTransport signs every attempt (including retries) sent to the server. It is the innermost transport, so
+// headers set by other transports (e.g. Accept-Encoding, traceparent, conditional cache headers) can be signed
+type signingTransport struct {
+	base   http.RoundTripper
+	signer command.Signer
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	// Request must not be changed by a transport - it is signed on a copy
+	req = req.Clone(req.Context())
+	body, err := command.ReadBodyForSigning(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read body to sign request")
+	}
+	if err = t.signer.Sign(req, body); err != nil {
+		return nil, errors.Wrap(err, "failed to sign request")
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Returns base transport as it is if signing is not enabled
+func newSigningTransport(signer command.Signer, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if signer == nil {
+		return base
+	}
+	return &signingTransport{base: base, signer: signer}
+}
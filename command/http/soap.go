@@ -0,0 +1,92 @@
+package httpCommand
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-http/command"
+	"net/http"
+)
+
+// Execute a SOAP call - body of the request is wrapped in an envelope, and content of the response body is decoded
+// using the response builder of the request. A Fault is returned as GoxHttpError with SoapFault
+func (h *HttpCommand) internalExecuteSoap(ctx context.Context, request *command.GoxRequest) (*command.GoxResponse, error) {
+	namespace, action := "", request.SoapAction
+	if h.api.Soap != nil {
+		namespace = h.api.Soap.Namespace
+		if action == "" {
+			action = h.api.Soap.Action
+		}
+	}
+
+	body, err := command.BuildSoapEnvelope(h.soapVersion, namespace, request.SoapHeader, request.Body)
+	if err != nil {
+		return nil, &command.GoxHttpError{
+			Err:        err,
+			StatusCode: http.StatusBadRequest,
+			Message:    "failed to build soap envelope",
+			ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+		}
+	}
+
+	contentType := command.SoapContentType(h.soapVersion, action)
+	accept := command.ContentTypeTextXml
+	if h.soapVersion == command.SoapVersion12 {
+		accept = command.ContentTypeSoap12
+	}
+	httpRequest := envelopeRequest(request, body, contentType, accept)
+	if h.soapVersion == command.SoapVersion11 && httpRequest.Header.Get("SOAPAction") == "" {
+		httpRequest.Header.Set("SOAPAction", `"`+action+`"`)
+	}
+
+	response, err := h.internalExecute(ctx, httpRequest)
+	if response == nil || len(response.Body) == 0 {
+		return response, err
+	}
+
+	content, fault, decodeErr := command.DecodeSoapResponse(response.Body)
+
+	// Servers send a Fault with an error status (500 in SOAP 1.1)
+	if fault != nil {
+		response.Err = &command.GoxHttpError{
+			Err:         fault,
+			StatusCode:  response.StatusCode,
+			Message:     "soap server returned fault: code=" + fault.Code + ", string=" + fault.String,
+			ErrorCode:   command.ErrorCodeSoapFault,
+			Body:        response.Body,
+			DecodedBody: fault,
+		}
+		return response, response.Err
+	}
+	if err != nil {
+		return response, err
+	}
+	if decodeErr != nil {
+		response.Err = &command.GoxHttpError{
+			Err:        decodeErr,
+			StatusCode: response.StatusCode,
+			Message:    "failed to decode soap response",
+			ErrorCode:  "failed_to_build_response_using_response_builder",
+			Body:       response.Body,
+		}
+		return response, response.Err
+	}
+
+	// Body content is always xml - it does not depend on content type of the response
+	responseBuilder := request.ResponseBuilder
+	if responseBuilder == nil && request.ResponseTarget != nil {
+		responseBuilder = command.NewCodecResponseBuilder(command.ContentTypeXml, request.ResponseTarget)
+	}
+	if responseBuilder != nil && len(content) > 0 {
+		if response.Response, err = responseBuilder.Response(content); err != nil {
+			response.Err = &command.GoxHttpError{
+				Err:        errors.Wrap(err, "failed to create response using response builder"),
+				StatusCode: response.StatusCode,
+				Message:    "failed to create response using response builder",
+				ErrorCode:  "failed_to_build_response_using_response_builder",
+				Body:       response.Body,
+			}
+			return response, response.Err
+		}
+	}
+	return response, nil
+}
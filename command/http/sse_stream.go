@@ -0,0 +1,258 @@
+package httpCommand
+
+import (
+	"context"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-http/command"
+	"go.uber.org/zap"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sseStream reads server-sent events. Every connect (and reconnect) is executed using the given handler, so it goes
+// through interceptors, circuit breaker, auth and headers configured for the api
+type sseStream struct {
+	gox.CrossFunction
+	logger     *zap.Logger
+	serverName string
+	api        *command.Api
+	request    *command.GoxRequest
+	handler    command.Handler
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	closed bool
+	lock   *sync.Mutex
+
+	body        io.ReadCloser
+	reader      *command.SseReader
+	connectedAt time.Time
+	lastEventId string
+	retry       time.Duration
+	err         error
+
+	// Reconnects done since the last event - stream fails if it is more than "max_reconnects" (0 = no limit)
+	reconnects    int
+	maxReconnects int
+}
+
+func (s *sseStream) Next() (*command.SseEvent, error) {
+	for {
+		if s.err != nil {
+			return nil, s.err
+		}
+		if s.reader == nil {
+			if s.err = s.connect(); s.err != nil {
+				return nil, s.err
+			}
+		}
+
+		event, err := s.reader.Next()
+		if s.reader.Retry() > 0 {
+			s.retry = s.reader.Retry()
+		}
+		if err == nil {
+			s.lastEventId = s.reader.LastEventId()
+			s.reconnects = 0
+			if EnableGoxHttpMetricLogging {
+				s.Metric().Tagged(map[string]string{"server": s.serverName, "api": s.api.Name}).Counter("gox_http_sse_event").Inc(1)
+			}
+			return event, nil
+		}
+
+		// Connection is lost (or closed by server) - reconnect after retry time
+		s.lastEventId = s.reader.LastEventId()
+		s.disconnect()
+		if s.isClosed() {
+			s.err = io.EOF
+			continue
+		}
+		s.logger.Debug("sse connection lost, reconnecting", zap.String("last_event_id", s.lastEventId), zap.Error(err))
+		s.err = s.wait(err)
+	}
+}
+
+// Connect to server - keeps retrying till it connects, or gets an error which can't be retried
+func (s *sseStream) connect() error {
+	for {
+		response, err := s.handler(s.ctx, s.connectRequest())
+		if err == nil {
+
+			// Server asked us to stop reconnecting
+			if response.StatusCode == http.StatusNoContent {
+				if response.BodyStream != nil {
+					_ = response.BodyStream.Close()
+				}
+				return io.EOF
+			}
+
+			// Body of some other content (e.g. html error page of a proxy) is not read as events
+			if mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type")); mediaType != command.ContentTypeEventStream {
+				if response.BodyStream != nil {
+					_ = response.BodyStream.Close()
+				}
+				err = errors.New("expected content type %s, got %s", command.ContentTypeEventStream, response.Header.Get("Content-Type"))
+				return &command.GoxHttpError{
+					Err:        err,
+					StatusCode: response.StatusCode,
+					Message:    "server did not respond with an event stream",
+					ErrorCode:  "unexpected_content_type",
+				}
+			}
+			s.body = response.BodyStream
+			s.reader = command.NewSseReader(s.body)
+			s.connectedAt = time.Now()
+			return nil
+		}
+
+		if s.isClosed() {
+			return io.EOF
+		}
+		if !isSseRetryable(err) {
+			return err
+		}
+		s.logger.Debug("failed to connect sse stream, retrying", zap.Error(err))
+		if EnableGoxHttpMetricLogging {
+			s.Metric().Tagged(map[string]string{"server": s.serverName, "api": s.api.Name}).Counter("gox_http_sse_connect_error").Inc(1)
+		}
+		if err := s.wait(err); err != nil {
+			return err
+		}
+	}
+}
+
+// Request for a connect - with "Last-Event-ID" header if we have received an event id
+func (s *sseStream) connectRequest() *command.GoxRequest {
+	request := *s.request
+	request.Stream = true
+	request.Header = http.Header{}
+	for name, values := range s.request.Header {
+		request.Header[name] = append([]string{}, values...)
+	}
+	if request.Header.Get("Accept") == "" {
+		request.Header.Set("Accept", command.ContentTypeEventStream)
+	}
+	request.Header.Set("Cache-Control", "no-cache")
+	if s.lastEventId != "" {
+		request.Header.Set("Last-Event-ID", s.lastEventId)
+	}
+	return &request
+}
+
+func (s *sseStream) disconnect() {
+	if s.body == nil {
+		return
+	}
+	_ = s.body.Close()
+	if EnableGoxHttpMetricLogging {
+		s.Metric().Tagged(map[string]string{"server": s.serverName, "api": s.api.Name}).Timer("gox_http_sse_connected_time").Record(time.Since(s.connectedAt))
+	}
+	s.body = nil
+	s.reader = nil
+}
+
+// Wait for retry time before reconnecting - fails with the error which caused the reconnect if "max_reconnects" are
+// done without getting an event
+func (s *sseStream) wait(cause error) error {
+	if s.reconnects++; s.maxReconnects > 0 && s.reconnects > s.maxReconnects {
+		s.logger.Info("sse stream failed to reconnect", zap.Int("reconnects", s.maxReconnects), zap.Error(cause))
+		if goxErr, ok := cause.(*command.GoxHttpError); ok {
+			return goxErr
+		}
+		return &command.GoxHttpError{
+			Err:        cause,
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    "sse stream is lost, and max_reconnects are done without getting an event",
+			ErrorCode:  command.ErrorCodeFailedToRequestServer,
+		}
+	}
+
+	timer := time.NewTimer(s.retry)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-s.ctx.Done():
+		if s.isClosed() {
+			return io.EOF
+		}
+		return &command.GoxHttpError{
+			Err:        s.ctx.Err(),
+			StatusCode: http.StatusRequestTimeout,
+			Message:    "context is done while waiting to reconnect sse stream",
+			ErrorCode:  "request_timeout_on_client",
+		}
+	}
+}
+
+func (s *sseStream) LastEventId() string {
+	return s.lastEventId
+}
+
+func (s *sseStream) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	s.cancel()
+	return nil
+}
+
+func (s *sseStream) isClosed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.closed
+}
+
+// Error response from server (except timeout and too many requests) means our request is wrong - retry will not help
+func isSseRetryable(err error) bool {
+	goxErr, ok := err.(*command.GoxHttpError)
+	if !ok {
+		return true
+	}
+	switch goxErr.ErrorCode {
+	case command.ErrorCodeFailedToBuildRequest:
+		return false
+	case "server_response_with_error":
+		return !goxErr.Is4xx() || goxErr.StatusCode == http.StatusRequestTimeout || goxErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// NewSseStream connects to a server-sent events api. Returns error if first connect fails with an error which can't be
+// retried. The stream is closed when ctx is done
+func NewSseStream(ctx context.Context, cf gox.CrossFunction, server *command.Server, api *command.Api, request *command.GoxRequest, handler command.Handler) (command.SseStream, error) {
+	if request == nil {
+		request = &command.GoxRequest{}
+	}
+	retry, maxReconnects := command.DefaultSseRetry, 0
+	if api.Sse != nil && api.Sse.RetryMs > 0 {
+		retry = time.Duration(api.Sse.RetryMs) * time.Millisecond
+	}
+	if api.Sse != nil && api.Sse.MaxReconnects > 0 {
+		maxReconnects = api.Sse.MaxReconnects
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	s := &sseStream{
+		CrossFunction: cf,
+		logger:        cf.Logger().Named("goxHttp").Named(api.Name),
+		serverName:    server.Name,
+		api:           api,
+		request:       request,
+		handler:       handler,
+		ctx:           streamCtx,
+		cancel:        cancel,
+		lock:          &sync.Mutex{},
+		retry:         retry,
+		maxReconnects: maxReconnects,
+	}
+	if err := s.connect(); err != nil {
+		cancel()
+		return nil, err
+	}
+	return s, nil
+}
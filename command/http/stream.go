@@ -0,0 +1,135 @@
+package httpCommand
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-http/command"
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Execute a streaming request - response body is given to the caller as it is, without reading it.
+//
+// The body is read after this method returns, so api timeout only applies till response headers are received. The
+// body can be read as long as the context given by the caller is valid. Streaming requests are not retried.
+func (h *HttpCommand) internalExecuteStream(ctx context.Context, request *command.GoxRequest) (*command.GoxResponse, error) {
+	sp, ctxWithSpan := DefaultStartSpanFromContextFunc(ctx, h.api.Name)
+	defer sp.Finish()
+
+	h.logger.Debug("got streaming request to execute", zap.Stringer("request", request))
+
+	streamCtx, cancel := context.WithCancel(ctxWithSpan)
+	headerTimer := time.AfterFunc(time.Duration(h.api.Timeout)*time.Millisecond, cancel)
+
+	r, err := h.buildRequest(streamCtx, request, sp)
+	if err != nil {
+		headerTimer.Stop()
+		cancel()
+		return nil, err
+	}
+
+	finalUrlToRequest := r.URL
+	response, err := h.send(r, finalUrlToRequest)
+
+	// Server rejected our credentials - refresh them and retry once
+	if err == nil && response.StatusCode() == http.StatusUnauthorized && h.auth != nil && h.auth.invalidate(r.Header) {
+		h.logger.Debug("got 401 from server, retrying with refreshed credentials")
+		closeRawBody(response)
+		if r, err = h.buildRequest(streamCtx, request, sp); err != nil {
+			headerTimer.Stop()
+			cancel()
+			return nil, err
+		}
+		response, err = h.send(r, finalUrlToRequest)
+	}
+	h.metrics.retried(r.Attempt - 1)
+
+	// Headers did not come within api timeout
+	if !headerTimer.Stop() {
+		if err == nil {
+			closeRawBody(response)
+		}
+		cancel()
+		responseObject := &command.GoxResponse{
+			StatusCode: http.StatusRequestTimeout,
+			Err: &command.GoxHttpError{
+				Err:        errors.New("response headers not received in %d ms", h.api.Timeout),
+				StatusCode: http.StatusRequestTimeout,
+				Message:    "request timeout on client",
+				ErrorCode:  "request_timeout_on_client",
+			},
+		}
+		return responseObject, responseObject.Err
+	}
+
+	if err != nil {
+		cancel()
+		responseObject := h.handleError(err)
+		return responseObject, responseObject.Err
+	}
+
+	responseObject := h.processStreamResponse(response, cancel)
+	responseObject.Header = response.Header()
+	return responseObject, responseObject.Err
+}
+
+func (h *HttpCommand) processStreamResponse(response *resty.Response, cancel context.CancelFunc) *command.GoxResponse {
+	body := response.RawBody()
+
+	// Error response - read the body so it can be given in error (same as a non-streaming request)
+	if response.IsError() && !h.api.IsHttpCodeAcceptable(response.StatusCode()) {
+		defer cancel()
+		b, err := ioutil.ReadAll(body)
+		_ = body.Close()
+		if goxErr, ok := err.(*command.GoxHttpError); ok {
+			return &command.GoxResponse{StatusCode: goxErr.StatusCode, Err: goxErr}
+		}
+		return &command.GoxResponse{
+			Body:       b,
+			StatusCode: response.StatusCode(),
+			Err: &command.GoxHttpError{
+				Err:         errors.New("got response with server with error"),
+				StatusCode:  response.StatusCode(),
+				Message:     "got response from server with error",
+				ErrorCode:   "server_response_with_error",
+				Body:        b,
+				DecodedBody: h.decodeErrorBody(response.Header(), b),
+			},
+		}
+	}
+
+	return &command.GoxResponse{
+		StatusCode: response.StatusCode(),
+		BodyStream: &streamBody{body: body, cancel: cancel, once: &sync.Once{}},
+	}
+}
+
+// streamBody releases the request context when caller closes the body
+type streamBody struct {
+	body   io.ReadCloser
+	cancel context.CancelFunc
+	once   *sync.Once
+}
+
+func (s *streamBody) Read(p []byte) (int, error) {
+	return s.body.Read(p)
+}
+
+func (s *streamBody) Close() (err error) {
+	s.once.Do(func() {
+		err = s.body.Close()
+		s.cancel()
+	})
+	return err
+}
+
+func closeRawBody(response *resty.Response) {
+	if body := response.RawBody(); body != nil {
+		_ = body.Close()
+	}
+}
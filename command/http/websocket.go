@@ -0,0 +1,478 @@
+package httpCommand
+
+import (
+	"context"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-http/command"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketConnection is a WebSocket connection which reconnects (with backoff) when connection is lost. A read loop
+// runs for the current connection and puts messages in "incoming"; a ping is sent every ping interval and connection
+// is considered lost if nothing (pong or message) is received within ping interval + pong timeout
+type websocketConnection struct {
+	gox.CrossFunction
+	logger     *zap.Logger
+	serverName string
+	api        *command.Api
+	request    *command.GoxRequest
+	headers    *command.HeaderTemplate
+	auth       authProvider
+	dialer     *websocket.Dialer
+	url        string
+
+	pingInterval        time.Duration
+	pongTimeout         time.Duration
+	reconnectInitWait   time.Duration
+	reconnectMaxWait    time.Duration
+	maxReconnectAttempt int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// conn is nil while we are reconnecting - "connected" is closed when a new connection is available
+	lock      *sync.Mutex
+	conn      *websocket.Conn
+	connected chan struct{}
+	writeLock *sync.Mutex
+
+	incoming chan []byte
+	done     chan struct{}
+	err      error
+}
+
+func (c *websocketConnection) Send(ctx context.Context, data []byte) error {
+	for {
+		c.lock.Lock()
+		conn, connected := c.conn, c.connected
+		c.lock.Unlock()
+
+		if conn != nil {
+			c.writeLock.Lock()
+			_ = conn.SetWriteDeadline(c.writeDeadline(ctx))
+			err := conn.WriteMessage(websocket.TextMessage, data)
+			c.writeLock.Unlock()
+			if err == nil {
+				return nil
+			}
+
+			// Message may be partly sent, so it is not sent again (server could get it twice) - caller decides to send it
+			// again. Read loop will see the closed connection and reconnect
+			c.logger.Debug("failed to write websocket message", zap.Error(err))
+			c.dropConnection(conn)
+			if ctx.Err() != nil {
+				return &command.GoxHttpError{
+					Err:        ctx.Err(),
+					StatusCode: http.StatusRequestTimeout,
+					Message:    "context is done while sending websocket message",
+					ErrorCode:  "request_timeout_on_client",
+				}
+			}
+			return &command.GoxHttpError{
+				Err:        err,
+				StatusCode: http.StatusBadRequest,
+				Message:    "failed to send websocket message",
+				ErrorCode:  "request_failed_on_client",
+			}
+		}
+
+		select {
+		case <-connected:
+		case <-c.done:
+			return c.err
+		case <-ctx.Done():
+			return &command.GoxHttpError{
+				Err:        ctx.Err(),
+				StatusCode: http.StatusRequestTimeout,
+				Message:    "context is done while waiting for websocket connection",
+				ErrorCode:  "request_timeout_on_client",
+			}
+		}
+	}
+}
+
+// Write must finish before deadline of ctx - api timeout is used if ctx does not have a deadline
+func (c *websocketConnection) writeDeadline(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(time.Duration(c.api.Timeout) * time.Millisecond)
+}
+
+func (c *websocketConnection) SendJson(ctx context.Context, in interface{}) error {
+	data, err := serialization.Stringify(in)
+	if err != nil {
+		return &command.GoxHttpError{
+			Err:        err,
+			StatusCode: http.StatusBadRequest,
+			Message:    "failed to serialize websocket message",
+			ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+		}
+	}
+	return c.Send(ctx, []byte(data))
+}
+
+func (c *websocketConnection) Receive(ctx context.Context) ([]byte, error) {
+	select {
+	case data := <-c.incoming:
+		return data, nil
+	case <-c.done:
+
+		// Give messages which were received before close
+		select {
+		case data := <-c.incoming:
+			return data, nil
+		default:
+			return nil, c.err
+		}
+	case <-ctx.Done():
+		return nil, &command.GoxHttpError{
+			Err:        ctx.Err(),
+			StatusCode: http.StatusRequestTimeout,
+			Message:    "context is done while waiting for websocket message",
+			ErrorCode:  "request_timeout_on_client",
+		}
+	}
+}
+
+func (c *websocketConnection) ReceiveJson(ctx context.Context, out interface{}) error {
+	data, err := c.Receive(ctx)
+	if err != nil {
+		return err
+	}
+	if err := serialization.JsonBytesToObject(data, out); err != nil {
+		return &command.GoxHttpError{
+			Err:        err,
+			StatusCode: http.StatusUnprocessableEntity,
+			Message:    "failed to decode websocket message",
+			ErrorCode:  "failed_to_build_response_using_response_builder",
+			Body:       data,
+		}
+	}
+	return nil
+}
+
+func (c *websocketConnection) Close() error {
+	c.cancel()
+	<-c.done
+	return nil
+}
+
+// Serve current connection, and reconnect when it is lost. Runs till ctx is done or reconnect fails
+func (c *websocketConnection) run(conn *websocket.Conn) {
+	defer close(c.done)
+	for {
+		connectedAt := time.Now()
+		err := c.serve(conn)
+		c.dropConnection(conn)
+		if EnableGoxHttpMetricLogging {
+			c.Metric().Tagged(map[string]string{"server": c.serverName, "api": c.api.Name}).Timer("gox_http_websocket_connected_time").Record(time.Since(connectedAt))
+		}
+		if c.ctx.Err() != nil {
+			c.err = io.EOF
+			return
+		}
+
+		c.logger.Debug("websocket connection lost, reconnecting", zap.Error(err))
+		if conn, err = c.reconnect(); err != nil {
+			c.err = err
+			return
+		}
+	}
+}
+
+// Read messages from the connection till it fails. Also sends pings, and closes the connection when ctx is done
+func (c *websocketConnection) serve(conn *websocket.Conn) error {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	heartbeat := c.pingInterval > 0
+	extendReadDeadline := func() {
+		if heartbeat {
+			_ = conn.SetReadDeadline(time.Now().Add(c.pingInterval + c.pongTimeout))
+		}
+	}
+	extendReadDeadline()
+	conn.SetPongHandler(func(string) error {
+		extendReadDeadline()
+		return nil
+	})
+
+	go func() {
+		var ticks <-chan time.Time
+		if heartbeat {
+			ticker := time.NewTicker(c.pingInterval)
+			defer ticker.Stop()
+			ticks = ticker.C
+		}
+		for {
+			select {
+			case <-stop:
+				return
+			case <-c.ctx.Done():
+				c.writeLock.Lock()
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+				c.writeLock.Unlock()
+				_ = conn.Close()
+				return
+			case <-ticks:
+				c.writeLock.Lock()
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.pongTimeout))
+				c.writeLock.Unlock()
+				if err != nil {
+					_ = conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	// If "incoming" is full, reading waits till the caller receives a message (server is slowed down, messages are not
+	// dropped). Pongs are not read while waiting - so deadline is extended after the message is delivered, and time
+	// spent waiting for the caller does not make the connection lost
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		select {
+		case c.incoming <- data:
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		}
+		extendReadDeadline()
+	}
+}
+
+// Mark connection as lost (if it is the current connection) so writers wait for the next connection
+func (c *websocketConnection) dropConnection(conn *websocket.Conn) {
+	_ = conn.Close()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.conn == conn {
+		c.conn = nil
+		c.connected = make(chan struct{})
+	}
+}
+
+// Connect again with exponential backoff. Fails if error can't be retried or "max_reconnect_attempts" are done
+func (c *websocketConnection) reconnect() (*websocket.Conn, error) {
+	wait := c.reconnectInitWait
+	for attempt := 1; ; attempt++ {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-c.ctx.Done():
+			timer.Stop()
+			return nil, io.EOF
+		}
+
+		conn, err := c.dial()
+		if err == nil {
+			c.setConnection(conn)
+			return conn, nil
+		}
+		if c.ctx.Err() != nil {
+			return nil, io.EOF
+		}
+		if EnableGoxHttpMetricLogging {
+			c.Metric().Tagged(map[string]string{"server": c.serverName, "api": c.api.Name}).Counter("gox_http_websocket_connect_error").Inc(1)
+		}
+		if !isSseRetryable(err) || (c.maxReconnectAttempt > 0 && attempt >= c.maxReconnectAttempt) {
+			c.logger.Info("failed to reconnect websocket", zap.Int("attempts", attempt), zap.Error(err))
+			return nil, err
+		}
+		c.logger.Debug("failed to reconnect websocket, retrying", zap.Int("attempt", attempt), zap.Error(err))
+
+		if wait *= 2; wait > c.reconnectMaxWait {
+			wait = c.reconnectMaxWait
+		}
+	}
+}
+
+func (c *websocketConnection) setConnection(conn *websocket.Conn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.conn = conn
+	close(c.connected)
+}
+
+// Open a connection with default headers, request headers and auth of the api. Credentials are refreshed and
+// handshake is tried again once if server responds with 401
+func (c *websocketConnection) dial() (*websocket.Conn, error) {
+	header := http.Header{}
+	conn, err := c.dialOnce(header)
+	if goxErr, ok := err.(*command.GoxHttpError); ok && goxErr.StatusCode == http.StatusUnauthorized && c.auth != nil && c.auth.invalidate(header) {
+		conn, err = c.dialOnce(http.Header{})
+	}
+	return conn, err
+}
+
+// Handshake with the given (empty) header - it is filled with the headers which are sent
+func (c *websocketConnection) dialOnce(header http.Header) (*websocket.Conn, error) {
+	if c.headers != nil {
+		headers, err := c.headers.Build(c.ctx)
+		if err != nil {
+			return nil, &command.GoxHttpError{
+				Err:        err,
+				StatusCode: http.StatusBadRequest,
+				Message:    "failed to build default headers",
+				ErrorCode:  command.ErrorCodeFailedToBuildRequest,
+			}
+		}
+		for name, values := range headers {
+			header[name] = values
+		}
+	}
+	for name, values := range c.request.Header {
+		header.Del(name)
+		for _, value := range values {
+			header.Add(name, value)
+		}
+	}
+
+	query := url.Values{}
+	for name, values := range c.request.QueryParam {
+		query[name] = append([]string{}, values...)
+	}
+	if c.auth != nil {
+		if err := c.auth.apply(c.ctx, header, query); err != nil {
+			return nil, err
+		}
+	}
+
+	u := c.url
+	if len(query) > 0 {
+		u = u + "?" + query.Encode()
+	}
+
+	conn, response, err := c.dialer.DialContext(c.ctx, u, header)
+	if err == nil {
+		return conn, nil
+	}
+	if response == nil {
+		return nil, &command.GoxHttpError{
+			Err:        err,
+			StatusCode: http.StatusBadRequest,
+			Message:    "failed to connect websocket",
+			ErrorCode:  "request_failed_on_client",
+		}
+	}
+
+	var body []byte
+	if response.Body != nil {
+		body, _ = ioutil.ReadAll(response.Body)
+		_ = response.Body.Close()
+	}
+	return nil, &command.GoxHttpError{
+		Err:        err,
+		StatusCode: response.StatusCode,
+		Message:    "server rejected websocket handshake",
+		ErrorCode:  "server_response_with_error",
+		Body:       body,
+	}
+}
+
+// Websocket url of the api - "ws" or "wss" (if server is https) with path params replaced
+func websocketUrl(server *command.Server, api *command.Api, request *command.GoxRequest) (string, error) {
+	u, err := api.GetRequestUrl(server, request)
+	if err != nil {
+		return "", err
+	}
+	pathParams, err := api.ResolvePathParams(u, request.PathParam)
+	if err != nil {
+		return "", err
+	}
+	for name, value := range pathParams {
+		u = strings.ReplaceAll(u, "{"+name+"}", url.PathEscape(value))
+	}
+	if strings.HasPrefix(u, "https://") {
+		return "wss://" + strings.TrimPrefix(u, "https://"), nil
+	}
+	return "ws://" + strings.TrimPrefix(u, "http://"), nil
+}
+
+// NewWebsocketConnection opens a WebSocket connection to the api. Returns error if the first connect fails. The
+// connection is closed when ctx is done or Close is called.
+//
+// Host, default headers and auth of the server/api are used. Proxy is taken from environment (HTTP_PROXY/HTTPS_PROXY)
+func NewWebsocketConnection(ctx context.Context, cf gox.CrossFunction, server *command.Server, api *command.Api, request *command.GoxRequest) (command.WebsocketConnection, error) {
+	if request == nil {
+		request = &command.GoxRequest{}
+	}
+	config := api.Websocket
+	if config == nil {
+		config = &command.Websocket{Enabled: true}
+	}
+
+	auth, err := newAuthProvider(server, api)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := command.NewHeaderTemplate(api.GetHeaders(server))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create default headers for api=%s", api.Name)
+	}
+	u, err := websocketUrl(server, api, request)
+	if err != nil {
+		return nil, err
+	}
+
+	connCtx, cancel := context.WithCancel(ctx)
+	c := &websocketConnection{
+		CrossFunction: cf,
+		logger:        cf.Logger().Named("goxHttp").Named(api.Name),
+		serverName:    server.Name,
+		api:           api,
+		request:       request,
+		headers:       headers,
+		auth:          auth,
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: time.Duration(api.Timeout) * time.Millisecond,
+		},
+		url:                 u,
+		pingInterval:        durationOrDefault(config.PingIntervalMs, command.DefaultWebsocketPingInterval),
+		pongTimeout:         durationOrDefault(config.PongTimeoutMs, command.DefaultWebsocketPongTimeout),
+		reconnectInitWait:   durationOrDefault(config.ReconnectInitialWaitMs, command.DefaultWebsocketReconnectInitWait),
+		reconnectMaxWait:    durationOrDefault(config.ReconnectMaxWaitMs, command.DefaultWebsocketReconnectMaxWait),
+		maxReconnectAttempt: config.MaxReconnectAttempts,
+		ctx:                 connCtx,
+		cancel:              cancel,
+		lock:                &sync.Mutex{},
+		connected:           make(chan struct{}),
+		writeLock:           &sync.Mutex{},
+		incoming:            make(chan []byte, 64),
+		done:                make(chan struct{}),
+	}
+	if config.PingIntervalMs < 0 {
+		c.pingInterval = 0
+	}
+
+	conn, err := c.dial()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	c.setConnection(conn)
+	go c.run(conn)
+	return c, nil
+}
+
+// Returns the ms value as duration - or the default if value is not set
+func durationOrDefault(ms int, defaultValue time.Duration) time.Duration {
+	if ms <= 0 {
+		return defaultValue
+	}
+	return time.Duration(ms) * time.Millisecond
+}
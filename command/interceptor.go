@@ -0,0 +1,39 @@
+package command
+
+import (
+	"context"
+)
+
+// Handler executes a request - it is the "next" step given to an interceptor
+type Handler func(ctx context.Context, request *GoxRequest) (*GoxResponse, error)
+
+// Interceptor can see and modify a request before it is sent and the response/error after it is received.
+//
+// An interceptor must call next to continue the chain. It can short-circuit the call by returning a response (or error)
+// without calling next.
+type Interceptor interface {
+	Intercept(ctx context.Context, request *GoxRequest, next Handler) (*GoxResponse, error)
+}
+
+// InterceptorFunc allows a plain function to be used as an Interceptor
+type InterceptorFunc func(ctx context.Context, request *GoxRequest, next Handler) (*GoxResponse, error)
+
+func (f InterceptorFunc) Intercept(ctx context.Context, request *GoxRequest, next Handler) (*GoxResponse, error) {
+	return f(ctx, request, next)
+}
+
+// ChainInterceptors wraps the handler with given interceptors. The first interceptor is the outermost one i.e. it sees
+// the request first and the response last
+func ChainInterceptors(handler Handler, interceptors ...Interceptor) Handler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor := interceptors[i]
+		if interceptor == nil {
+			continue
+		}
+		next := handler
+		handler = func(ctx context.Context, request *GoxRequest) (*GoxResponse, error) {
+			return interceptor.Intercept(ctx, request, next)
+		}
+	}
+	return handler
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/devlibx/gox-base"
+	"io"
 	"net/http"
 )
 
@@ -19,16 +20,37 @@ type Servers map[string]*Server
 // ****************************************************************************************
 type Server struct {
 	Name                     string
-	Host                     string `yaml:"host"`
-	Port                     int    `yaml:"port"`
-	Https                    bool   `yaml:"https"`
-	ConnectTimeout           int    `yaml:"connect_timeout"`
-	ConnectionRequestTimeout int    `yaml:"connection_request_timeout"`
+	Host                     string            `yaml:"host"`
+	Port                     int               `yaml:"port"`
+	Https                    bool              `yaml:"https"`
+	ConnectTimeout           int               `yaml:"connect_timeout"`
+	ConnectionRequestTimeout int               `yaml:"connection_request_timeout"`
+	Auth                     *Auth             `yaml:"auth"`
+	Signing                  *Signing          `yaml:"signing"`
+	Headers                  map[string]string `yaml:"headers"`
+
+	// Url of the server e.g. "https://api.example.com/v2/payments" - if set, it is used instead of host, port and
+	// https. Path of the url is the base path of all APIs of this server
+	Url string `yaml:"url"`
+
+	// Prefix added to path of all APIs of this server e.g. "/v2/payments"
+	BasePath string `yaml:"base_path"`
+
+	// Interceptors which run for all APIs of this server (set from code - not read from yaml)
+	Interceptors []Interceptor `yaml:"-"`
 }
 
 // List of all APIs
 type Apis map[string]*Api
 
+// Type of an api
+const (
+	ApiTypeHttp    = "http"
+	ApiTypeGraphQL = "graphql"
+	ApiTypeJsonRpc = "jsonrpc"
+	ApiTypeSoap    = "soap"
+)
+
 // A single API
 // ****************************************************************************************
 // IMP NOTE - "config_parser.go -> UnmarshalYAML() method is created to do custom parsing.
@@ -48,6 +70,182 @@ type Api struct {
 	InitialRetryWaitTimeMs int    `yaml:"retry_initial_wait_time_ms"`
 	acceptableCodes        []int
 	DisableHystrix         bool
+
+	// Percentage of "concurrency" which is only given to PriorityHigh requests. When this is >0 requests are
+	// admitted in priority order and lower priority requests are shed first when the queue is full
+	HighPriorityConcurrencyPercent int `yaml:"high_priority_concurrency_percent"`
+
+	// Auth to use for this API - overrides the auth defined in server (use type=none to disable server auth)
+	Auth *Auth `yaml:"auth"`
+
+	// Request signing for this API - overrides the signing defined in server (use type=none to disable server signing)
+	Signing *Signing `yaml:"signing"`
+
+	// Default headers for this API - merged in this order: server < api < request
+	Headers map[string]string `yaml:"headers"`
+
+	// Response cache for GET calls of this API
+	Cache *Cache `yaml:"cache"`
+
+	// Server-sent events config - set it to use this api with ExecuteSse
+	Sse *Sse `yaml:"sse"`
+
+	// WebSocket config - set it to use this api with OpenWebsocket
+	Websocket *Websocket `yaml:"websocket"`
+
+	// Pagination config - set it to read this api page by page with Paginate
+	Pagination *Pagination `yaml:"pagination"`
+
+	// Buckets of duration and response size histograms of this api
+	Metrics *Metrics `yaml:"metrics"`
+
+	// Access log config - set it to log one structured entry for every call of this api
+	AccessLog *AccessLog `yaml:"access_log"`
+
+	// Query param templates, and body template used when request has no body - these are go templates filled from
+	// GoxRequest.TemplateParams. Body template type is "json" (default - result must be valid json) or "text"
+	QueryParams      map[string]string `yaml:"query_params"`
+	BodyTemplate     string            `yaml:"body_template"`
+	BodyTemplateType string            `yaml:"body_template_type"`
+
+	// Merge identical in-flight GET calls into one upstream call. Requests are identical if they have same method,
+	// resolved url and values of "coalesce_headers" (comma separated)
+	Coalesce        bool   `yaml:"coalesce"`
+	CoalesceHeaders string `yaml:"coalesce_headers"`
+
+	// Compress request body with gzip, deflate or zstd if it is at least "request_compression_min_bytes" long
+	RequestCompression         string `yaml:"request_compression"`
+	RequestCompressionMinBytes int    `yaml:"request_compression_min_bytes"`
+
+	// Advertise gzip, deflate, br and zstd in Accept-Encoding and decode the response body
+	ResponseCompression bool `yaml:"response_compression"`
+
+	// Content type used to encode request body if request does not have a Content-Type header (default=application/json)
+	ContentType string `yaml:"content_type"`
+
+	// Full name of the proto message to decode error responses into e.g. "google.rpc.Status" - the message must be
+	// registered i.e. its generated package must be imported. Decoded error is set in GoxHttpError.DecodedBody
+	ErrorProto string `yaml:"error_proto"`
+
+	// Fail with "response_too_large" error if response body is larger than this (0 = no limit)
+	MaxResponseBytes int `yaml:"max_response_bytes"`
+
+	// If true, request fails with "failed_to_build_request" error if a {name} path param of the path is not set, or
+	// a path param which is not in the path is set
+	StrictUrl bool `yaml:"strict_url"`
+
+	// If true, a request can override the path (GoxRequest.Path - added to server base url) or the full url
+	// (GoxRequest.Url) of this api
+	AllowUrlOverride bool `yaml:"allow_url_override"`
+
+	// Type of this api - "http" (default), "graphql", "jsonrpc" or "soap". Method of graphql, jsonrpc and soap apis
+	// defaults to POST
+	Type string `yaml:"type"`
+
+	// GraphQL config - used if type=graphql
+	GraphQL *GraphQL `yaml:"graphql"`
+
+	// SOAP config - used if type=soap
+	Soap *Soap `yaml:"soap"`
+
+	// Interceptors which run for this API (set from code - not read from yaml)
+	Interceptors []Interceptor `yaml:"-"`
+}
+
+// Auth config used by a server or api
+// Values can use "env:" parameterization, or "secret:<name>" to resolve it using DefaultSecretResolverFunc
+// ****************************************************************************************
+// IMP NOTE - "config_parser.go -> UnmarshalYAML() method is created to do custom parsing.
+// If you change anything here (add/update/delete) you must make changes in UnmarshalYAML()
+// ****************************************************************************************
+type Auth struct {
+	Type string `yaml:"type"`
+
+	// Basic auth
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// Static bearer token
+	Token string `yaml:"token"`
+
+	// Api key - sent in header or query param with given name
+	In    string `yaml:"in"`
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+
+	// OAuth2 client credentials
+	TokenUrl               string `yaml:"token_url"`
+	ClientId               string `yaml:"client_id"`
+	ClientSecret           string `yaml:"client_secret"`
+	Scopes                 string `yaml:"scopes"`
+	RefreshBeforeExpirySec int    `yaml:"refresh_before_expiry_sec"`
+}
+
+// Request signing config used by a server or api
+// ****************************************************************************************
+// IMP NOTE - "config_parser.go -> UnmarshalYAML() method is created to do custom parsing.
+// If you change anything here (add/update/delete) you must make changes in UnmarshalYAML()
+// ****************************************************************************************
+type Signing struct {
+	Type            string `yaml:"type"`
+	Algorithm       string `yaml:"algorithm"`
+	Encoding        string `yaml:"encoding"`
+	KeyId           string `yaml:"key_id"`
+	Secret          string `yaml:"secret"`
+	SignatureHeader string `yaml:"signature_header"`
+	KeyIdHeader     string `yaml:"key_id_header"`
+	TimestampHeader string `yaml:"timestamp_header"`
+	NonceHeader     string `yaml:"nonce_header"`
+	SignedHeaders   string `yaml:"signed_headers"`
+	Canonicalizer   string `yaml:"canonicalizer"`
+}
+
+// Response cache config of an api
+// ****************************************************************************************
+// IMP NOTE - "config_parser.go -> UnmarshalYAML() method is created to do custom parsing.
+// If you change anything here (add/update/delete) you must make changes in UnmarshalYAML()
+// ****************************************************************************************
+type Cache struct {
+	Enabled    bool `yaml:"enabled"`
+	MaxEntries int  `yaml:"max_entries"`
+
+	// Store to use - default is an in-memory LRU store with "max_entries" entries (set from code - not read from yaml)
+	Store CacheStore `yaml:"-"`
+}
+
+// Server-sent events config of an api
+// ****************************************************************************************
+// IMP NOTE - "config_parser.go -> UnmarshalYAML() method is created to do custom parsing.
+// If you change anything here (add/update/delete) you must make changes in UnmarshalYAML()
+// ****************************************************************************************
+type Sse struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Time to wait before reconnecting, till server sends a "retry" hint (default=3000)
+	RetryMs int `yaml:"retry_ms"`
+
+	// Stream fails after "max_reconnects" reconnects in a row without getting an event (0 = reconnect forever)
+	MaxReconnects int `yaml:"max_reconnects"`
+}
+
+// WebSocket config of an api. Api "timeout" is used as handshake timeout
+// ****************************************************************************************
+// IMP NOTE - "config_parser.go -> UnmarshalYAML() method is created to do custom parsing.
+// If you change anything here (add/update/delete) you must make changes in UnmarshalYAML()
+// ****************************************************************************************
+type Websocket struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Send a ping every "ping_interval_ms" - connection is reconnected if no pong (or message) comes within
+	// "pong_timeout_ms" after it. Set ping_interval_ms=-1 to disable heartbeat
+	PingIntervalMs int `yaml:"ping_interval_ms"`
+	PongTimeoutMs  int `yaml:"pong_timeout_ms"`
+
+	// Backoff between reconnect attempts - starts with "reconnect_initial_wait_ms" and doubles till
+	// "reconnect_max_wait_ms". Connection fails after "max_reconnect_attempts" failed attempts (0 = retry forever)
+	ReconnectInitialWaitMs int `yaml:"reconnect_initial_wait_ms"`
+	ReconnectMaxWaitMs     int `yaml:"reconnect_max_wait_ms"`
+	MaxReconnectAttempts   int `yaml:"max_reconnect_attempts"`
 }
 
 func (a *Api) GetTimeoutWithRetryIncluded() int {
@@ -95,13 +293,53 @@ type ResponseBuilder interface {
 	Response(data []byte) (interface{}, error)
 }
 
+// Priority of a request - used to decide which requests are shed first when an API is saturated
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
 type GoxRequest struct {
+	Priority        Priority
 	Header          http.Header
 	PathParam       MultivaluedMap
 	QueryParam      MultivaluedMap
 	Body            interface{}
 	BodyProvider    BodyProvider
 	ResponseBuilder ResponseBuilder
+
+	// Response is decoded into this object using the codec for response content type (if ResponseBuilder is not set)
+	ResponseTarget interface{}
+
+	// Form fields and files - if set, body is sent as multipart/form-data (if there are files) or
+	// application/x-www-form-urlencoded, and Body/BodyProvider are not used
+	FormData MultivaluedMap
+	Files    []*MultipartFile
+
+	// If true the response body is not read - it is returned in GoxResponse.BodyStream
+	Stream bool
+
+	// Query, variables and operation name for a "type: graphql" api - Body is not used for a graphql api
+	GraphQL *GraphQLRequest
+
+	// Method and params for a "type: jsonrpc" api - either a single call, or a batch of calls sent in one request
+	JsonRpc      *JsonRpcCall
+	JsonRpcBatch []*JsonRpcCall
+
+	// For a "type: soap" api - SOAPAction to use instead of the action in api config, and an object (or xml) to send
+	// in soap:Header. Body is wrapped in soap:Body
+	SoapAction string
+	SoapHeader interface{}
+
+	// Params used to fill "query_params", "body_template" and {name} path params of the api config
+	TemplateParams map[string]interface{}
+
+	// Path (added to server base url) or full url to use instead of the api path - api must have "allow_url_override"
+	Path string
+	Url  string
 }
 
 type GoxResponse struct {
@@ -109,6 +347,18 @@ type GoxResponse struct {
 	Response   interface{}
 	StatusCode int
 	Err        error
+
+	// Set only for a streaming request (GoxRequest.Stream) - caller must close it
+	BodyStream io.ReadCloser
+
+	// Headers of the response
+	Header http.Header
+
+	// Set only if cache is enabled for this API
+	CacheStatus CacheStatus
+
+	// Set only for a jsonrpc batch - result of each call, in the order of calls in the request
+	JsonRpcResults []JsonRpcResult
 }
 
 func (r *GoxResponse) AsStringObjectMapOrEmpty() gox.StringObjectMap {
@@ -1,11 +1,12 @@
 package command
 
 import (
-	"fmt"
 	"github.com/devlibx/gox-base/errors"
 	"github.com/devlibx/gox-base/serialization"
 	"github.com/devlibx/gox-base/util"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
@@ -22,12 +23,17 @@ func (c *Config) SetupDefaults() {
 			if v.ConnectionRequestTimeout <= 0 {
 				v.ConnectionRequestTimeout = 50
 			}
-			if v.Port == 0 {
+			if v.Port == 0 && v.Https {
+				v.Port = 443
+			} else if v.Port == 0 {
 				v.Port = 80
 			}
 			if util.IsStringEmpty(v.Host) {
 				v.Host = "localhost"
 			}
+			if v.Auth != nil {
+				v.Auth.setupDefaults()
+			}
 		}
 	}
 
@@ -44,9 +50,19 @@ func (c *Config) SetupDefaults() {
 			if v.QueueSize <= 0 {
 				v.QueueSize = 1
 			}
-			if util.IsStringEmpty(v.Method) {
+			if util.IsStringEmpty(v.Method) && (v.IsGraphQL() || v.IsJsonRpc() || v.IsSoap()) {
+				v.Method = "POST"
+			} else if util.IsStringEmpty(v.Method) {
 				v.Method = "GET"
 			}
+			if v.HighPriorityConcurrencyPercent < 0 {
+				v.HighPriorityConcurrencyPercent = 0
+			} else if v.HighPriorityConcurrencyPercent > 100 {
+				v.HighPriorityConcurrencyPercent = 100
+			}
+			if v.Auth != nil {
+				v.Auth.setupDefaults()
+			}
 			if util.IsStringEmpty(v.AcceptableCodes) {
 				v.AcceptableCodes = "200,201"
 			}
@@ -84,12 +100,34 @@ func (c *Config) FindApiByName(toFind string) (*Api, error) {
 	return nil, errors.New("api not found with %s name", toFind)
 }
 
+// ValidateType returns error if "type" of this api is not known
+func (a *Api) ValidateType() error {
+	switch a.Type {
+	case "", ApiTypeHttp, ApiTypeGraphQL, ApiTypeJsonRpc, ApiTypeSoap:
+		return nil
+	}
+	return errors.New("unknown api type: api=%s, type=%s", a.Name, a.Type)
+}
+
 func (a *Api) GetPath(server *Server) string {
-	if server.Https {
-		return fmt.Sprintf("https://%s:%d%s", server.Host, server.Port, a.Path)
-	} else {
-		return fmt.Sprintf("http://%s:%d%s", server.Host, server.Port, a.Path)
+	base, _ := server.GetBaseUrl()
+	return base + joinUrlPath("", a.Path)
+}
+
+// Number of concurrent slots which are only given to high priority requests. At least one slot is always left for
+// other requests, so an api with concurrency=1 does not reserve anything
+func (a *Api) GetHighPriorityReservedConcurrency() int {
+	if a.HighPriorityConcurrencyPercent <= 0 {
+		return 0
+	}
+	reserved := (a.Concurrency*a.HighPriorityConcurrencyPercent + 99) / 100
+	if reserved > a.Concurrency-1 {
+		reserved = a.Concurrency - 1
+	}
+	if reserved < 0 {
+		reserved = 0
 	}
+	return reserved
 }
 
 func (a *Api) IsHttpCodeAcceptable(code int) bool {
@@ -181,6 +219,12 @@ func (b *goxRequestBuilder) WithResponseBuilder(builder ResponseBuilder) *goxReq
 	return b
 }
 
+// WithResponseTarget sets the object to decode response into - codec is picked using the response content type
+func (b *goxRequestBuilder) WithResponseTarget(out interface{}) *goxRequestBuilder {
+	b.request.ResponseTarget = out
+	return b
+}
+
 func (b *goxRequestBuilder) WithBodyProvider(builder BodyProvider) *goxRequestBuilder {
 	b.request.BodyProvider = builder
 	return b
@@ -191,6 +235,134 @@ func (b *goxRequestBuilder) WithBody(body interface{}) *goxRequestBuilder {
 	return b
 }
 
+// WithFormField adds a form field. Body is sent as application/x-www-form-urlencoded, or multipart/form-data if
+// request also has files
+func (b *goxRequestBuilder) WithFormField(name string, value interface{}) *goxRequestBuilder {
+	if b.request.FormData == nil {
+		b.request.FormData = map[string][]string{}
+	}
+	if str, ok := value.(string); ok {
+		b.request.FormData[name] = append(b.request.FormData[name], str)
+	} else {
+		b.request.FormData[name] = append(b.request.FormData[name], serialization.StringifySuppressError(value, ""))
+	}
+	return b
+}
+
+// WithFileFromPath adds a file part which is read from the given path. Content type is detected from file extension
+// if it is empty
+func (b *goxRequestBuilder) WithFileFromPath(field string, path string, contentType string) *goxRequestBuilder {
+	fileName := filepath.Base(path)
+	if contentType == "" {
+		contentType = contentTypeFromFileName(fileName)
+	}
+	b.request.Files = append(b.request.Files, &MultipartFile{Field: field, FileName: fileName, ContentType: contentType, Path: path})
+	return b
+}
+
+// WithFileFromReader adds a file part which is read from the given reader. Content type is detected from file name
+// if it is empty
+func (b *goxRequestBuilder) WithFileFromReader(field string, fileName string, contentType string, reader io.Reader) *goxRequestBuilder {
+	if contentType == "" {
+		contentType = contentTypeFromFileName(fileName)
+	}
+	b.request.Files = append(b.request.Files, &MultipartFile{Field: field, FileName: fileName, ContentType: contentType, Reader: reader})
+	return b
+}
+
+func (b *goxRequestBuilder) WithPriority(priority Priority) *goxRequestBuilder {
+	b.request.Priority = priority
+	return b
+}
+
+func (b *goxRequestBuilder) WithGraphQLQuery(query string) *goxRequestBuilder {
+	b.graphQL().Query = query
+	return b
+}
+
+func (b *goxRequestBuilder) WithGraphQLOperationName(operationName string) *goxRequestBuilder {
+	b.graphQL().OperationName = operationName
+	return b
+}
+
+func (b *goxRequestBuilder) WithGraphQLVariable(name string, value interface{}) *goxRequestBuilder {
+	g := b.graphQL()
+	if g.Variables == nil {
+		g.Variables = map[string]interface{}{}
+	}
+	g.Variables[name] = value
+	return b
+}
+
+func (b *goxRequestBuilder) WithGraphQLVariables(variables map[string]interface{}) *goxRequestBuilder {
+	for name, value := range variables {
+		b.WithGraphQLVariable(name, value)
+	}
+	return b
+}
+
+func (b *goxRequestBuilder) graphQL() *GraphQLRequest {
+	if b.request.GraphQL == nil {
+		b.request.GraphQL = &GraphQLRequest{}
+	}
+	return b.request.GraphQL
+}
+
+func (b *goxRequestBuilder) WithJsonRpcMethod(method string, params interface{}) *goxRequestBuilder {
+	b.request.JsonRpc = &JsonRpcCall{Method: method, Params: params}
+	return b
+}
+
+func (b *goxRequestBuilder) WithJsonRpcNotification(method string, params interface{}) *goxRequestBuilder {
+	b.request.JsonRpc = &JsonRpcCall{Method: method, Params: params, Notification: true}
+	return b
+}
+
+func (b *goxRequestBuilder) WithJsonRpcBatch(calls ...*JsonRpcCall) *goxRequestBuilder {
+	b.request.JsonRpcBatch = append(b.request.JsonRpcBatch, calls...)
+	return b
+}
+
+func (b *goxRequestBuilder) WithSoapAction(action string) *goxRequestBuilder {
+	b.request.SoapAction = action
+	return b
+}
+
+func (b *goxRequestBuilder) WithSoapHeader(header interface{}) *goxRequestBuilder {
+	b.request.SoapHeader = header
+	return b
+}
+
+func (b *goxRequestBuilder) WithTemplateParam(name string, value interface{}) *goxRequestBuilder {
+	if b.request.TemplateParams == nil {
+		b.request.TemplateParams = map[string]interface{}{}
+	}
+	b.request.TemplateParams[name] = value
+	return b
+}
+
+func (b *goxRequestBuilder) WithTemplateParams(params map[string]interface{}) *goxRequestBuilder {
+	for name, value := range params {
+		b.WithTemplateParam(name, value)
+	}
+	return b
+}
+
+func (b *goxRequestBuilder) WithPath(path string) *goxRequestBuilder {
+	b.request.Path = path
+	return b
+}
+
+func (b *goxRequestBuilder) WithUrl(url string) *goxRequestBuilder {
+	b.request.Url = url
+	return b
+}
+
+func (b *goxRequestBuilder) WithStream() *goxRequestBuilder {
+	b.request.Stream = true
+	return b
+}
+
 func (b *goxRequestBuilder) Build() *GoxRequest {
 	return b.request
 }
@@ -0,0 +1,186 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/devlibx/gox-base/errors"
+	"sync/atomic"
+)
+
+const JsonRpcVersion = "2.0"
+
+// Pre-defined JSON-RPC 2.0 error codes
+const (
+	JsonRpcCodeParseError     = -32700
+	JsonRpcCodeInvalidRequest = -32600
+	JsonRpcCodeMethodNotFound = -32601
+	JsonRpcCodeInvalidParams  = -32602
+	JsonRpcCodeInternalError  = -32603
+)
+
+var jsonRpcIdCounter int64
+
+// Gives a new id for a JSON-RPC call (unique in this process)
+func nextJsonRpcId() int64 {
+	return atomic.AddInt64(&jsonRpcIdCounter, 1)
+}
+
+// JsonRpcCall is a single method invocation of a "type: jsonrpc" api
+type JsonRpcCall struct {
+	Method string
+	Params interface{}
+
+	// Id of the call - an id is generated for the request if it is not set (call is not changed). A notification has
+	// no id, and server does not respond to it
+	Id           interface{}
+	Notification bool
+
+	// Result of a batch call is decoded into this object (if set). For a single call use ResponseTarget of request
+	Result interface{}
+}
+
+// JsonRpcResult is the outcome of one call of a batch - GoxResponse.JsonRpcResults has one for each call, in the order
+// of calls
+type JsonRpcResult struct {
+	Method string
+
+	// Id sent for this call (nil for a notification)
+	Id interface{}
+
+	// Raw result, and the error (GoxHttpError with JsonRpcError) if this call failed
+	RawResult json.RawMessage
+	Err       error
+}
+
+// JsonRpcError is the "error" object of a JSON-RPC response
+type JsonRpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *JsonRpcError) Error() string {
+	return fmt.Sprintf("jsonrpc error: code=%d, message=%s", e.Code, e.Message)
+}
+
+type jsonRpcRequest struct {
+	JsonRpc string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+	Id      interface{} `json:"id,omitempty"`
+}
+
+// JsonRpcResponse is the envelope of a JSON-RPC response
+type JsonRpcResponse struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *JsonRpcError   `json:"error"`
+	Id      json.RawMessage `json:"id"`
+}
+
+// IsJsonRpc returns true if this is a "type: jsonrpc" api
+func (a *Api) IsJsonRpc() bool {
+	return a.Type == ApiTypeJsonRpc
+}
+
+// BuildJsonRpcBody builds the request envelope of a single call, or the array of envelopes for a batch. It also gives
+// the id sent for each call - ids are generated for calls which don't have one (calls are not changed). Ids set in
+// calls of a batch must be unique, and generated ids never match them - response of a call is matched by its id
+func BuildJsonRpcBody(calls []*JsonRpcCall, batch bool) ([]byte, []interface{}, error) {
+	used := map[string]bool{}
+	for _, call := range calls {
+		if call == nil || call.Notification || call.Id == nil {
+			continue
+		}
+		key := JsonRpcIdKey(call.Id)
+		if used[key] {
+			return nil, nil, errors.New("jsonrpc id is used by more than one call: id=%s", key)
+		}
+		used[key] = true
+	}
+
+	requests := make([]*jsonRpcRequest, 0, len(calls))
+	ids := make([]interface{}, 0, len(calls))
+	for _, call := range calls {
+		if call == nil || call.Method == "" {
+			return nil, nil, errors.New("jsonrpc method is not given")
+		}
+		request := &jsonRpcRequest{JsonRpc: JsonRpcVersion, Method: call.Method, Params: call.Params}
+		if !call.Notification {
+			request.Id = call.Id
+			if request.Id == nil {
+				id := nextJsonRpcId()
+				for used[JsonRpcIdKey(id)] {
+					id = nextJsonRpcId()
+				}
+				request.Id = id
+			}
+		}
+		requests = append(requests, request)
+		ids = append(ids, request.Id)
+	}
+	if !batch {
+		if len(requests) != 1 {
+			return nil, nil, errors.New("expected one jsonrpc call, got %d", len(requests))
+		}
+		body, err := json.Marshal(requests[0])
+		return body, ids, err
+	}
+	if len(requests) == 0 {
+		return nil, nil, errors.New("jsonrpc batch must have at least one call")
+	}
+	body, err := json.Marshal(requests)
+	return body, ids, err
+}
+
+// DecodeJsonRpcResponse reads a single response object, or an array of response objects (batch)
+func DecodeJsonRpcResponse(body []byte) ([]*JsonRpcResponse, error) {
+	body = bytes.TrimSpace(body)
+	responses := make([]*JsonRpcResponse, 0)
+	if len(body) > 0 && body[0] == '[' {
+		if err := json.Unmarshal(body, &responses); err != nil {
+			return nil, errors.Wrap(err, "failed to decode jsonrpc batch response")
+		}
+		return responses, nil
+	}
+	response := &JsonRpcResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, errors.Wrap(err, "failed to decode jsonrpc response")
+	}
+	return append(responses, response), nil
+}
+
+// JsonRpcIdKey gives a key to match the id of a call with the id in response e.g. 1 and 1.0 are same
+func JsonRpcIdKey(id interface{}) string {
+	var raw []byte
+	if r, ok := id.(json.RawMessage); ok {
+		raw = r
+	} else {
+		raw, _ = json.Marshal(id)
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return string(raw)
+	}
+	key, _ := json.Marshal(value)
+	return string(key)
+}
+
+// NewJsonRpcError gives a GoxHttpError for the "error" object of a JSON-RPC response
+func NewJsonRpcError(statusCode int, rpcError *JsonRpcError, body []byte) *GoxHttpError {
+	return &GoxHttpError{
+		Err:         rpcError,
+		StatusCode:  statusCode,
+		Message:     fmt.Sprintf("jsonrpc server returned error: code=%d, message=%s", rpcError.Code, rpcError.Message),
+		ErrorCode:   ErrorCodeJsonRpcError,
+		Body:        body,
+		DecodedBody: rpcError,
+	}
+}
+
+// JsonRpcError gives the error returned by a JSON-RPC server (nil if this is not a JSON-RPC error)
+func (e *GoxHttpError) JsonRpcError() *JsonRpcError {
+	rpcError, _ := e.DecodedBody.(*JsonRpcError)
+	return rpcError
+}
@@ -0,0 +1,24 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBuildJsonRpcBody_Ids(t *testing.T) {
+
+	// Generated id skips the ids set in calls
+	next := atomic.LoadInt64(&jsonRpcIdCounter) + 1
+	_, ids, err := BuildJsonRpcBody([]*JsonRpcCall{{Method: "add", Id: next}, {Method: "add"}, {Method: "add", Id: float64(next + 1)}}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, next, ids[0])
+	assert.Equal(t, next+2, ids[1])
+	assert.Equal(t, float64(next+1), ids[2])
+
+	// Same id can't be used by two calls of a batch (1 and 1.0 are same id)
+	_, _, err = BuildJsonRpcBody([]*JsonRpcCall{{Method: "add", Id: 1}, {Method: "add", Id: 1.0}}, true)
+	assert.Error(t, err)
+	_, _, err = BuildJsonRpcBody([]*JsonRpcCall{{Method: "add", Id: "a"}, {Method: "add", Id: "a", Notification: true}}, true)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,150 @@
+package command
+
+import (
+	"fmt"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-base/metrics"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Buckets of request and attempt duration histograms - used if api does not set "metrics.latency_buckets_ms"
+var DefaultLatencyBuckets = DurationBuckets{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// Buckets (in bytes) of response size histogram - used if api does not set "metrics.response_size_buckets"
+var DefaultResponseSizeBuckets = ValueBuckets{256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+
+// Metrics config of an api
+// ****************************************************************************************
+// IMP NOTE - "config_parser.go -> UnmarshalYAML() method is created to do custom parsing.
+// If you change anything here (add/update/delete) you must make changes in UnmarshalYAML()
+// ****************************************************************************************
+type Metrics struct {
+
+	// Comma separated upper bounds (in ms) of request and attempt duration histograms e.g. "10,50,100,500,1000"
+	LatencyBucketsMs string `yaml:"latency_buckets_ms"`
+
+	// Comma separated upper bounds (in bytes) of response size histogram e.g. "1024,65536,1048576"
+	ResponseSizeBuckets string `yaml:"response_size_buckets"`
+}
+
+// DurationBuckets are histogram buckets of durations
+type DurationBuckets []time.Duration
+
+func (b DurationBuckets) String() string {
+	return fmt.Sprintf("%v", []time.Duration(b))
+}
+
+func (b DurationBuckets) Len() int {
+	return len(b)
+}
+
+func (b DurationBuckets) Less(i, j int) bool {
+	return b[i] < b[j]
+}
+
+func (b DurationBuckets) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+func (b DurationBuckets) AsValues() []float64 {
+	values := make([]float64, len(b))
+	for i, d := range b {
+		values[i] = float64(d)
+	}
+	return values
+}
+
+func (b DurationBuckets) AsDurations() []time.Duration {
+	return append([]time.Duration{}, b...)
+}
+
+// ValueBuckets are histogram buckets of values
+type ValueBuckets []float64
+
+func (b ValueBuckets) String() string {
+	return fmt.Sprintf("%v", []float64(b))
+}
+
+func (b ValueBuckets) Len() int {
+	return len(b)
+}
+
+func (b ValueBuckets) Less(i, j int) bool {
+	return b[i] < b[j]
+}
+
+func (b ValueBuckets) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+func (b ValueBuckets) AsValues() []float64 {
+	return append([]float64{}, b...)
+}
+
+func (b ValueBuckets) AsDurations() []time.Duration {
+	durations := make([]time.Duration, len(b))
+	for i, v := range b {
+		durations[i] = time.Duration(v)
+	}
+	return durations
+}
+
+// GetLatencyBuckets gives the buckets of duration histograms of this api
+func (a *Api) GetLatencyBuckets() (metrics.Buckets, error) {
+	if a.Metrics == nil || strings.TrimSpace(a.Metrics.LatencyBucketsMs) == "" {
+		return DefaultLatencyBuckets, nil
+	}
+	values, err := parseBuckets(a.Metrics.LatencyBucketsMs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse metrics.latency_buckets_ms of api=%s", a.Name)
+	}
+	buckets := make(DurationBuckets, len(values))
+	for i, v := range values {
+		buckets[i] = time.Duration(v * float64(time.Millisecond))
+	}
+	return buckets, nil
+}
+
+// GetResponseSizeBuckets gives the buckets of response size histogram of this api
+func (a *Api) GetResponseSizeBuckets() (metrics.Buckets, error) {
+	if a.Metrics == nil || strings.TrimSpace(a.Metrics.ResponseSizeBuckets) == "" {
+		return DefaultResponseSizeBuckets, nil
+	}
+	values, err := parseBuckets(a.Metrics.ResponseSizeBuckets)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse metrics.response_size_buckets of api=%s", a.Name)
+	}
+	return ValueBuckets(values), nil
+}
+
+// Parse comma separated bucket bounds - they must be positive and are returned in sorted order
+func parseBuckets(value string) ([]float64, error) {
+	values := make([]float64, 0)
+	for _, part := range strings.Split(value, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		if v <= 0 {
+			return nil, errors.New("bucket must be >0: value=%s", part)
+		}
+		values = append(values, v)
+	}
+	sort.Float64s(values)
+	return values, nil
+}
+
+// StatusClass gives the class of http status e.g. "2xx" - "none" if there is no status
+func StatusClass(statusCode int) string {
+	if statusCode <= 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
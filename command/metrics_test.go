@@ -0,0 +1,34 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestApi_GetLatencyBuckets(t *testing.T) {
+	api := &Api{Name: "getUser"}
+	buckets, err := api.GetLatencyBuckets()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLatencyBuckets, buckets)
+
+	api.Metrics = &Metrics{LatencyBucketsMs: "50, 2.5", ResponseSizeBuckets: "1024,64"}
+	buckets, err = api.GetLatencyBuckets()
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{2500 * time.Microsecond, 50 * time.Millisecond}, buckets.AsDurations())
+	buckets, err = api.GetResponseSizeBuckets()
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{64, 1024}, buckets.AsValues())
+
+	api.Metrics = &Metrics{LatencyBucketsMs: "10,abc", ResponseSizeBuckets: "0"}
+	_, err = api.GetLatencyBuckets()
+	assert.Error(t, err)
+	_, err = api.GetResponseSizeBuckets()
+	assert.Error(t, err)
+}
+
+func TestStatusClass(t *testing.T) {
+	assert.Equal(t, "2xx", StatusClass(201))
+	assert.Equal(t, "5xx", StatusClass(503))
+	assert.Equal(t, "none", StatusClass(0))
+}
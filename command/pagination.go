@@ -0,0 +1,353 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/devlibx/gox-base/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Pagination types
+const (
+	PaginationTypeOffset = "offset"
+	PaginationTypePage   = "page"
+	PaginationTypeCursor = "cursor"
+	PaginationTypeLink   = "link"
+)
+
+// Pagination config of a list api
+// ****************************************************************************************
+// IMP NOTE - "config_parser.go -> UnmarshalYAML() method is created to do custom parsing.
+// If you change anything here (add/update/delete) you must make changes in UnmarshalYAML()
+// ****************************************************************************************
+type Pagination struct {
+
+	// offset, page, cursor or link (RFC 5988 Link header with rel=next)
+	Type string `yaml:"type"`
+
+	// Page size - sent in "limit_param" if it is >0. For offset and page pagination, a page with less items than limit
+	// is the last page
+	Limit      int    `yaml:"limit"`
+	LimitParam string `yaml:"limit_param"`
+
+	// Query param for offset pagination - starts with 0 and moves by number of items received
+	OffsetParam string `yaml:"offset_param"`
+
+	// Query param for page pagination - starts with "start_page" (default 1)
+	PageParam string `yaml:"page_param"`
+	StartPage int    `yaml:"start_page"`
+
+	// Query param for cursor pagination, and the path of next cursor in response e.g. "meta.next_cursor". Empty or
+	// missing cursor means this is the last page
+	CursorParam string `yaml:"cursor_param"`
+	CursorPath  string `yaml:"cursor_path"`
+
+	// Path of items array in response e.g. "data.items" - empty means response itself is the array. An empty page
+	// ends the iteration, and so does a page without items array for offset and page pagination
+	ItemsPath string `yaml:"items_path"`
+
+	// Stop after these many pages (0 = no limit)
+	MaxPages int `yaml:"max_pages"`
+}
+
+// Page is a single page of a paginated api
+type Page struct {
+
+	// 1 for the first page
+	Number   int
+	Response *GoxResponse
+
+	// Items read from "items_path" of the response
+	Items []json.RawMessage
+}
+
+// PageIterator reads a paginated api one page at a time. A page is fetched only when it is needed
+type PageIterator interface {
+
+	// NextPage fetches the next page. Returns io.EOF when there are no more pages, or "max_pages" are read
+	NextPage() (*Page, error)
+
+	// NextItem decodes the next item (json) into out - next page is fetched when items of current page are done.
+	// Returns io.EOF when there are no more items
+	NextItem(out interface{}) error
+}
+
+// Key of the next page link in the context of a page request - see Api.GetRequestUrlWithContext
+type nextPageLinkKey struct{}
+
+type pageIterator struct {
+	ctx        context.Context
+	pagination *Pagination
+	request    *GoxRequest
+	execute    Handler
+
+	pageNumber int
+	offset     int
+	page       int
+	next       url.Values
+	nextLink   string
+	done       bool
+
+	items []json.RawMessage
+}
+
+func (p *pageIterator) NextPage() (*Page, error) {
+	if p.done || (p.pagination.MaxPages > 0 && p.pageNumber >= p.pagination.MaxPages) {
+		p.done = true
+		return nil, io.EOF
+	}
+	if err := p.ctx.Err(); err != nil {
+		return nil, &GoxHttpError{
+			Err:        err,
+			StatusCode: http.StatusRequestTimeout,
+			Message:    "context is done while reading pages",
+			ErrorCode:  "request_timeout_on_client",
+		}
+	}
+
+	// Next link is marked in the context, so that only this url is called even if api does not allow url override
+	ctx := p.ctx
+	if p.nextLink != "" {
+		ctx = context.WithValue(ctx, nextPageLinkKey{}, p.nextLink)
+	}
+	response, err := p.execute(ctx, p.pageRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := p.readItems(response)
+	if err != nil {
+		return nil, err
+	}
+	if items != nil && len(items) == 0 {
+		p.done = true
+		return nil, io.EOF
+	}
+	p.pageNumber++
+	if err = p.moveToNextPage(response, items); err != nil {
+		return nil, err
+	}
+	return &Page{Number: p.pageNumber, Response: response, Items: items}, nil
+}
+
+func (p *pageIterator) NextItem(out interface{}) error {
+	for len(p.items) == 0 {
+		page, err := p.NextPage()
+		if err != nil {
+			return err
+		}
+		p.items = page.Items
+	}
+	item := p.items[0]
+	p.items = p.items[1:]
+	if err := json.Unmarshal(item, out); err != nil {
+		return errors.Wrap(err, "failed to decode page item")
+	}
+	return nil
+}
+
+// Copy of request with query params of the current page
+func (p *pageIterator) pageRequest() *GoxRequest {
+	request := *p.request
+	request.QueryParam = MultivaluedMap{}
+	for name, values := range p.request.QueryParam {
+		request.QueryParam[name] = append([]string{}, values...)
+	}
+
+	pagination := p.pagination
+	if pagination.Limit > 0 && pagination.Type != PaginationTypeLink {
+		request.QueryParam[pagination.LimitParam] = []string{strconv.Itoa(pagination.Limit)}
+	}
+	switch pagination.Type {
+	case PaginationTypeOffset:
+		request.QueryParam[pagination.OffsetParam] = []string{strconv.Itoa(p.offset)}
+	case PaginationTypePage:
+		request.QueryParam[pagination.PageParam] = []string{strconv.Itoa(p.page)}
+	}
+
+	// Next link is called as it is - only its query params are sent
+	if p.nextLink != "" {
+		request.Url, request.Path = p.nextLink, ""
+		request.PathParam = nil
+		request.QueryParam = MultivaluedMap{}
+	}
+
+	// Query params of the next link (or next cursor) replace the query params of the request
+	for name, values := range p.next {
+		request.QueryParam[name] = append([]string{}, values...)
+	}
+	return &request
+}
+
+// Items of the page - returns nil if items are not a json array (page size can't be known)
+func (p *pageIterator) readItems(response *GoxResponse) ([]json.RawMessage, error) {
+	raw, ok := JsonPathLookup(response.Body, p.pagination.ItemsPath)
+	if !ok || len(raw) == 0 || raw[0] != '[' {
+		return nil, nil
+	}
+	items := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, errors.Wrap(err, "failed to read items from page: items_path=%s", p.pagination.ItemsPath)
+	}
+	return items, nil
+}
+
+func (p *pageIterator) moveToNextPage(response *GoxResponse, items []json.RawMessage) error {
+	pagination := p.pagination
+	switch pagination.Type {
+	case PaginationTypeOffset, PaginationTypePage:
+
+		// Without items we can't know where the next page starts, or if there is one - so this is the last page
+		if items == nil || (pagination.Limit > 0 && len(items) < pagination.Limit) {
+			p.done = true
+		}
+		p.offset += len(items)
+		p.page++
+
+	case PaginationTypeCursor:
+		// Number cursor is used as it is in the response (not converted to float e.g. 1.2345678e+07)
+		cursor := ""
+		if raw, ok := JsonPathLookup(response.Body, pagination.CursorPath); ok && len(raw) > 0 && raw[0] == '"' {
+			if err := json.Unmarshal(raw, &cursor); err != nil {
+				return errors.Wrap(err, "failed to read cursor from page: cursor_path=%s", pagination.CursorPath)
+			}
+		} else if ok && string(raw) != "null" {
+			cursor = string(raw)
+		}
+		if cursor == "" {
+			p.done = true
+		} else {
+			p.next = url.Values{pagination.CursorParam: []string{cursor}}
+		}
+
+	case PaginationTypeLink:
+		next := ""
+		if response.Header != nil {
+			next = NextLink(response.Header.Values("Link"))
+		}
+		if next == "" {
+			p.done = true
+			return nil
+		}
+		nextUrl, err := url.Parse(next)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse next link: %s", next)
+		}
+		p.next = nextUrl.Query()
+		p.nextLink = next
+	}
+	return nil
+}
+
+// NextLink finds the url with rel="next" in RFC 5988 Link headers. Returns empty string if there is no next link
+func NextLink(headers []string) string {
+	for _, header := range headers {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				name, value := param, ""
+				if i := strings.Index(param, "="); i >= 0 {
+					name, value = param[:i], param[i+1:]
+				}
+				if !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// JsonPathLookup finds the value at a dot separated path e.g. "data.items" or "data.0.id" in a json document. Empty path
+// gives the whole document
+func JsonPathLookup(data []byte, path string) (json.RawMessage, bool) {
+	current := json.RawMessage(bytes.TrimSpace(data))
+	if path == "" {
+		return current, len(current) > 0
+	}
+	for _, key := range strings.Split(path, ".") {
+		if len(current) == 0 {
+			return nil, false
+		}
+		if current[0] == '[' {
+			index, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, false
+			}
+			values := make([]json.RawMessage, 0)
+			if err := json.Unmarshal(current, &values); err != nil || index < 0 || index >= len(values) {
+				return nil, false
+			}
+			current = values[index]
+		} else {
+			values := map[string]json.RawMessage{}
+			if err := json.Unmarshal(current, &values); err != nil {
+				return nil, false
+			}
+			value, ok := values[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		}
+	}
+	return current, true
+}
+
+// NewPageIterator creates an iterator for an api with pagination config. Every page is fetched using the given
+// handler - so it goes through the normal circuit breaker and retry path
+func NewPageIterator(ctx context.Context, api *Api, request *GoxRequest, execute Handler) (PageIterator, error) {
+	if api.Pagination == nil {
+		return nil, errors.New("pagination is not configured for api=%s", api.Name)
+	}
+	pagination := *api.Pagination
+	switch pagination.Type {
+	case PaginationTypeOffset, PaginationTypePage, PaginationTypeLink:
+	case PaginationTypeCursor:
+		if pagination.CursorPath == "" {
+			return nil, errors.New("cursor_path is required for cursor pagination: api=%s", api.Name)
+		}
+	default:
+		return nil, errors.New("unknown pagination type: api=%s, type=%s", api.Name, pagination.Type)
+	}
+	if pagination.LimitParam == "" {
+		pagination.LimitParam = "limit"
+	}
+	if pagination.OffsetParam == "" {
+		pagination.OffsetParam = "offset"
+	}
+	if pagination.PageParam == "" {
+		pagination.PageParam = "page"
+	}
+	if pagination.CursorParam == "" {
+		pagination.CursorParam = "cursor"
+	}
+	if pagination.StartPage <= 0 {
+		pagination.StartPage = 1
+	}
+
+	if request == nil {
+		request = &GoxRequest{}
+	}
+	return &pageIterator{
+		ctx:        ctx,
+		pagination: &pagination,
+		request:    request,
+		execute:    execute,
+		page:       pagination.StartPage,
+	}, nil
+}
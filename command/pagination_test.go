@@ -0,0 +1,217 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// List of 0..total-1 - served with given page params
+func paginationTestHandler(total int, requests *[]MultivaluedMap, body func(request *GoxRequest, items []int) (string, http.Header)) Handler {
+	all := make([]int, total)
+	for i := range all {
+		all[i] = i
+	}
+	return func(ctx context.Context, request *GoxRequest) (*GoxResponse, error) {
+		*requests = append(*requests, request.QueryParam)
+		start, limit := 0, 2
+		if v, ok := request.QueryParam["offset"]; ok {
+			start, _ = strconv.Atoi(v[0])
+		}
+		if v, ok := request.QueryParam["page"]; ok {
+			page, _ := strconv.Atoi(v[0])
+			start = (page - 1) * limit
+		}
+		if v, ok := request.QueryParam["cursor"]; ok {
+			start, _ = strconv.Atoi(v[0])
+		}
+		end := start + limit
+		if end > total {
+			end = total
+		}
+		if start > total {
+			start = total
+		}
+		b, header := body(request, all[start:end])
+		return &GoxResponse{StatusCode: http.StatusOK, Body: []byte(b), Header: header}, nil
+	}
+}
+
+func readAllItems(t *testing.T, iterator PageIterator) []int {
+	items := make([]int, 0)
+	for {
+		var item int
+		err := iterator.NextItem(&item)
+		if err == io.EOF {
+			return items
+		}
+		assert.NoError(t, err)
+		items = append(items, item)
+	}
+}
+
+func TestPageIterator_OffsetAndPage(t *testing.T) {
+	requests := make([]MultivaluedMap, 0)
+	handler := paginationTestHandler(5, &requests, func(request *GoxRequest, items []int) (string, http.Header) {
+		b, _ := json.Marshal(map[string]interface{}{"data": map[string]interface{}{"items": items}})
+		return string(b), nil
+	})
+
+	api := &Api{Name: "list", Pagination: &Pagination{Type: PaginationTypeOffset, Limit: 2, ItemsPath: "data.items"}}
+	iterator, err := NewPageIterator(context.Background(), api, &GoxRequest{QueryParam: MultivaluedMap{"q": {"x"}}}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, readAllItems(t, iterator))
+	assert.Equal(t, 3, len(requests))
+	assert.Equal(t, MultivaluedMap{"q": {"x"}, "limit": {"2"}, "offset": {"4"}}, requests[2])
+
+	requests = requests[:0]
+	api.Pagination = &Pagination{Type: PaginationTypePage, Limit: 2, StartPage: 1, ItemsPath: "data.items"}
+	iterator, err = NewPageIterator(context.Background(), api, nil, handler)
+	assert.NoError(t, err)
+	page, err := iterator.NextPage()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Number)
+	assert.Equal(t, 2, len(page.Items))
+	assert.Equal(t, []int{2, 3, 4}, readAllItems(t, iterator))
+	assert.Equal(t, "3", requests[2]["page"][0])
+
+	// Exact multiple of limit - last page is empty
+	requests = requests[:0]
+	handler = paginationTestHandler(4, &requests, func(request *GoxRequest, items []int) (string, http.Header) {
+		b, _ := json.Marshal(items)
+		return string(b), nil
+	})
+	api.Pagination = &Pagination{Type: PaginationTypePage, Limit: 2, StartPage: 1}
+	iterator, err = NewPageIterator(context.Background(), api, nil, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3}, readAllItems(t, iterator))
+	assert.Equal(t, 3, len(requests))
+}
+
+func TestPageIterator_DefaultsOfConfigBuiltInCode(t *testing.T) {
+	requests := make([]MultivaluedMap, 0)
+	handler := paginationTestHandler(5, &requests, func(request *GoxRequest, items []int) (string, http.Header) {
+		b, _ := json.Marshal(items)
+		return string(b), nil
+	})
+
+	// Start page is 1 if it is not set
+	api := &Api{Name: "list", Pagination: &Pagination{Type: PaginationTypePage, Limit: 2}}
+	iterator, err := NewPageIterator(context.Background(), api, nil, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, readAllItems(t, iterator))
+	assert.Equal(t, "1", requests[0]["page"][0])
+
+	// No limit and no items array - first page is the last page
+	for _, paginationType := range []string{PaginationTypeOffset, PaginationTypePage} {
+		requests = requests[:0]
+		handler = paginationTestHandler(5, &requests, func(request *GoxRequest, items []int) (string, http.Header) {
+			return `{"status": "ok"}`, nil
+		})
+		api = &Api{Name: "list", Pagination: &Pagination{Type: paginationType}}
+		iterator, err = NewPageIterator(context.Background(), api, nil, handler)
+		assert.NoError(t, err)
+		page, err := iterator.NextPage()
+		assert.NoError(t, err)
+		assert.Nil(t, page.Items)
+		_, err = iterator.NextPage()
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, 1, len(requests))
+	}
+}
+
+func TestPageIterator_CursorAndLink(t *testing.T) {
+	requests := make([]MultivaluedMap, 0)
+	handler := paginationTestHandler(5, &requests, func(request *GoxRequest, items []int) (string, http.Header) {
+		var next interface{}
+		if len(items) > 0 && items[len(items)-1] < 4 {
+			next = items[len(items)-1] + 1
+		}
+		b, _ := json.Marshal(map[string]interface{}{"items": items, "meta": map[string]interface{}{"next": next}})
+		return string(b), nil
+	})
+	api := &Api{Name: "list", Pagination: &Pagination{Type: PaginationTypeCursor, CursorParam: "cursor", CursorPath: "meta.next", ItemsPath: "items"}}
+	iterator, err := NewPageIterator(context.Background(), api, nil, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, readAllItems(t, iterator))
+	assert.Equal(t, 3, len(requests))
+	assert.Nil(t, requests[0]["cursor"])
+	assert.Equal(t, "4", requests[2]["cursor"][0])
+
+	requests = requests[:0]
+	handler = paginationTestHandler(5, &requests, func(request *GoxRequest, items []int) (string, http.Header) {
+		b, _ := json.Marshal(items)
+		header := http.Header{}
+		if len(items) > 0 && items[len(items)-1] < 4 {
+			header.Add("Link", `<https://example.com/list?page=1>; rel="first"`)
+			header.Add("Link", fmt.Sprintf(`<https://example.com/list?offset=%d&limit=2>; rel="next", <https://example.com/list?offset=4>; rel="last"`, items[len(items)-1]+1))
+		}
+		return string(b), header
+	})
+	api.Pagination = &Pagination{Type: PaginationTypeLink, MaxPages: 2}
+	iterator, err = NewPageIterator(context.Background(), api, nil, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3}, readAllItems(t, iterator))
+	assert.Equal(t, 2, len(requests))
+	assert.Equal(t, MultivaluedMap{"offset": {"2"}, "limit": {"2"}}, requests[1])
+}
+
+func TestPageIterator_NumberCursorIsSentAsItIs(t *testing.T) {
+	requests := make([]MultivaluedMap, 0)
+	handler := func(ctx context.Context, request *GoxRequest) (*GoxResponse, error) {
+		requests = append(requests, request.QueryParam)
+		if len(requests) == 1 {
+			return &GoxResponse{StatusCode: http.StatusOK, Body: []byte(`{"items": [1], "next": 12345678}`)}, nil
+		}
+		return &GoxResponse{StatusCode: http.StatusOK, Body: []byte(`{"items": [2], "next": null}`)}, nil
+	}
+	api := &Api{Name: "list", Pagination: &Pagination{Type: PaginationTypeCursor, CursorPath: "next", ItemsPath: "items"}}
+	iterator, err := NewPageIterator(context.Background(), api, nil, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, readAllItems(t, iterator))
+	assert.Equal(t, 2, len(requests))
+	assert.Equal(t, "12345678", requests[1]["cursor"][0])
+}
+
+func TestPageIterator_StopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	requests := make([]MultivaluedMap, 0)
+	handler := paginationTestHandler(10, &requests, func(request *GoxRequest, items []int) (string, http.Header) {
+		b, _ := json.Marshal(items)
+		return string(b), nil
+	})
+	api := &Api{Name: "list", Pagination: &Pagination{Type: PaginationTypeOffset, Limit: 2}}
+	iterator, err := NewPageIterator(ctx, api, nil, handler)
+	assert.NoError(t, err)
+
+	_, err = iterator.NextPage()
+	assert.NoError(t, err)
+	cancel()
+	_, err = iterator.NextPage()
+	assert.Error(t, err)
+	assert.Equal(t, 1, len(requests))
+
+	_, err = NewPageIterator(ctx, &Api{Name: "list"}, nil, handler)
+	assert.Error(t, err)
+	_, err = NewPageIterator(ctx, &Api{Name: "list", Pagination: &Pagination{Type: PaginationTypeCursor}}, nil, handler)
+	assert.Error(t, err)
+}
+
+func TestNextLinkAndJsonPath(t *testing.T) {
+	assert.Equal(t, "/items?page=2", NextLink([]string{`</items?page=1>; rel="prev", </items?page=2>; rel="next"`}))
+	assert.Equal(t, "/items?page=3", NextLink([]string{`</items?page=3>; title="x"; rel="last next"`}))
+	assert.Equal(t, "", NextLink([]string{`</items?page=1>; rel="prev"`}))
+
+	raw, ok := JsonPathLookup([]byte(`{"data": [{"id": 1}, {"id": 2}]}`), "data.1.id")
+	assert.True(t, ok)
+	assert.Equal(t, "2", string(raw))
+	_, ok = JsonPathLookup([]byte(`{"data": [{"id": 1}]}`), "data.5.id")
+	assert.False(t, ok)
+	_, ok = JsonPathLookup([]byte(`{"data": {}}`), "data.items")
+	assert.False(t, ok)
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"github.com/devlibx/gox-base/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	ContentTypeProtobuf  = "application/protobuf"
+	ContentTypeXProtobuf = "application/x-protobuf"
+)
+
+// Protobuf codec - works only with proto.Message (bytes are sent as they are)
+type protobufCodec struct {
+}
+
+func (p *protobufCodec) Marshal(in interface{}) ([]byte, error) {
+	switch v := in.(type) {
+	case proto.Message:
+		return proto.Marshal(v)
+	case []byte:
+		return v, nil
+	}
+	return nil, errors.New("protobuf body must be a proto.Message, got %T", in)
+}
+
+func (p *protobufCodec) Unmarshal(data []byte, out interface{}) error {
+	if message, ok := out.(proto.Message); ok {
+		return proto.Unmarshal(data, message)
+	}
+	return errors.New("protobuf response can only be read into a proto.Message, got %T", out)
+}
+
+// DefaultContentType gives the content type to use for a body (or response target) when it is not set in request or
+// api config - protobuf for proto.Message and json for everything else
+func DefaultContentType(body interface{}) string {
+	if _, ok := body.(proto.Message); ok {
+		return ContentTypeXProtobuf
+	}
+	return ContentTypeJson
+}
+
+// GetErrorProtoType finds the message type given in "error_proto" of this api. Returns nil if it is not set
+func (a *Api) GetErrorProtoType() (protoreflect.MessageType, error) {
+	if a.ErrorProto == "" {
+		return nil, nil
+	}
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(a.ErrorProto))
+	if err != nil {
+		return nil, errors.Wrap(err, "error_proto message is not registered: api=%s, error_proto=%s", a.Name, a.ErrorProto)
+	}
+	return messageType, nil
+}
+
+// DecodeErrorBody decodes an error response into a new message of given type, using the codec for response content
+// type (protobuf if response has no content type). Returns nil if it can't be decoded
+func DecodeErrorBody(messageType protoreflect.MessageType, contentType string, body []byte) proto.Message {
+	if messageType == nil || len(body) == 0 {
+		return nil
+	}
+	if contentType == "" {
+		contentType = ContentTypeXProtobuf
+	}
+	codec, ok := FindCodec(contentType)
+	if !ok {
+		return nil
+	}
+	message := messageType.New().Interface()
+	if err := codec.Unmarshal(body, message); err != nil {
+		return nil
+	}
+	return message
+}
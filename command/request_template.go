@@ -0,0 +1,190 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-base/serialization"
+	"strings"
+	"text/template"
+)
+
+// Types of body template
+const (
+	BodyTemplateTypeJson = "json"
+	BodyTemplateTypeText = "text"
+)
+
+// Functions available to query and body templates
+var requestTemplateFuncs = template.FuncMap{
+
+	// Encode a value as json e.g. {{ json (.Param "items") }} - a param which is already encoded is used as it is
+	"json": func(value interface{}) (string, error) {
+		if encoded, ok := value.(jsonParam); ok {
+			return string(encoded), nil
+		}
+		b, err := json.Marshal(value)
+		return string(b), err
+	},
+}
+
+// jsonParam is a template param encoded as json - params are encoded by default in a "json" body template, so that a
+// param value can't change the structure of the body
+type jsonParam string
+
+// Data available to query and body templates - it has Ctx and Env of header templates, and request template params
+type requestTemplateData struct {
+	headerTemplateData
+	params     map[string]interface{}
+	jsonParams bool
+}
+
+// Param returns a template param of the request - template fails if the param is missing
+func (d *requestTemplateData) Param(name string) (interface{}, error) {
+	value, ok := d.params[name]
+	if !ok {
+		return nil, errors.New("template param is missing: name=%s", name)
+	}
+	return d.value(value)
+}
+
+// ParamOr returns a template param of the request, or the given default value if the param is missing
+func (d *requestTemplateData) ParamOr(name string, defaultValue interface{}) (interface{}, error) {
+	if value, ok := d.params[name]; ok {
+		return d.value(value)
+	}
+	return d.value(defaultValue)
+}
+
+// Value of param as it is, or encoded as json in a "json" body template
+func (d *requestTemplateData) value(value interface{}) (interface{}, error) {
+	if !d.jsonParams {
+		return value, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode template param as json")
+	}
+	return jsonParam(b), nil
+}
+
+// Has returns true if request has the template param
+func (d *requestTemplateData) Has(name string) bool {
+	_, ok := d.params[name]
+	return ok
+}
+
+// RequestTemplate holds "query_params" and "body_template" of an api. These are go templates which are filled from
+// GoxRequest.TemplateParams e.g. "{{ .Param "user_id" }}". In a "json" body template params are encoded as json. Path
+// params ({name} in path) which are not set in request are also filled from the template params
+type RequestTemplate struct {
+	api        *Api
+	pathParams []string
+	query      map[string]*template.Template
+	body       *template.Template
+	bodyType   string
+}
+
+// NewRequestTemplate parses query and body templates of the api. Returns nil if api has no templates and no path params
+func NewRequestTemplate(api *Api) (*RequestTemplate, error) {
+	pathParams := api.GetPathParamNames()
+	if len(api.QueryParams) == 0 && api.BodyTemplate == "" && len(pathParams) == 0 {
+		return nil, nil
+	}
+
+	t := &RequestTemplate{api: api, pathParams: pathParams, query: map[string]*template.Template{}}
+
+	for name, value := range api.QueryParams {
+		q, err := template.New(name).Funcs(requestTemplateFuncs).Parse(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse query param template: api=%s, param=%s", api.Name, name)
+		}
+		t.query[name] = q
+	}
+
+	if api.BodyTemplate != "" {
+		switch t.bodyType = strings.ToLower(strings.TrimSpace(api.BodyTemplateType)); t.bodyType {
+		case "":
+			t.bodyType = BodyTemplateTypeJson
+		case BodyTemplateTypeJson, BodyTemplateTypeText:
+		default:
+			return nil, errors.New("unknown body_template_type: api=%s, type=%s", api.Name, api.BodyTemplateType)
+		}
+		body, err := template.New("body").Funcs(requestTemplateFuncs).Parse(api.BodyTemplate)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse body template: api=%s", api.Name)
+		}
+		t.body = body
+	}
+	return t, nil
+}
+
+// HasBody returns true if api has a body template
+func (t *RequestTemplate) HasBody() bool {
+	return t != nil && t.body != nil
+}
+
+// BodyType gives the type of body template - "json" or "text"
+func (t *RequestTemplate) BodyType() string {
+	return t.bodyType
+}
+
+// PathParams gives values of path params which are not set in the request, from template params of the request
+func (t *RequestTemplate) PathParams(request *GoxRequest) map[string]string {
+	values := map[string]string{}
+	for _, name := range t.pathParams {
+		if _, ok := request.PathParam[name]; ok {
+			continue
+		}
+		if value, ok := request.TemplateParams[name]; ok {
+			values[name] = templateParamString(value)
+		}
+	}
+	return values
+}
+
+// QueryParams evaluates the query param templates. A query param which evaluates to empty string is not added
+func (t *RequestTemplate) QueryParams(ctx context.Context, request *GoxRequest) (MultivaluedMap, error) {
+	data := t.data(ctx, request)
+	values := MultivaluedMap{}
+	for name, q := range t.query {
+		sb := strings.Builder{}
+		if err := q.Execute(&sb, data); err != nil {
+			return nil, errors.Wrap(err, "failed to build query param from template: api=%s, param=%s", t.api.Name, name)
+		}
+		if value := sb.String(); value != "" {
+			values[name] = []string{value}
+		}
+	}
+	return values, nil
+}
+
+// Body evaluates the body template. For a json template, result must be a valid json
+func (t *RequestTemplate) Body(ctx context.Context, request *GoxRequest) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	data := t.data(ctx, request)
+	data.jsonParams = t.bodyType == BodyTemplateTypeJson
+	if err := t.body.Execute(buf, data); err != nil {
+		return nil, errors.Wrap(err, "failed to build body from template: api=%s", t.api.Name)
+	}
+	if t.bodyType == BodyTemplateTypeJson && !json.Valid(buf.Bytes()) {
+		return nil, errors.New("body template did not give a valid json: api=%s, body=%s", t.api.Name, buf.String())
+	}
+	return buf.Bytes(), nil
+}
+
+func (t *RequestTemplate) data(ctx context.Context, request *GoxRequest) *requestTemplateData {
+	return &requestTemplateData{headerTemplateData: headerTemplateData{ctx: ctx}, params: request.TemplateParams}
+}
+
+func templateParamString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	return serialization.StringifySuppressError(value, "")
+}
@@ -0,0 +1,97 @@
+package command
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRequestTemplate(t *testing.T) {
+	api := &Api{
+		Name: "createUser",
+		Path: "/tenants/{tenant}/users",
+		QueryParams: map[string]string{
+			"source": `{{ .ParamOr "source" "web" }}`,
+			"trace":  `{{ if .Has "trace" }}{{ .Param "trace" }}{{ end }}`,
+		},
+		BodyTemplate: `{"name": {{ json (.Param "name") }}, "roles": {{ json (.ParamOr "roles" (list)) }}}`,
+	}
+	_, err := NewRequestTemplate(api)
+	assert.Error(t, err, "list function is not defined")
+
+	// Params are encoded as json in a json template - "json" function gives the same result
+	api.BodyTemplate = `{"name": {{ .Param "name" }}, "age": {{ .Param "age" }}, "roles": {{ json (.ParamOr "roles" "none") }}}`
+	requestTemplate, err := NewRequestTemplate(api)
+	assert.NoError(t, err)
+	assert.Equal(t, BodyTemplateTypeJson, requestTemplate.BodyType())
+
+	request := NewGoxRequestBuilder("createUser").
+		WithTemplateParam("tenant", "t1").
+		WithTemplateParams(map[string]interface{}{"name": `John", "admin": "true`, "age": 10}).
+		Build()
+	query, err := requestTemplate.QueryParams(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, MultivaluedMap{"source": {"web"}}, query)
+	assert.Equal(t, map[string]string{"tenant": "t1"}, requestTemplate.PathParams(request))
+
+	body, err := requestTemplate.Body(context.Background(), request)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name": "John\", \"admin\": \"true", "age": 10, "roles": "none"}`, string(body))
+
+	// Path param set in request is not overridden
+	request.PathParam = MultivaluedMap{"tenant": {"t2"}}
+	assert.Equal(t, map[string]string{}, requestTemplate.PathParams(request))
+
+	// Missing param fails the template
+	delete(request.TemplateParams, "age")
+	_, err = requestTemplate.Body(context.Background(), request)
+	assert.Error(t, err)
+
+	// Json template must give a valid json
+	request.TemplateParams["age"] = "ten"
+	api.BodyTemplate = `{"name": {{ .Param "name" }}, "age": {{ .Param "age" }}`
+	requestTemplate, err = NewRequestTemplate(api)
+	assert.NoError(t, err)
+	_, err = requestTemplate.Body(context.Background(), request)
+	assert.Error(t, err)
+
+	// Text template is sent as it is - params are not encoded
+	request.TemplateParams["name"] = `John "H"`
+	api.BodyTemplate = `{"name": {{ json (.Param "name") }}, "age": {{ .Param "age" }}}`
+	api.BodyTemplateType = BodyTemplateTypeText
+	requestTemplate, err = NewRequestTemplate(api)
+	assert.NoError(t, err)
+	body, err = requestTemplate.Body(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "John \"H\"", "age": ten}`, string(body))
+
+	api.BodyTemplateType = "yaml"
+	_, err = NewRequestTemplate(api)
+	assert.Error(t, err)
+}
+
+func TestParseConfig_RequestTemplateIsValidated(t *testing.T) {
+	config := Config{}
+	err := serialization.ReadYamlFromString(`
+apis:
+  createUser:
+    method: POST
+    path: /users
+    query_params:
+      source: '{{ .ParamOr "source" "web" }}'
+    body_template: '{"name": {{ json (.Param "name") }}}'
+`, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, `{{ .ParamOr "source" "web" }}`, config.Apis["createUser"].QueryParams["source"])
+	assert.Equal(t, `{"name": {{ json (.Param "name") }}}`, config.Apis["createUser"].BodyTemplate)
+
+	err = serialization.ReadYamlFromString(`
+apis:
+  createUser:
+    method: POST
+    path: /users
+    body_template: '{"name": {{ .Param "name" }'
+`, &Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,265 @@
+package command
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/devlibx/gox-base/errors"
+	"github.com/devlibx/gox-base/util"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SigningTypeNone = "none"
+	SigningTypeHmac = "hmac"
+)
+
+const DefaultCanonicalizerName = "default"
+
+// Signer signs the final http request. It is called before every attempt (including retries) so timestamp and nonce
+// are fresh for every attempt
+type Signer interface {
+	Sign(request *http.Request, body []byte) error
+}
+
+// Canonicalizer builds the string which is signed. Register your own implementation with RegisterCanonicalizer() to
+// support vendor specific signing schemes, and use it with "canonicalizer: <name>" in signing config
+type Canonicalizer interface {
+	Canonicalize(request *http.Request, body []byte, signedHeaders []string, timestamp string, nonce string) (string, error)
+}
+
+// CanonicalizerFunc allows a plain function to be used as a Canonicalizer
+type CanonicalizerFunc func(request *http.Request, body []byte, signedHeaders []string, timestamp string, nonce string) (string, error)
+
+func (f CanonicalizerFunc) Canonicalize(request *http.Request, body []byte, signedHeaders []string, timestamp string, nonce string) (string, error) {
+	return f(request, body, signedHeaders, timestamp, nonce)
+}
+
+var canonicalizers = map[string]Canonicalizer{DefaultCanonicalizerName: CanonicalizerFunc(DefaultCanonicalize)}
+var canonicalizersLock = &sync.RWMutex{}
+
+// RegisterCanonicalizer registers a canonicalizer which can be used by name in signing config
+func RegisterCanonicalizer(name string, canonicalizer Canonicalizer) {
+	canonicalizersLock.Lock()
+	defer canonicalizersLock.Unlock()
+	canonicalizers[name] = canonicalizer
+}
+
+func findCanonicalizer(name string) (Canonicalizer, bool) {
+	canonicalizersLock.RLock()
+	defer canonicalizersLock.RUnlock()
+	c, ok := canonicalizers[name]
+	return c, ok
+}
+
+// DefaultCanonicalize builds a string with following lines (joined by "\n"):
+//
+//	METHOD
+//	escaped path
+//	query params sorted by name and value
+//	one "name:value" line for each signed header (lower case name, in the configured order)
+//	timestamp
+//	nonce
+//	hex encoded sha256 of body
+func DefaultCanonicalize(request *http.Request, body []byte, signedHeaders []string, timestamp string, nonce string) (string, error) {
+	lines := []string{strings.ToUpper(request.Method), request.URL.EscapedPath()}
+
+	query := request.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	params := make([]string, 0)
+	for _, k := range keys {
+		values := append([]string{}, query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, escapeQuery(k)+"="+escapeQuery(v))
+		}
+	}
+	lines = append(lines, strings.Join(params, "&"))
+
+	for _, name := range signedHeaders {
+		value := request.Header.Get(name)
+		if strings.EqualFold(name, "host") {
+			value = request.Host
+			if value == "" {
+				value = request.URL.Host
+			}
+		}
+		lines = append(lines, strings.ToLower(name)+":"+strings.TrimSpace(value))
+	}
+
+	bodyHash := sha256.Sum256(body)
+	lines = append(lines, timestamp, nonce, hex.EncodeToString(bodyHash[:]))
+	return strings.Join(lines, "\n"), nil
+}
+
+func escapeQuery(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// hmacSigner signs the request with HMAC of the canonical string
+type hmacSigner struct {
+	signing       *Signing
+	hash          func() hash.Hash
+	signedHeaders []string
+	canonicalizer Canonicalizer
+}
+
+func (s *hmacSigner) Sign(request *http.Request, body []byte) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+
+	canonical, err := s.canonicalizer.Canonicalize(request, body, s.signedHeaders, timestamp, nonce)
+	if err != nil {
+		return errors.Wrap(err, "failed to build canonical string to sign")
+	}
+
+	mac := hmac.New(s.hash, []byte(s.signing.Secret))
+	mac.Write([]byte(canonical))
+	var signature string
+	if s.signing.Encoding == "base64" {
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	} else {
+		signature = hex.EncodeToString(mac.Sum(nil))
+	}
+
+	request.Header.Set(s.signing.TimestampHeader, timestamp)
+	request.Header.Set(s.signing.NonceHeader, nonce)
+	request.Header.Set(s.signing.SignatureHeader, signature)
+	if !util.IsStringEmpty(s.signing.KeyId) {
+		request.Header.Set(s.signing.KeyIdHeader, s.signing.KeyId)
+	}
+	return nil
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate nonce")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ReadBodyForSigning reads the body of the request without consuming it
+func ReadBodyForSigning(request *http.Request) ([]byte, error) {
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	} else if request.Body != nil && request.Body != http.NoBody {
+		data, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = request.Body.Close()
+		request.Body = ioutil.NopCloser(bytes.NewReader(data))
+		return data, nil
+	}
+	return []byte{}, nil
+}
+
+// GetSigning returns the signing config to be used for this api (api signing overrides server signing)
+func (a *Api) GetSigning(server *Server) *Signing {
+	var signing *Signing
+	if a.Signing != nil {
+		signing = a.Signing
+	} else if server != nil {
+		signing = server.Signing
+	}
+	if signing == nil || util.IsStringEmpty(signing.Type) || strings.EqualFold(strings.TrimSpace(signing.Type), SigningTypeNone) {
+		return nil
+	}
+	return signing
+}
+
+func (s *Signing) setupDefaults() {
+	s.Type = strings.ToLower(strings.TrimSpace(s.Type))
+	s.Algorithm = strings.ToLower(strings.TrimSpace(s.Algorithm))
+	if s.Algorithm == "" {
+		s.Algorithm = "sha256"
+	}
+	if s.Encoding == "" {
+		s.Encoding = "hex"
+	}
+	if s.SignatureHeader == "" {
+		s.SignatureHeader = "X-Signature"
+	}
+	if s.KeyIdHeader == "" {
+		s.KeyIdHeader = "X-Key-Id"
+	}
+	if s.TimestampHeader == "" {
+		s.TimestampHeader = "X-Timestamp"
+	}
+	if s.NonceHeader == "" {
+		s.NonceHeader = "X-Nonce"
+	}
+	if s.Canonicalizer == "" {
+		s.Canonicalizer = DefaultCanonicalizerName
+	}
+}
+
+// NewSigner creates a signer from the signing config. Returns nil if signing is not enabled
+func NewSigner(signing *Signing) (Signer, error) {
+	if signing == nil {
+		return nil, nil
+	}
+
+	resolved := *signing
+	resolved.setupDefaults()
+	if resolved.Type == SigningTypeNone {
+		return nil, nil
+	} else if resolved.Type != SigningTypeHmac {
+		return nil, errors.New("unknown signing type: type=%s", resolved.Type)
+	}
+
+	var err error
+	if resolved.Secret, err = ResolveSecret(resolved.Secret); err != nil {
+		return nil, errors.Wrap(err, "failed to resolve signing secret")
+	}
+
+	s := &hmacSigner{signing: &resolved, signedHeaders: make([]string, 0)}
+	switch resolved.Algorithm {
+	case "sha1":
+		s.hash = sha1.New
+	case "sha256":
+		s.hash = sha256.New
+	case "sha512":
+		s.hash = sha512.New
+	default:
+		return nil, errors.New("unknown signing algorithm: algorithm=%s", resolved.Algorithm)
+	}
+
+	var ok bool
+	if s.canonicalizer, ok = findCanonicalizer(resolved.Canonicalizer); !ok {
+		return nil, errors.New("canonicalizer not registered: name=%s", resolved.Canonicalizer)
+	}
+
+	for _, h := range strings.Split(resolved.SignedHeaders, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			s.signedHeaders = append(s.signedHeaders, h)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,228 @@
+package command
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/devlibx/gox-base/errors"
+	"strings"
+)
+
+// SOAP versions and their envelope namespaces
+const (
+	SoapVersion11           = "1.1"
+	SoapVersion12           = "1.2"
+	SoapEnvelopeNamespace11 = "http://schemas.xmlsoap.org/soap/envelope/"
+	SoapEnvelopeNamespace12 = "http://www.w3.org/2003/05/soap-envelope"
+	ContentTypeSoap12       = "application/soap+xml"
+)
+
+// SOAP config of an api with "type: soap"
+// ****************************************************************************************
+// IMP NOTE - "config_parser.go -> UnmarshalYAML() method is created to do custom parsing.
+// If you change anything here (add/update/delete) you must make changes in UnmarshalYAML()
+// ****************************************************************************************
+type Soap struct {
+
+	// SOAPAction of the operation - request can override it
+	Action string `yaml:"action"`
+
+	// SOAP version - "1.1" (default) or "1.2"
+	Version string `yaml:"version"`
+
+	// Namespace of the body element - it is set as default namespace (xmlns) of the body element if it does not have one
+	Namespace string `yaml:"namespace"`
+}
+
+// SoapFault is the "Fault" returned by a SOAP server. Code, String, Actor and Detail are read from
+// faultcode/faultstring/faultactor/detail (SOAP 1.1) or Code/Reason/Role/Detail (SOAP 1.2)
+type SoapFault struct {
+	Code   string
+	String string
+	Actor  string
+
+	// Raw xml inside the detail element
+	Detail string
+}
+
+func (f *SoapFault) Error() string {
+	return fmt.Sprintf("soap fault: code=%s, string=%s", f.Code, f.String)
+}
+
+// Fault element with fields of both SOAP 1.1 and 1.2
+type soapFaultXml struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	FaultActor  string `xml:"faultactor"`
+	Detail11    struct {
+		Content string `xml:",innerxml"`
+	} `xml:"detail"`
+	Code struct {
+		Value string `xml:"Value"`
+	} `xml:"Code"`
+	Reason struct {
+		Text []string `xml:"Text"`
+	} `xml:"Reason"`
+	Role     string `xml:"Role"`
+	Detail12 struct {
+		Content string `xml:",innerxml"`
+	} `xml:"Detail"`
+}
+
+type soapEnvelopeXml struct {
+	Body struct {
+		Content []byte `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+// IsSoap returns true if this is a "type: soap" api
+func (a *Api) IsSoap() bool {
+	return a.Type == ApiTypeSoap
+}
+
+// GetSoapVersion gives the SOAP version of this api - returns error if version is not known
+func (a *Api) GetSoapVersion() (string, error) {
+	if !a.IsSoap() || a.Soap == nil || a.Soap.Version == "" {
+		return SoapVersion11, nil
+	}
+	switch a.Soap.Version {
+	case SoapVersion11, SoapVersion12:
+		return a.Soap.Version, nil
+	}
+	return "", errors.New("unknown soap version: api=%s, version=%s", a.Name, a.Soap.Version)
+}
+
+// SoapContentType gives the Content-Type of a request for given version. SOAP 1.2 sends the action in Content-Type
+func SoapContentType(version string, action string) string {
+	if version == SoapVersion12 {
+		if action != "" {
+			return fmt.Sprintf("%s; charset=utf-8; action=%q", ContentTypeSoap12, action)
+		}
+		return ContentTypeSoap12 + "; charset=utf-8"
+	}
+	return ContentTypeTextXml + "; charset=utf-8"
+}
+
+// BuildSoapEnvelope wraps the body (and optional header) in a SOAP envelope. Body and header can be []byte (xml) or an
+// object which is serialized with encoding/xml
+func BuildSoapEnvelope(version string, namespace string, header interface{}, body interface{}) ([]byte, error) {
+	envelopeNamespace := SoapEnvelopeNamespace11
+	if version == SoapVersion12 {
+		envelopeNamespace = SoapEnvelopeNamespace12
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<soap:Envelope xmlns:soap="` + envelopeNamespace + `">`)
+	if header != nil {
+		headerXml, err := soapXml(header, "")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to serialize soap header")
+		}
+		buf.WriteString("<soap:Header>")
+		buf.Write(headerXml)
+		buf.WriteString("</soap:Header>")
+	}
+	buf.WriteString("<soap:Body>")
+	if body != nil {
+		bodyXml, err := soapXml(body, namespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to serialize soap body")
+		}
+		buf.Write(bodyXml)
+	}
+	buf.WriteString("</soap:Body></soap:Envelope>")
+	return buf.Bytes(), nil
+}
+
+// Serialize the object to xml, and set namespace as default namespace of the root element if it does not have one
+func soapXml(object interface{}, namespace string) ([]byte, error) {
+	var data []byte
+	if b, ok := object.([]byte); ok {
+		data = bytes.TrimSpace(b)
+	} else if s, ok := object.(string); ok {
+		data = bytes.TrimSpace([]byte(s))
+	} else {
+		var err error
+		if data, err = xml.Marshal(object); err != nil {
+			return nil, err
+		}
+	}
+
+	// Xml declaration is not allowed inside the envelope
+	if bytes.HasPrefix(data, []byte("<?xml")) {
+		if i := bytes.Index(data, []byte("?>")); i >= 0 {
+			data = bytes.TrimSpace(data[i+2:])
+		}
+	}
+	if namespace == "" || len(data) == 0 || data[0] != '<' {
+		return data, nil
+	}
+
+	end := bytes.IndexAny(data, " \t\r\n/>")
+	rootEnd := bytes.IndexByte(data, '>')
+	if end < 0 || rootEnd < 0 || bytes.Contains(data[:rootEnd], []byte("xmlns=")) {
+		return data, nil
+	}
+	escaped := &strings.Builder{}
+	_ = xml.EscapeText(escaped, []byte(namespace))
+	result := make([]byte, 0, len(data)+len(namespace)+10)
+	result = append(result, data[:end]...)
+	result = append(result, ` xmlns="`+escaped.String()+`"`...)
+	return append(result, data[end:]...), nil
+}
+
+// DecodeSoapResponse gives the content of the envelope body. If body has a Fault, it is returned as SoapFault
+func DecodeSoapResponse(data []byte) ([]byte, *SoapFault, error) {
+	envelope := &soapEnvelopeXml{}
+	if err := xml.Unmarshal(data, envelope); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to decode soap envelope")
+	}
+	content := bytes.TrimSpace(envelope.Body.Content)
+
+	// Find the first element in body to see if this is a fault
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return content, nil, nil
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "Fault" {
+			return content, nil, nil
+		}
+
+		faultXml := &soapFaultXml{}
+		if err := decoder.DecodeElement(faultXml, &start); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to decode soap fault")
+		}
+		fault := &SoapFault{
+			Code:   strings.TrimSpace(faultXml.FaultCode),
+			String: strings.TrimSpace(faultXml.FaultString),
+			Actor:  strings.TrimSpace(faultXml.FaultActor),
+			Detail: strings.TrimSpace(faultXml.Detail11.Content),
+		}
+		if fault.Code == "" {
+			fault.Code = strings.TrimSpace(faultXml.Code.Value)
+		}
+		if fault.String == "" && len(faultXml.Reason.Text) > 0 {
+			fault.String = strings.TrimSpace(faultXml.Reason.Text[0])
+		}
+		if fault.Actor == "" {
+			fault.Actor = strings.TrimSpace(faultXml.Role)
+		}
+		if fault.Detail == "" {
+			fault.Detail = strings.TrimSpace(faultXml.Detail12.Content)
+		}
+		return content, fault, nil
+	}
+}
+
+// SoapFault gives the fault returned by a SOAP server (nil if this is not a SOAP fault)
+func (e *GoxHttpError) SoapFault() *SoapFault {
+	fault, _ := e.DecodedBody.(*SoapFault)
+	return fault
+}
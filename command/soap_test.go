@@ -0,0 +1,55 @@
+package command
+
+import (
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type soapTestRequest struct {
+	XMLName xml.Name `xml:"GetPrice"`
+	Item    string   `xml:"Item"`
+}
+
+func TestBuildSoapEnvelope(t *testing.T) {
+	data, err := BuildSoapEnvelope(SoapVersion11, "http://example.com/prices", nil, soapTestRequest{Item: "apple"})
+	assert.NoError(t, err)
+	assert.Equal(t, xml.Header+`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>`+
+		`<GetPrice xmlns="http://example.com/prices"><Item>apple</Item></GetPrice></soap:Body></soap:Envelope>`, string(data))
+
+	// Xml body which already has a namespace is sent as is (without xml declaration)
+	data, err = BuildSoapEnvelope(SoapVersion12, "http://example.com/prices", []byte(`<auth><token>t</token></auth>`),
+		[]byte(`<?xml version="1.0"?><m:GetPrice xmlns:m="urn:m" xmlns="urn:other"><m:Item>apple</m:Item></m:GetPrice>`))
+	assert.NoError(t, err)
+	assert.Equal(t, xml.Header+`<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Header><auth><token>t</token></auth></soap:Header>`+
+		`<soap:Body><m:GetPrice xmlns:m="urn:m" xmlns="urn:other"><m:Item>apple</m:Item></m:GetPrice></soap:Body></soap:Envelope>`, string(data))
+}
+
+func TestDecodeSoapResponse(t *testing.T) {
+	content, fault, err := DecodeSoapResponse([]byte(`<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <GetPriceResponse xmlns="http://example.com/prices"><Price>1.5</Price></GetPriceResponse>
+  </soap:Body>
+</soap:Envelope>`))
+	assert.NoError(t, err)
+	assert.Nil(t, fault)
+	assert.Equal(t, `<GetPriceResponse xmlns="http://example.com/prices"><Price>1.5</Price></GetPriceResponse>`, string(content))
+
+	// SOAP 1.1 fault
+	_, fault, err = DecodeSoapResponse([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>
+<soap:Fault><faultcode>soap:Client</faultcode><faultstring>Invalid item</faultstring><faultactor>urn:prices</faultactor>
+<detail><error>unknown</error></detail></soap:Fault></soap:Body></soap:Envelope>`))
+	assert.NoError(t, err)
+	assert.Equal(t, &SoapFault{Code: "soap:Client", String: "Invalid item", Actor: "urn:prices", Detail: "<error>unknown</error>"}, fault)
+
+	// SOAP 1.2 fault
+	_, fault, err = DecodeSoapResponse([]byte(`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body>
+<env:Fault><env:Code><env:Value>env:Sender</env:Value></env:Code><env:Reason><env:Text xml:lang="en">Invalid item</env:Text></env:Reason>
+<env:Detail><error>unknown</error></env:Detail></env:Fault></env:Body></env:Envelope>`))
+	assert.NoError(t, err)
+	assert.Equal(t, &SoapFault{Code: "env:Sender", String: "Invalid item", Detail: "<error>unknown</error>"}, fault)
+
+	_, _, err = DecodeSoapResponse([]byte(`not xml`))
+	assert.Error(t, err)
+}
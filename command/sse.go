@@ -0,0 +1,155 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ContentTypeEventStream = "text/event-stream"
+
+// Default time to wait before reconnecting a server-sent events stream
+const DefaultSseRetry = 3 * time.Second
+
+// SseEvent is a single server-sent event
+//
+// Id    - last event id (it stays same for following events till server sends a new id)
+// Event - event type ("message" if server did not send it)
+// Data  - data lines of this event joined by "\n"
+// Retry - reconnection time sent by server with this event (0 if not sent)
+type SseEvent struct {
+	Id    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// SseStream gives events of a server-sent events api. It reconnects automatically (with "Last-Event-ID" header) if
+// the connection is lost
+type SseStream interface {
+
+	// Next blocks till the next event is received. Returns io.EOF when stream is closed (by Close, or by server with a
+	// 204 response on reconnect), or an error if it can't reconnect
+	Next() (*SseEvent, error)
+
+	// LastEventId gives the last event id received from server
+	LastEventId() string
+
+	// Close closes the stream - a blocked Next returns io.EOF
+	Close() error
+}
+
+// SseReader parses a server-sent events stream (https://html.spec.whatwg.org/multipage/server-sent-events.html)
+type SseReader struct {
+	scanner     *bufio.Scanner
+	lastEventId string
+	retry       time.Duration
+}
+
+// Next gives the next event from the stream. Returns io.EOF at the end of stream
+func (r *SseReader) Next() (*SseEvent, error) {
+	event := &SseEvent{}
+	data := strings.Builder{}
+	hasData := false
+
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+
+		// Blank line dispatches the event - event without data is not dispatched
+		if line == "" {
+			if !hasData {
+				event = &SseEvent{}
+				continue
+			}
+			event.Id = r.lastEventId
+			event.Data = data.String()
+			if event.Event == "" {
+				event.Event = "message"
+			}
+			return event, nil
+		}
+
+		// Comment
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			if hasData {
+				data.WriteString("\n")
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				r.lastEventId = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 && strings.Trim(value, "0123456789") == "" {
+				r.retry = time.Duration(ms) * time.Millisecond
+				event.Retry = r.retry
+			}
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// LastEventId gives the last event id sent by server - to be sent in "Last-Event-ID" header on reconnect
+func (r *SseReader) LastEventId() string {
+	return r.lastEventId
+}
+
+// Retry gives the last reconnection time sent by server (0 if not sent)
+func (r *SseReader) Retry() time.Duration {
+	return r.retry
+}
+
+// NewSseReader creates a reader to read events from a server-sent events stream
+func NewSseReader(reader io.Reader) *SseReader {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	scanner.Split(scanSseLines)
+	return &SseReader{scanner: scanner}
+}
+
+// Split lines on "\r\n", "\n" or "\r"
+func scanSseLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// "\r" - need one more byte to know if it is "\r\n"
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
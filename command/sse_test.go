@@ -0,0 +1,60 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSseReader(t *testing.T) {
+	stream := ": comment\n" +
+		"retry: 100\n" +
+		"\n" +
+		"id: 1\n" +
+		"data: first\n" +
+		"data:  second line\n" +
+		"\n" +
+		"event: update\r\n" +
+		"data: {\"id\": 2}\r\n" +
+		"\r\n" +
+		"id: 3\r" +
+		"data\r" +
+		"\r" +
+		"data: partial event is dropped"
+
+	reader := NewSseReader(strings.NewReader(stream))
+
+	event, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, &SseEvent{Id: "1", Event: "message", Data: "first\n second line"}, event)
+	assert.Equal(t, 100*time.Millisecond, reader.Retry())
+
+	// Id is same as last id if event does not have it
+	event, err = reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, &SseEvent{Id: "1", Event: "update", Data: `{"id": 2}`}, event)
+
+	event, err = reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, &SseEvent{Id: "3", Event: "message", Data: ""}, event)
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "3", reader.LastEventId())
+}
+
+func TestSseReader_RetryInEvent(t *testing.T) {
+	reader := NewSseReader(strings.NewReader("retry: 2000\ndata: a\n\nretry: abc\ndata: b\n\n"))
+
+	event, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Second, event.Retry)
+
+	// Invalid retry is ignored
+	event, err = reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), event.Retry)
+	assert.Equal(t, 2*time.Second, reader.Retry())
+}
@@ -0,0 +1,64 @@
+package command
+
+import (
+	"encoding/json"
+	"github.com/devlibx/gox-base/errors"
+	"io"
+)
+
+// StreamDecoder decodes one value at a time from a streaming response body. Next returns io.EOF when there are no
+// more values
+type StreamDecoder interface {
+	Next(out interface{}) error
+}
+
+type ndJsonDecoder struct {
+	decoder *json.Decoder
+}
+
+func (d *ndJsonDecoder) Next(out interface{}) error {
+	return d.decoder.Decode(out)
+}
+
+// NewNdJsonDecoder gives a decoder for newline delimited JSON (one JSON value per line)
+func NewNdJsonDecoder(reader io.Reader) StreamDecoder {
+	return &ndJsonDecoder{decoder: json.NewDecoder(reader)}
+}
+
+type jsonArrayDecoder struct {
+	decoder *json.Decoder
+	started bool
+	done    bool
+}
+
+func (d *jsonArrayDecoder) Next(out interface{}) error {
+	if d.done {
+		return io.EOF
+	}
+
+	// Consume the opening "[" before the first element
+	if !d.started {
+		token, err := d.decoder.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return errors.New("expected response to be a JSON array, got token=%v", token)
+		}
+		d.started = true
+	}
+
+	if !d.decoder.More() {
+		d.done = true
+		if _, err := d.decoder.Token(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	return d.decoder.Decode(out)
+}
+
+// NewJsonArrayDecoder gives a decoder which reads elements of a top-level JSON array one by one
+func NewJsonArrayDecoder(reader io.Reader) StreamDecoder {
+	return &jsonArrayDecoder{decoder: json.NewDecoder(reader)}
+}
@@ -0,0 +1,50 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+type streamItem struct {
+	Id int `json:"id"`
+}
+
+func TestNdJsonDecoder(t *testing.T) {
+	decoder := NewNdJsonDecoder(strings.NewReader("{\"id\": 1}\n{\"id\": 2}\n\n{\"id\": 3}\n"))
+	ids := make([]int, 0)
+	for {
+		item := streamItem{}
+		err := decoder.Next(&item)
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		ids = append(ids, item.Id)
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+}
+
+func TestJsonArrayDecoder(t *testing.T) {
+	decoder := NewJsonArrayDecoder(strings.NewReader(`[{"id": 1}, {"id": 2}, {"id": 3}]`))
+	ids := make([]int, 0)
+	for {
+		item := streamItem{}
+		err := decoder.Next(&item)
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		ids = append(ids, item.Id)
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+
+	// Empty array
+	err := NewJsonArrayDecoder(strings.NewReader(`[]`)).Next(&streamItem{})
+	assert.Equal(t, io.EOF, err)
+
+	// Not an array
+	err = NewJsonArrayDecoder(strings.NewReader(`{"id": 1}`)).Next(&streamItem{})
+	assert.Error(t, err)
+}
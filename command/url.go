@@ -0,0 +1,215 @@
+package command
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/errors"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var pathParamRegex = regexp.MustCompile(`{([^{}/]+)}`)
+
+// GetBaseUrl gives "scheme://host[:port][/base_path]" of the server - port is omitted if it is the default port of the
+// scheme. If "url" is set, it is used instead of host, port and https
+func (s *Server) GetBaseUrl() (string, error) {
+	scheme, host, port, basePath := "http", s.Host, s.Port, s.BasePath
+	if s.Https {
+		scheme = "https"
+	}
+	if s.Url != "" {
+		u, err := url.Parse(s.Url)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to parse url of server=%s", s.Name)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.RawQuery != "" || u.Fragment != "" {
+			return "", errors.New("url of server must be a http or https url without query: server=%s, url=%s", s.Name, s.Url)
+		}
+		scheme, host, port = u.Scheme, u.Hostname(), 0
+		if u.Port() != "" {
+			if port, err = strconv.Atoi(u.Port()); err != nil {
+				return "", errors.Wrap(err, "failed to parse port in url of server=%s", s.Name)
+			}
+		}
+		basePath = joinUrlPath(u.EscapedPath(), s.BasePath)
+	}
+
+	// IPv6 literals must be in [] - host can be given with or without them
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if port <= 0 || (scheme == "http" && port == 80) || (scheme == "https" && port == 443) {
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+	} else {
+		host = net.JoinHostPort(host, strconv.Itoa(port))
+	}
+	return scheme + "://" + host + joinUrlPath(basePath, ""), nil
+}
+
+// GetRequestUrl gives the url to call for a request - it is server base url + api path, unless request overrides the
+// path or full url (only allowed if api has "allow_url_override")
+func (a *Api) GetRequestUrl(server *Server, request *GoxRequest) (string, error) {
+	return a.GetRequestUrlWithContext(context.Background(), server, request)
+}
+
+// GetRequestUrlWithContext is same as GetRequestUrl, but it also allows the url of the request to be the next page
+// link which is set by the page iterator in the context (even if api does not have "allow_url_override")
+func (a *Api) GetRequestUrlWithContext(ctx context.Context, server *Server, request *GoxRequest) (string, error) {
+	if link, ok := ctx.Value(nextPageLinkKey{}).(string); ok && link != "" && request != nil && request.Url == link {
+		return a.getNextPageUrl(server, link)
+	}
+
+	if request == nil || (request.Url == "" && request.Path == "") {
+		return a.GetPath(server), nil
+	}
+
+	var err error
+	if !a.AllowUrlOverride {
+		err = errors.New("request can not override url or path of api=%s - set allow_url_override to allow it", a.Name)
+	} else if request.Url != "" {
+		if u, e := url.Parse(request.Url); e != nil {
+			err = errors.Wrap(e, "failed to parse url of request: api=%s", a.Name)
+		} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			err = errors.New("url of request must be a http or https url: api=%s, url=%s", a.Name, request.Url)
+		} else {
+			return request.Url, nil
+		}
+	} else {
+		base, e := server.GetBaseUrl()
+		if e != nil {
+			return "", e
+		}
+		return base + joinUrlPath("", request.Path), nil
+	}
+	return "", &GoxHttpError{
+		Err:        err,
+		StatusCode: http.StatusBadRequest,
+		Message:    err.Error(),
+		ErrorCode:  ErrorCodeFailedToBuildRequest,
+	}
+}
+
+// Next page link given by the server - a relative link is resolved against the api url. Link must be on the same
+// scheme and host as the server, otherwise auth and headers of the server would be sent to some other host
+func (a *Api) getNextPageUrl(server *Server, link string) (string, error) {
+	base, err := server.GetBaseUrl()
+	if err != nil {
+		return "", err
+	}
+	baseUrl, err := url.Parse(base)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse url of server=%s", server.Name)
+	}
+	apiUrl, err := url.Parse(a.GetPath(server))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse url of api=%s", a.Name)
+	}
+	nextUrl, err := url.Parse(link)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse next link: api=%s, link=%s", a.Name, link)
+	}
+
+	// Query of the link is sent as query params of the request
+	nextUrl = apiUrl.ResolveReference(nextUrl)
+	nextUrl.RawQuery, nextUrl.ForceQuery, nextUrl.Fragment = "", false, ""
+	if !strings.EqualFold(nextUrl.Scheme, baseUrl.Scheme) || !strings.EqualFold(nextUrl.Hostname(), baseUrl.Hostname()) || urlPort(nextUrl) != urlPort(baseUrl) {
+		err = errors.New("next link must be on the same scheme and host as server: api=%s, server=%s, link=%s", a.Name, server.Name, link)
+		return "", &GoxHttpError{
+			Err:        err,
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+			ErrorCode:  ErrorCodeFailedToBuildRequest,
+		}
+	}
+	return nextUrl.String(), nil
+}
+
+// Port of the url - default port of the scheme if url does not have a port
+func urlPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if strings.EqualFold(u.Scheme, "https") {
+		return "443"
+	}
+	return "80"
+}
+
+// Join base path and path with a single "/" - result starts with "/" and does not end with "/" (unless path does)
+func joinUrlPath(base string, path string) string {
+	base = strings.TrimSuffix(base, "/")
+	if base != "" && !strings.HasPrefix(base, "/") {
+		base = "/" + base
+	}
+	if path == "" {
+		return base
+	}
+	return base + "/" + strings.TrimPrefix(path, "/")
+}
+
+// GetPathParamNames gives names of {name} path params in the path of this api
+func (a *Api) GetPathParamNames() []string {
+	return PathParamNames(a.Path)
+}
+
+// PathParamNames gives names of {name} path params in the given path (or url)
+func PathParamNames(path string) []string {
+	names := make([]string, 0)
+	seen := map[string]bool{}
+	for _, match := range pathParamRegex.FindAllStringSubmatch(path, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// ResolvePathParams gives the value of each path param - first value is used if a param has many values. Values are
+// escaped when they are put in the url. With "strict_url", it fails if a path param of the path (or url) used for the
+// request is missing, or a param which is not in it is given
+func (a *Api) ResolvePathParams(path string, params MultivaluedMap) (map[string]string, error) {
+	values := map[string]string{}
+	for name, v := range params {
+		if len(v) > 0 {
+			values[name] = v[0]
+		}
+	}
+	if !a.StrictUrl {
+		return values, nil
+	}
+
+	var missing, unknown, repeated []string
+	names := map[string]bool{}
+	for _, name := range PathParamNames(path) {
+		names[name] = true
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	for name, v := range params {
+		if !names[name] {
+			unknown = append(unknown, name)
+		} else if len(v) > 1 {
+			repeated = append(repeated, name)
+		}
+	}
+	if len(missing) == 0 && len(unknown) == 0 && len(repeated) == 0 {
+		return values, nil
+	}
+
+	sort.Strings(unknown)
+	sort.Strings(repeated)
+	err := errors.New("invalid path params: api=%s, path=%s, missing=[%s], unknown=[%s], repeated=[%s]",
+		a.Name, path, strings.Join(missing, ","), strings.Join(unknown, ","), strings.Join(repeated, ","))
+	return nil, &GoxHttpError{
+		Err:        err,
+		StatusCode: http.StatusBadRequest,
+		Message:    err.Error(),
+		ErrorCode:  ErrorCodeFailedToBuildRequest,
+	}
+}
@@ -0,0 +1,80 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestApi_ResolvePathParams(t *testing.T) {
+	api := &Api{Name: "getOrder", Path: "/users/{user}/orders/{order}/items/{order}"}
+	assert.Equal(t, []string{"user", "order"}, api.GetPathParamNames())
+
+	// Not strict - first value is used and params are not validated
+	values, err := api.ResolvePathParams(api.Path, MultivaluedMap{"user": {"1", "2"}, "other": {"3"}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"user": "1", "other": "3"}, values)
+
+	api.StrictUrl = true
+	values, err = api.ResolvePathParams(api.Path, MultivaluedMap{"user": {"1"}, "order": {"a/b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"user": "1", "order": "a/b"}, values)
+
+	_, err = api.ResolvePathParams(api.Path, MultivaluedMap{"user": {"1", "2"}, "other": {"3"}})
+	goxErr, ok := err.(*GoxHttpError)
+	assert.True(t, ok)
+	assert.Equal(t, ErrorCodeFailedToBuildRequest, goxErr.ErrorCode)
+	assert.Equal(t, "invalid path params: api=getOrder, path=/users/{user}/orders/{order}/items/{order}, missing=[order], unknown=[other], repeated=[user]", goxErr.Message)
+}
+
+func TestServer_GetBaseUrl(t *testing.T) {
+	tests := []struct {
+		server   *Server
+		expected string
+	}{
+		{&Server{Host: "localhost", Port: 80}, "http://localhost"},
+		{&Server{Host: "localhost", Port: 8080}, "http://localhost:8080"},
+		{&Server{Host: "example.com", Port: 443, Https: true, BasePath: "v2/payments/"}, "https://example.com/v2/payments"},
+		{&Server{Host: "example.com", Port: 80, Https: true}, "https://example.com:80"},
+		{&Server{Host: "::1", Port: 8080}, "http://[::1]:8080"},
+		{&Server{Host: "[::1]", Port: 80}, "http://[::1]"},
+		{&Server{Url: "https://example.com:443/v2/payments/", Host: "ignored", Port: 9000}, "https://example.com/v2/payments"},
+		{&Server{Url: "http://[::1]:9123", BasePath: "/v2"}, "http://[::1]:9123/v2"},
+	}
+	for _, test := range tests {
+		baseUrl, err := test.server.GetBaseUrl()
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, baseUrl)
+	}
+
+	_, err := (&Server{Name: "bad", Url: "example.com/v2"}).GetBaseUrl()
+	assert.Error(t, err)
+	_, err = (&Server{Name: "bad", Url: "http://example.com/v2?a=b"}).GetBaseUrl()
+	assert.Error(t, err)
+}
+
+func TestApi_GetRequestUrl(t *testing.T) {
+	server := &Server{Url: "https://example.com/v2"}
+	api := &Api{Name: "getUser", Path: "/users/{id}"}
+
+	u, err := api.GetRequestUrl(server, &GoxRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/v2/users/{id}", u)
+
+	// Override is not allowed
+	_, err = api.GetRequestUrl(server, &GoxRequest{Path: "/accounts"})
+	goxErr, ok := err.(*GoxHttpError)
+	assert.True(t, ok)
+	assert.Equal(t, ErrorCodeFailedToBuildRequest, goxErr.ErrorCode)
+
+	api.AllowUrlOverride = true
+	u, err = api.GetRequestUrl(server, &GoxRequest{Path: "accounts/{id}"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/v2/accounts/{id}", u)
+
+	u, err = api.GetRequestUrl(server, &GoxRequest{Path: "/ignored", Url: "http://other.com/users"})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://other.com/users", u)
+
+	_, err = api.GetRequestUrl(server, &GoxRequest{Url: "/users"})
+	assert.Error(t, err)
+}
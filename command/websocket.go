@@ -0,0 +1,41 @@
+package command
+
+import (
+	"context"
+	"time"
+)
+
+// WebsocketConnection is a managed WebSocket connection. It reconnects automatically (with backoff) if the connection
+// is lost, and keeps the connection alive using ping/pong.
+//
+// Messages sent by server while connection was down are lost - callers should handle it at message level if needed.
+// Received messages are buffered (64 messages); when the buffer is full, the connection is not read till the caller
+// receives a message.
+type WebsocketConnection interface {
+
+	// Send a text message. Waits for the connection if it is reconnecting. Write must finish before ctx deadline (or api
+	// timeout). A message which failed to write is not sent again - error is returned and the caller may send it again
+	// (server may get it twice)
+	Send(ctx context.Context, data []byte) error
+
+	// SendJson sends the object as a JSON text message
+	SendJson(ctx context.Context, in interface{}) error
+
+	// Receive blocks till the next message is received. Returns io.EOF once connection is closed
+	Receive(ctx context.Context) ([]byte, error)
+
+	// ReceiveJson receives the next message and decodes it (JSON) into out
+	ReceiveJson(ctx context.Context, out interface{}) error
+
+	// Close sends a close frame and closes the connection. Connection is also closed when the context used to open it
+	// is done
+	Close() error
+}
+
+// Defaults used if websocket config is created from code
+const (
+	DefaultWebsocketPingInterval      = 30 * time.Second
+	DefaultWebsocketPongTimeout       = 10 * time.Second
+	DefaultWebsocketReconnectInitWait = 500 * time.Millisecond
+	DefaultWebsocketReconnectMaxWait  = 30 * time.Second
+)
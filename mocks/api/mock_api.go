@@ -35,6 +35,22 @@ func (m *MockGoxHttpContext) EXPECT() *MockGoxHttpContextMockRecorder {
 	return m.recorder
 }
 
+// AddInterceptor mocks base method.
+func (m *MockGoxHttpContext) AddInterceptor(interceptors ...command.Interceptor) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range interceptors {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "AddInterceptor", varargs...)
+}
+
+// AddInterceptor indicates an expected call of AddInterceptor.
+func (mr *MockGoxHttpContextMockRecorder) AddInterceptor(interceptors ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddInterceptor", reflect.TypeOf((*MockGoxHttpContext)(nil).AddInterceptor), interceptors...)
+}
+
 // Execute mocks base method.
 func (m *MockGoxHttpContext) Execute(ctx context.Context, api string, request *command.GoxRequest) (*command.GoxResponse, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +66,26 @@ func (mr *MockGoxHttpContextMockRecorder) Execute(ctx, api, request interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Execute", reflect.TypeOf((*MockGoxHttpContext)(nil).Execute), ctx, api, request)
 }
 
+// ExecuteAll mocks base method.
+func (m *MockGoxHttpContext) ExecuteAll(ctx context.Context, options *command.BatchOptions, calls ...*command.BatchCall) (*command.BatchResult, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, options}
+	for _, a := range calls {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecuteAll", varargs...)
+	ret0, _ := ret[0].(*command.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteAll indicates an expected call of ExecuteAll.
+func (mr *MockGoxHttpContextMockRecorder) ExecuteAll(ctx, options interface{}, calls ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, options}, calls...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteAll", reflect.TypeOf((*MockGoxHttpContext)(nil).ExecuteAll), varargs...)
+}
+
 // ExecuteAsync mocks base method.
 func (m *MockGoxHttpContext) ExecuteAsync(ctx context.Context, api string, request *command.GoxRequest) chan *command.GoxResponse {
 	m.ctrl.T.Helper()
@@ -64,6 +100,66 @@ func (mr *MockGoxHttpContextMockRecorder) ExecuteAsync(ctx, api, request interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteAsync", reflect.TypeOf((*MockGoxHttpContext)(nil).ExecuteAsync), ctx, api, request)
 }
 
+// ExecuteSse mocks base method.
+func (m *MockGoxHttpContext) ExecuteSse(ctx context.Context, api string, request *command.GoxRequest) (command.SseStream, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteSse", ctx, api, request)
+	ret0, _ := ret[0].(command.SseStream)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteSse indicates an expected call of ExecuteSse.
+func (mr *MockGoxHttpContextMockRecorder) ExecuteSse(ctx, api, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteSse", reflect.TypeOf((*MockGoxHttpContext)(nil).ExecuteSse), ctx, api, request)
+}
+
+// ExecuteStream mocks base method.
+func (m *MockGoxHttpContext) ExecuteStream(ctx context.Context, api string, request *command.GoxRequest) (*command.GoxResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteStream", ctx, api, request)
+	ret0, _ := ret[0].(*command.GoxResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteStream indicates an expected call of ExecuteStream.
+func (mr *MockGoxHttpContextMockRecorder) ExecuteStream(ctx, api, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteStream", reflect.TypeOf((*MockGoxHttpContext)(nil).ExecuteStream), ctx, api, request)
+}
+
+// OpenWebsocket mocks base method.
+func (m *MockGoxHttpContext) OpenWebsocket(ctx context.Context, api string, request *command.GoxRequest) (command.WebsocketConnection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenWebsocket", ctx, api, request)
+	ret0, _ := ret[0].(command.WebsocketConnection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenWebsocket indicates an expected call of OpenWebsocket.
+func (mr *MockGoxHttpContextMockRecorder) OpenWebsocket(ctx, api, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenWebsocket", reflect.TypeOf((*MockGoxHttpContext)(nil).OpenWebsocket), ctx, api, request)
+}
+
+// Paginate mocks base method.
+func (m *MockGoxHttpContext) Paginate(ctx context.Context, api string, request *command.GoxRequest) (command.PageIterator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Paginate", ctx, api, request)
+	ret0, _ := ret[0].(command.PageIterator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Paginate indicates an expected call of Paginate.
+func (mr *MockGoxHttpContextMockRecorder) Paginate(ctx, api, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Paginate", reflect.TypeOf((*MockGoxHttpContext)(nil).Paginate), ctx, api, request)
+}
+
 // ReloadApi mocks base method.
 func (m *MockGoxHttpContext) ReloadApi(apiToReload string) error {
 	m.ctrl.T.Helper()
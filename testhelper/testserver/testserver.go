@@ -0,0 +1,33 @@
+package testserver
+
+import (
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/devlibx/gox-base"
+	"github.com/devlibx/gox-base/serialization"
+	"github.com/devlibx/gox-http/command"
+	httpCommand "github.com/devlibx/gox-http/command/http"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// ServerName is the server in test configs which is pointed to the test server
+const ServerName = "testServer"
+
+// Start starts a test server with the given handler, and reads the config with url of "testServer" set to this server.
+// Hystrix state is reset so that every test starts with a closed circuit. Returned func stops the test server
+func Start(t *testing.T, configYaml string, handler http.Handler) (*command.Config, func()) {
+	httpCommand.HystrixConfigMap = gox.StringObjectMap{}
+	hystrix.Flush()
+
+	ts := httptest.NewServer(handler)
+
+	config := command.Config{}
+	err := serialization.ReadYamlFromString(configYaml, &config)
+	assert.NoError(t, err)
+	if server, ok := config.Servers[ServerName]; assert.True(t, ok, "config must have server %s", ServerName) {
+		server.Url = ts.URL
+	}
+	return &config, ts.Close
+}